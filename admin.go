@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Broadcasts are paced well below the global send rate so reminders due
+// meanwhile still go out on time.
+const broadcastRate = 10
+
+// adminChatID is the operators' chat, set with ADMIN_CHAT_ID. Everyone in it
+// may use admin commands, as may the ADMIN_IDS users anywhere.
+var adminChatID int64
+
+// checkAdmin reports whether admin commands are allowed here, telling the
+// chat off if they're not.
+func checkAdmin(chatID int64, userID int64, bot BotClient) bool {
+	if isAdmin(userID) || (adminChatID != 0 && chatID == adminChatID) {
+		return true
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.admin_only"))
+	send(bot, msg)
+
+	return false
+}
+
+func handleAdminStats(chatID int64, userID int64, bot BotClient) {
+	if !checkAdmin(chatID, userID, bot) {
+		return
+	}
+
+	var chats, removed, todos, reminders, recurring int
+	var stats DeliveryStats
+	for _, userData := range todoData {
+		chats++
+		if userData.RemovedFromChat {
+			removed++
+		}
+		todos += todoCount(userData)
+		reminders += len(userData.Reminders)
+		for _, reminder := range userData.Reminders {
+			if reminder.Recurring {
+				recurring++
+			}
+		}
+		stats.Delivered += userData.DeliveryStats.Delivered
+		stats.Failed += userData.DeliveryStats.Failed
+	}
+
+	text := tr(chatID, "admin.stats", chats, removed, todos, reminders, recurring,
+		stats.Delivered, stats.Failed, stats.SuccessRate()*100)
+	if maintenance {
+		text += "\n" + tr(chatID, "admin.stats_held", len(heldReminders))
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+}
+
+// handleBroadcast sends text to every chat the bot is still in. Sending
+// runs in the background without holding dataMu, and the admin gets a
+// report once it's done.
+func handleBroadcast(chatID int64, userID int64, text string, bot BotClient) {
+	if !checkAdmin(chatID, userID, bot) {
+		return
+	}
+	if text == "" {
+		sendUsage(chatID, "broadcast", bot)
+		return
+	}
+
+	var targets []int64
+	for target, userData := range todoData {
+		if !userData.RemovedFromChat {
+			targets = append(targets, target)
+		}
+	}
+	send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "admin.broadcast_started", len(targets))))
+
+	report := tr(chatID, "admin.broadcast_done")
+	go func() {
+		limiter := NewRateLimiter(broadcastRate, 1)
+		delivered := 0
+		for _, target := range targets {
+			limiter.Wait()
+			if _, err := sendDirect(bot, tgbotapi.NewMessage(target, text)); err != nil {
+				chatLog(target).Error("Failed to broadcast", "err", err)
+				continue
+			}
+			delivered++
+		}
+		sendDirect(bot, tgbotapi.NewMessage(chatID, fmt.Sprintf(report, delivered, len(targets))))
+	}()
+}
+
+// handleBackup sends the admin all user data in the format of the json
+// storage backend, whichever backend is in use, so it can be restored with
+// STORAGE_BACKEND=json.
+func handleBackup(chatID int64, userID int64, bot BotClient) {
+	if !checkAdmin(chatID, userID, bot) {
+		return
+	}
+
+	raw, err := json.Marshal(todoData)
+	if err != nil {
+		slog.Error("Failed to encode user data", "err", err)
+		return
+	}
+
+	name := fmt.Sprintf("userdata-%s.json", time.Now().UTC().Format("2006-01-02T150405"))
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: name, Bytes: raw})
+	doc.Caption = tr(chatID, "admin.backup", len(todoData))
+	send(bot, doc)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type AgendaItem struct {
+	Time time.Time
+	Icon string
+	Text string
+}
+
+// agendaItems merges the chat's reminders and todos into one list ordered
+// by time. Todos without a due date come last, in list order.
+func agendaItems(userData *UserData, now time.Time) []AgendaItem {
+	var dated, undated []AgendaItem
+	for _, reminder := range userData.Reminders {
+		if !reminder.Recurring {
+			dated = append(dated, AgendaItem{Time: reminder.Time, Icon: "🔔", Text: reminder.DisplayTitle()})
+			continue
+		}
+		next, err := nextFireTime(reminder, now)
+		if err != nil {
+			continue
+		}
+		dated = append(dated, AgendaItem{Time: next, Icon: "🔁", Text: reminder.DisplayTitle()})
+	}
+	for _, todo := range userData.Todos {
+		if todo.Due == nil {
+			undated = append(undated, AgendaItem{Icon: "📝", Text: todo.Text})
+			continue
+		}
+		dated = append(dated, AgendaItem{Time: *todo.Due, Icon: "📝", Text: todo.Text})
+	}
+
+	sort.SliceStable(dated, func(i, j int) bool {
+		return dated[i].Time.Before(dated[j].Time)
+	})
+
+	return append(dated, undated...)
+}
+
+func handleAgenda(chatID int64, bot BotClient) {
+	var items []AgendaItem
+	if userData, exists := todoData[chatID]; exists {
+		items = agendaItems(userData, time.Now())
+	}
+	if len(items) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "agenda.empty"))
+		send(bot, msg)
+		return
+	}
+
+	var list string
+	for _, item := range items {
+		if item.Time.IsZero() {
+			list += fmt.Sprintf("%s %s\n", item.Icon, item.Text)
+			continue
+		}
+		list += fmt.Sprintf("%s %s — %s\n", item.Icon, formatTime(chatID, item.Time), item.Text)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "agenda.title", list))
+	send(bot, msg)
+}
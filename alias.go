@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Aliases may point at other aliases; expansion stops after this many steps
+// so that cycles can't loop forever.
+const maxAliasDepth = 5
+
+var aliasNamePattern = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// expandAlias rewrites a message starting with one of the chat's aliases
+// into the command it stands for, e.g. "/m tea" with m = "remind 30m"
+// becomes "/remind 30m tea". It returns false if the aliases form a cycle.
+func expandAlias(aliases map[string]string, message *tgbotapi.Message) bool {
+	if !message.IsCommand() {
+		return true
+	}
+
+	seen := make(map[string]bool)
+	for depth := 0; ; depth++ {
+		name := message.Command()
+		expansion, exists := aliases[name]
+		if !exists {
+			return true
+		}
+		if seen[name] || depth == maxAliasDepth {
+			return false
+		}
+		seen[name] = true
+
+		text := "/" + expansion
+		command, _, _ := strings.Cut(expansion, " ")
+		if args := message.CommandArguments(); args != "" {
+			text += " " + args
+		}
+		message.Text = text
+		message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command) + 1}}
+	}
+}
+
+func handleAlias(chatID int64, args string, bot BotClient) {
+	fields, expansion := cutFields(args, 1)
+	if len(fields) == 0 {
+		handleAliasList(chatID, bot)
+		return
+	}
+	name := strings.ToLower(fields[0])
+	expansion = strings.TrimPrefix(expansion, "/")
+	if expansion == "" || !aliasNamePattern.MatchString(name) {
+		sendUsage(chatID, "alias", bot)
+		return
+	}
+	if _, builtin := findCommand(name); builtin {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "alias.builtin", name))
+		send(bot, msg)
+		return
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	userData := todoData[chatID]
+
+	if expansion == "off" {
+		delete(userData.Aliases, name)
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "alias.removed", name)))
+	} else {
+		if userData.Aliases == nil {
+			userData.Aliases = make(map[string]string)
+		}
+		userData.Aliases[name] = expansion
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "alias.set", name, expansion)))
+	}
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func handleAliasList(chatID int64, bot BotClient) {
+	userData, exists := todoData[chatID]
+	if !exists || len(userData.Aliases) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "alias.empty")+" "+commandUsage(chatID, "alias"))
+		send(bot, msg)
+		return
+	}
+
+	names := make([]string, 0, len(userData.Aliases))
+	for name := range userData.Aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var list string
+	for _, name := range names {
+		list += fmt.Sprintf("/%s → /%s\n", name, userData.Aliases[name])
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "alias.title", list))
+	send(bot, msg)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// resolveAnchor resolves expressions like "workday+2h" or "lunch-15m"
+// against the chat's named anchor times. The anchor is taken today, or
+// tomorrow if the resulting time has already passed.
+func resolveAnchor(anchors map[string]int, expr string, now time.Time) (time.Time, bool, error) {
+	i := strings.IndexAny(expr, "+-")
+	name := expr
+	if i >= 0 {
+		name = expr[:i]
+	}
+
+	minutes, exists := anchors[name]
+	if !exists {
+		return time.Time{}, false, nil
+	}
+
+	var offset time.Duration
+	if i >= 0 {
+		duration, err := parseDuration(expr[i+1:])
+		if err != nil {
+			return time.Time{}, true, err
+		}
+		offset = duration
+		if expr[i] == '-' {
+			offset = -duration
+		}
+	}
+
+	t := time.Date(now.Year(), now.Month(), now.Day(), minutes/60, minutes%60, 0, 0, now.Location()).Add(offset)
+	if !t.After(now) {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return t, true, nil
+}
+
+// parseReminderTime turns the time argument of /remind into an absolute
+// time: an anchor expression, a clock time, a day phrase, a date or a
+// duration from now.
+func parseReminderTime(chatID int64, timeStr string, now time.Time) (time.Time, error) {
+	now = now.In(chatLocation(chatID))
+
+	if t, isBusiness, err := parseBusinessTime(chatID, timeStr, now); isBusiness {
+		return t, err
+	}
+
+	if userData, exists := todoData[chatID]; exists {
+		t, isAnchor, err := resolveAnchor(userData.Anchors, timeStr, now)
+		if isAnchor {
+			return t, err
+		}
+	}
+
+	if minutes, err := parseClock(timeStr); err == nil {
+		return nextClockTime(now, minutes), nil
+	}
+	if minutes, err := parseClock12(timeStr); err == nil {
+		return nextClockTime(now, minutes), nil
+	}
+	if t, isPhrase, err := parseDayPhrase(timeStr, now); isPhrase {
+		return t, err
+	}
+	if t, err := parseDateTime(timeStr, now.Location()); err == nil {
+		return t, nil
+	}
+
+	return addDuration(now, timeStr)
+}
+
+// nextClockTime returns the next time the clock shows the given minutes
+// after midnight, rolling over to tomorrow if that time has already passed.
+func nextClockTime(now time.Time, minutes int) time.Time {
+	t := time.Date(now.Year(), now.Month(), now.Day(), minutes/60, minutes%60, 0, 0, now.Location())
+	if !t.After(now) {
+		t = time.Date(now.Year(), now.Month(), now.Day()+1, minutes/60, minutes%60, 0, 0, now.Location())
+	}
+
+	return t
+}
+
+func handleAnchor(chatID int64, args string, bot BotClient) {
+	parts := strings.Fields(args)
+	if len(parts) == 0 {
+		handleAnchorList(chatID, bot)
+		return
+	}
+	if len(parts) != 2 || strings.ContainsAny(parts[0], "+-") {
+		sendUsage(chatID, "anchor", bot)
+		return
+	}
+	name := parts[0]
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	userData := todoData[chatID]
+
+	if parts[1] == "off" {
+		delete(userData.Anchors, name)
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "anchor.removed", name)))
+	} else {
+		minutes, err := parseClock(parts[1])
+		if err != nil {
+			msg := tgbotapi.NewMessage(chatID, tr(chatID, "anchor.invalid_time"))
+			send(bot, msg)
+			return
+		}
+		if userData.Anchors == nil {
+			userData.Anchors = make(map[string]int)
+		}
+		userData.Anchors[name] = minutes
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "anchor.set", name, parts[1], name)))
+	}
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func handleAnchorList(chatID int64, bot BotClient) {
+	userData, exists := todoData[chatID]
+	if !exists || len(userData.Anchors) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "anchor.empty")+" "+commandUsage(chatID, "anchor"))
+		send(bot, msg)
+		return
+	}
+
+	names := make([]string, 0, len(userData.Anchors))
+	for name := range userData.Anchors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var list string
+	for _, name := range names {
+		minutes := userData.Anchors[name]
+		list += fmt.Sprintf("%s — %02d:%02d\n", name, minutes/60, minutes%60)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "anchor.title", list))
+	send(bot, msg)
+}
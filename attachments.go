@@ -0,0 +1,106 @@
+package main
+
+import (
+	"log/slog"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Telegram limits media captions to 1024 characters.
+const maxCaptionLength = 1024
+
+// Media types a reminder can carry. Reminders saved before other media were
+// supported have no type and hold a document.
+const (
+	mediaDocument = "document"
+	mediaPhoto    = "photo"
+	mediaVoice    = "voice"
+	mediaAudio    = "audio"
+	mediaVideo    = "video"
+)
+
+// replyMedia returns the type and file ID of the media in message, if any.
+// Of a photo's sizes the largest is kept.
+func replyMedia(message *tgbotapi.Message) (string, string, bool) {
+	switch {
+	case message.Document != nil:
+		return mediaDocument, message.Document.FileID, true
+	case len(message.Photo) > 0:
+		return mediaPhoto, message.Photo[len(message.Photo)-1].FileID, true
+	case message.Voice != nil:
+		return mediaVoice, message.Voice.FileID, true
+	case message.Audio != nil:
+		return mediaAudio, message.Audio.FileID, true
+	case message.Video != nil:
+		return mediaVideo, message.Video.FileID, true
+	}
+
+	return "", "", false
+}
+
+// attachReplyMedia makes the reminder carry the document, photo, voice
+// note, audio or video the command replied to. Without text of its own the
+// reminder takes the media's caption, the document's name, or a label for
+// the kind of media.
+func attachReplyMedia(message *tgbotapi.Message, reminder *Reminder) {
+	reply := message.ReplyToMessage
+	if reply == nil {
+		return
+	}
+	mediaType, fileID, ok := replyMedia(reply)
+	if !ok {
+		return
+	}
+
+	reminder.MediaType = mediaType
+	reminder.MediaFileID = fileID
+	if reminder.Content == "" {
+		reminder.Content = reply.Caption
+	}
+	if reminder.Content == "" && reply.Document != nil {
+		reminder.Content = reply.Document.FileName
+	}
+	if reminder.Content == "" {
+		reminder.Content = tr(message.Chat.ID, "media."+mediaType)
+	}
+}
+
+// mediaMessage builds the message re-sending the reminder's media with
+// caption as its caption.
+func mediaMessage(reminder Reminder, msg tgbotapi.MessageConfig) tgbotapi.Chattable {
+	file := tgbotapi.FileID(reminder.MediaFileID)
+	base := tgbotapi.BaseFile{
+		BaseChat: tgbotapi.BaseChat{ChatID: msg.ChatID, ReplyMarkup: msg.ReplyMarkup},
+		File:     file,
+	}
+
+	switch reminder.MediaType {
+	case mediaPhoto:
+		return tgbotapi.PhotoConfig{BaseFile: base, Caption: msg.Text, CaptionEntities: msg.Entities}
+	case mediaVoice:
+		return tgbotapi.VoiceConfig{BaseFile: base, Caption: msg.Text, CaptionEntities: msg.Entities}
+	case mediaAudio:
+		return tgbotapi.AudioConfig{BaseFile: base, Caption: msg.Text, CaptionEntities: msg.Entities}
+	case mediaVideo:
+		return tgbotapi.VideoConfig{BaseFile: base, Caption: msg.Text, CaptionEntities: msg.Entities}
+	}
+
+	return tgbotapi.DocumentConfig{BaseFile: base, Caption: msg.Text, CaptionEntities: msg.Entities}
+}
+
+// sendReminderMessage sends msg, re-sending the reminder's media with msg as
+// its caption if it has any. If the file can't be sent any more, e.g.
+// because Telegram no longer knows its ID, the text is sent alone.
+func sendReminderMessage(bot BotClient, reminder Reminder, msg tgbotapi.MessageConfig) (tgbotapi.Message, error) {
+	if reminder.MediaFileID == "" || len([]rune(msg.Text)) > maxCaptionLength {
+		return send(bot, msg)
+	}
+
+	sent, err := send(bot, mediaMessage(reminder, msg))
+	if err == nil {
+		return sent, nil
+	}
+
+	slog.Warn("Failed to send reminder media, sending text only", "reminder_id", reminder.ID, "media_type", reminder.MediaType, "err", err)
+	return send(bot, msg)
+}
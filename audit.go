@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	defaultAuditLogSize = 50
+	maxAuditSummary     = 100
+)
+
+var auditLogSize = defaultAuditLogSize
+
+type AuditEntry struct {
+	Command string    `json:"command"`
+	At      time.Time `json:"at"`
+	Summary string    `json:"summary,omitempty"`
+}
+
+// recordAction appends a command to the chat's audit log, dropping the oldest
+// entries beyond auditLogSize.
+func recordAction(chatID int64, command string, args string, at time.Time) {
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	userData := todoData[chatID]
+
+	summary := []rune(args)
+	if len(summary) > maxAuditSummary {
+		summary = append(summary[:maxAuditSummary-1], '…')
+	}
+
+	userData.AuditLog = append(userData.AuditLog, AuditEntry{Command: command, At: at, Summary: string(summary)})
+	if len(userData.AuditLog) > auditLogSize {
+		userData.AuditLog = userData.AuditLog[len(userData.AuditLog)-auditLogSize:]
+	}
+}
+
+func handleAuditLog(chatID int64, bot BotClient) {
+	userData, exists := todoData[chatID]
+	if !exists || len(userData.AuditLog) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "audit.empty"))
+		send(bot, msg)
+		return
+	}
+
+	var list string
+	for _, entry := range userData.AuditLog {
+		list += fmt.Sprintf("%s /%s %s\n", formatTime(chatID, entry.At), entry.Command, entry.Summary)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "audit.title", list))
+	send(bot, msg)
+}
+
+type logLine struct {
+	At   time.Time
+	Text string
+}
+
+// renderActivityLog lists the chat's recorded commands and fired reminders
+// in chronological order, one per line.
+func renderActivityLog(userData *UserData, loc *time.Location) string {
+	var lines []logLine
+	for _, entry := range userData.AuditLog {
+		lines = append(lines, logLine{At: entry.At, Text: strings.TrimSpace(fmt.Sprintf("/%s %s", entry.Command, entry.Summary))})
+	}
+	for _, fired := range userData.History {
+		lines = append(lines, logLine{At: fired.FiredAt, Text: "🔔 " + fired.Reminder.Content})
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].At.Before(lines[j].At)
+	})
+
+	var b strings.Builder
+	for _, line := range lines {
+		fmt.Fprintf(&b, "%s %s\n", line.At.In(loc).Format(time.RFC3339), line.Text)
+	}
+
+	return b.String()
+}
+
+func handleLogExport(chatID int64, bot BotClient) {
+	userData, exists := todoData[chatID]
+	if !exists || len(userData.AuditLog)+len(userData.History) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "audit.empty"))
+		send(bot, msg)
+		return
+	}
+
+	text := renderActivityLog(userData, chatLocation(chatID))
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: "mylog.txt", Bytes: []byte(text)})
+	send(bot, doc)
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const backupVersion = 1
+
+// Backup is the file /export sends and /import reads back. Only what makes
+// sense on another bot instance is included: message IDs and delivery
+// bookkeeping are left out, and so are history and audit entries.
+type Backup struct {
+	Version    int                       `json:"version"`
+	ExportedAt time.Time                 `json:"exported_at"`
+	Todos      []Todo                    `json:"todos"`
+	Lists      map[string][]Todo         `json:"lists,omitempty"`
+	Reminders  []Reminder                `json:"reminders"`
+	Templates  map[string][]TemplateItem `json:"templates,omitempty"`
+	Anchors    map[string]int            `json:"anchors,omitempty"`
+	Aliases    map[string]string         `json:"aliases,omitempty"`
+	Settings   Settings                  `json:"settings"`
+}
+
+// BackupResult counts what an import did. Conflicts are items the chat
+// already has, which are kept as they are.
+type BackupResult struct {
+	Todos     int
+	Reminders int
+	Conflicts int
+	Expired   int
+	OverLimit int
+}
+
+func newBackup(userData *UserData) Backup {
+	backup := Backup{
+		Version:    backupVersion,
+		ExportedAt: time.Now().UTC(),
+		Todos:      userData.Todos,
+		Lists:      userData.Lists,
+		Reminders:  make([]Reminder, 0, len(userData.Reminders)),
+		Templates:  userData.Templates,
+		Anchors:    userData.Anchors,
+		Aliases:    userData.Aliases,
+		Settings:   userData.Settings,
+	}
+	backup.Settings.CalendarToken = ""
+	backup.Settings.PendingEmail = nil
+	for _, reminder := range userData.Reminders {
+		reminder.CountdownMessageID = 0
+		reminder.SourceMessageID = 0
+		reminder.DeliveryAttempts = 0
+		backup.Reminders = append(backup.Reminders, reminder)
+	}
+
+	return backup
+}
+
+func handleExport(chatID int64, bot BotClient) {
+	userData, exists := todoData[chatID]
+	if !exists {
+		userData = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+
+	raw, err := json.MarshalIndent(newBackup(userData), "", "  ")
+	if err != nil {
+		chatLog(chatID).Error("Failed to encode backup", "err", err)
+		return
+	}
+
+	name := fmt.Sprintf("remindeer-%s.json", time.Now().In(chatLocation(chatID)).Format("2006-01-02"))
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: name, Bytes: raw})
+	doc.Caption = tr(chatID, "backup.exported", todoCount(userData), len(userData.Reminders))
+	send(bot, doc)
+}
+
+// parseBackup decodes and validates an /export file.
+func parseBackup(raw []byte) (Backup, error) {
+	var backup Backup
+	if err := json.Unmarshal(raw, &backup); err != nil {
+		return Backup{}, err
+	}
+	if backup.Version < 1 || backup.Version > backupVersion {
+		return Backup{}, fmt.Errorf("unsupported backup version %d", backup.Version)
+	}
+
+	todos := append([]Todo{}, backup.Todos...)
+	for name, list := range backup.Lists {
+		if !listNamePattern.MatchString(name) || reservedListNames[name] {
+			return Backup{}, fmt.Errorf("invalid list name %q", name)
+		}
+		todos = append(todos, list...)
+	}
+	for _, todo := range todos {
+		if strings.TrimSpace(todo.Text) == "" {
+			return Backup{}, fmt.Errorf("todo without text")
+		}
+	}
+
+	if backup.Settings.Timezone != "" {
+		if _, err := parseTimezone(backup.Settings.Timezone); err != nil {
+			return Backup{}, fmt.Errorf("invalid timezone %q", backup.Settings.Timezone)
+		}
+	}
+
+	for _, reminder := range backup.Reminders {
+		if reminder.Content == "" && reminder.Title == "" {
+			return Backup{}, fmt.Errorf("reminder %d without content", reminder.ID)
+		}
+		if isOnStart(reminder) {
+			continue
+		}
+		if reminder.Recurring {
+			if _, err := parseCron(reminder.Schedule); err != nil {
+				return Backup{}, fmt.Errorf("reminder %d: %v", reminder.ID, err)
+			}
+		} else if reminder.Time.IsZero() {
+			return Backup{}, fmt.Errorf("reminder %d without time", reminder.ID)
+		}
+	}
+
+	return backup, nil
+}
+
+func hasTodo(todos []Todo, text string) bool {
+	for _, todo := range todos {
+		if todo.Text == text {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasReminder(reminders []Reminder, reminder Reminder) bool {
+	for _, r := range reminders {
+		if r.DisplayTitle() != reminder.DisplayTitle() || r.Recurring != reminder.Recurring {
+			continue
+		}
+		if r.Recurring && r.Schedule == reminder.Schedule || !r.Recurring && r.Time.Equal(reminder.Time) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// dropForeignTargets removes the extra targets of imported reminders that
+// userID couldn't set with /remindto, and returns how many it removed.
+// Each chat is checked once.
+func dropForeignTargets(chatID int64, userID int64, backup *Backup, bot BotClient) int {
+	allowed := map[int64]bool{chatID: true}
+	dropped := 0
+	for i := range backup.Reminders {
+		var targets []int64
+		for _, target := range backup.Reminders[i].Targets {
+			ok, checked := allowed[target]
+			if !checked {
+				ok = isChatMember(target, userID, bot)
+				allowed[target] = ok
+			}
+			if ok {
+				targets = append(targets, target)
+			} else {
+				dropped++
+			}
+		}
+		backup.Reminders[i].Targets = targets
+	}
+
+	return dropped
+}
+
+// mergeBackup adds the backup to the chat's data. Todos with the same text
+// as one already on the list, reminders with the same title and time, and
+// names already taken are kept as they are in the chat. IDs are assigned
+// afresh, so links between todos and reminders are remapped.
+func mergeBackup(chatID int64, backup Backup, bot BotClient) BackupResult {
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	userData := todoData[chatID]
+	var result BackupResult
+
+	todoIDs := make(map[int]int)
+	addTodos := func(list []Todo, name string) {
+		for _, todo := range list {
+			existing := userData.Todos
+			if name != "" {
+				existing = userData.Lists[name]
+			}
+			if hasTodo(existing, todo.Text) {
+				result.Conflicts++
+				continue
+			}
+
+			oldID := todo.ID
+			todo.ReminderIDs = nil
+			todo.SourceMessageID = 0
+			userData.NextTodoID++
+			todo.ID = userData.NextTodoID
+			if oldID != 0 {
+				todoIDs[oldID] = todo.ID
+			}
+			if name == "" {
+				userData.Todos = append(userData.Todos, todo)
+			} else {
+				userData.Lists[name] = append(userData.Lists[name], todo)
+			}
+			result.Todos++
+		}
+	}
+	addTodos(backup.Todos, "")
+	for name, list := range backup.Lists {
+		if userData.Lists == nil {
+			userData.Lists = make(map[string][]Todo)
+		}
+		if _, exists := userData.Lists[name]; !exists {
+			if len(userData.Lists) >= maxTodoLists {
+				result.Conflicts += len(list)
+				continue
+			}
+			userData.Lists[name] = []Todo{}
+		}
+		addTodos(list, name)
+	}
+
+	now := time.Now()
+	for _, reminder := range backup.Reminders {
+		if !reminder.Recurring && reminder.Time.Before(now) {
+			result.Expired++
+			continue
+		}
+		if hasReminder(userData.Reminders, reminder) {
+			result.Conflicts++
+			continue
+		}
+
+		reminder.TodoID = todoIDs[reminder.TodoID]
+		reminder, err := addReminder(chatID, reminder)
+		if err != nil {
+			result.OverLimit++
+			continue
+		}
+		relinkReminder(userData, reminder)
+		scheduleReminder(chatID, reminder, bot)
+		result.Reminders++
+	}
+
+	result.Conflicts += mergeNamed(&userData.Templates, backup.Templates)
+	result.Conflicts += mergeNamed(&userData.Anchors, backup.Anchors)
+	result.Conflicts += mergeNamed(&userData.Aliases, backup.Aliases)
+
+	if userData.Settings.Timezone == "" {
+		userData.Settings.Timezone = backup.Settings.Timezone
+	}
+	if _, ok := catalogs[backup.Settings.Language]; ok && userData.Settings.Language == "" {
+		userData.Settings.Language = backup.Settings.Language
+	}
+
+	return result
+}
+
+// mergeNamed copies entries of src missing from dst and returns how many
+// were already taken.
+func mergeNamed[V any](dst *map[string]V, src map[string]V) int {
+	conflicts := 0
+	for name, value := range src {
+		if _, exists := (*dst)[name]; exists {
+			conflicts++
+			continue
+		}
+		if *dst == nil {
+			*dst = make(map[string]V)
+		}
+		(*dst)[name] = value
+	}
+
+	return conflicts
+}
+
+func handleImport(chatID int64, userID int64, document *tgbotapi.Document, bot BotClient) {
+	if document == nil {
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "backup.send_file")))
+		return
+	}
+	if document.FileSize > maxImportSize {
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "backup.too_large")))
+		return
+	}
+
+	var raw []byte
+	var err error
+	unlocked(func() {
+		raw, err = downloadFile(bot, document.FileID, maxImportSize)
+	})
+	if err != nil {
+		chatLog(chatID).Error("Failed to download backup", "err", err)
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "backup.download_failed")))
+		return
+	}
+
+	backup, err := parseBackup(raw)
+	if err != nil {
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "backup.invalid", err)))
+		return
+	}
+
+	todos := len(backup.Todos)
+	for _, list := range backup.Lists {
+		todos += len(list)
+	}
+	reminders := len(backup.Reminders)
+	if userData, exists := todoData[chatID]; exists {
+		todos += todoCount(userData)
+		reminders += len(userData.Reminders)
+	}
+	if todos > maxTodosPerChat || reminders > maxRemindersPerChat {
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "backup.over_limit")))
+		return
+	}
+	if !checkQuota(chatID, len(raw), bot) {
+		return
+	}
+
+	dropped := dropForeignTargets(chatID, userID, &backup, bot)
+	result := mergeBackup(chatID, backup, bot)
+	text := tr(chatID, "backup.imported", result.Todos, result.Reminders, result.Conflicts, result.Expired)
+	if result.OverLimit > 0 {
+		text += "\n" + tr(chatID, "backup.over_limit_skipped", result.OverLimit)
+	}
+	if dropped > 0 {
+		text += "\n" + tr(chatID, "backup.targets_dropped", dropped)
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
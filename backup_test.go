@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeBackup(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 6001
+	soon := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	todoData[chatID] = &UserData{
+		Todos:          []Todo{{ID: 1, Text: "buy milk"}},
+		Reminders:      []Reminder{{ID: 1, Content: "call mom", Time: soon}},
+		NextTodoID:     1,
+		NextReminderID: 1,
+		Templates:      map[string][]TemplateItem{"morning": {{TimeStr: "1h", Content: "water"}}},
+		Settings:       Settings{Timezone: "Europe/Kyiv"},
+	}
+	backup := Backup{
+		Todos: []Todo{
+			{ID: 10, Text: "buy milk"},
+			{ID: 11, Text: "pay rent"},
+		},
+		Lists: map[string][]Todo{"work": {{ID: 12, Text: "report"}}},
+		Reminders: []Reminder{
+			{ID: 20, Content: "call mom", Time: soon},
+			{ID: 21, Content: "rent is due", Time: soon, TodoID: 11},
+			{ID: 22, Content: "long gone", Time: soon.Add(-48 * time.Hour)},
+			{ID: 23, Content: "standup", Recurring: true, Schedule: "0 10 * * 1-5"},
+		},
+		Templates: map[string][]TemplateItem{
+			"morning": {{TimeStr: "2h", Content: "coffee"}},
+			"evening": {{TimeStr: "1h", Content: "read"}},
+		},
+		Settings: Settings{Timezone: "America/New_York", Language: "en"},
+	}
+
+	result := mergeBackup(chatID, backup, bot)
+	want := BackupResult{Todos: 2, Reminders: 2, Conflicts: 3, Expired: 1}
+	if result != want {
+		t.Errorf("result %+v, want %+v", result, want)
+	}
+
+	userData := todoData[chatID]
+	if len(userData.Todos) != 2 || userData.Todos[1].Text != "pay rent" || userData.Todos[1].ID != 2 {
+		t.Errorf("todos %+v, want pay rent added as #2", userData.Todos)
+	}
+	if list := userData.Lists["work"]; len(list) != 1 || list[0].ID != 3 {
+		t.Errorf("list work %+v, want report as #3", list)
+	}
+
+	// The reminder linked to "pay rent" follows it to its new ID.
+	var rent Reminder
+	for _, reminder := range userData.Reminders {
+		if reminder.Content == "rent is due" {
+			rent = reminder
+		}
+	}
+	if rent.TodoID != 2 {
+		t.Errorf("rent reminder linked to todo %d, want 2", rent.TodoID)
+	}
+	if ids := userData.Todos[1].ReminderIDs; len(ids) != 1 || ids[0] != rent.ID {
+		t.Errorf("pay rent has reminders %v, want [%d]", ids, rent.ID)
+	}
+
+	if got := userData.Templates["morning"][0].Content; got != "water" {
+		t.Errorf("template morning overwritten with %q", got)
+	}
+	if _, ok := userData.Templates["evening"]; !ok {
+		t.Error("template evening not imported")
+	}
+	if userData.Settings.Timezone != "Europe/Kyiv" || userData.Settings.Language != "en" {
+		t.Errorf("settings %+v, want the chat's timezone kept and the language filled in", userData.Settings)
+	}
+}
+
+func TestMergeBackupIsIdempotent(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 6002
+	backup := Backup{
+		Todos:     []Todo{{ID: 1, Text: "buy milk"}},
+		Reminders: []Reminder{{ID: 1, Content: "call mom", Time: time.Now().Add(time.Hour)}},
+	}
+
+	mergeBackup(chatID, backup, bot)
+	result := mergeBackup(chatID, backup, bot)
+	if want := (BackupResult{Conflicts: 2}); result != want {
+		t.Errorf("second import %+v, want %+v", result, want)
+	}
+	if userData := todoData[chatID]; len(userData.Todos) != 1 || len(userData.Reminders) != 1 {
+		t.Errorf("after importing twice: %d todos, %d reminders", len(userData.Todos), len(userData.Reminders))
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const maxButtonLabel = 30
+
+type bulkSelectionKey struct {
+	ChatID    int64
+	MessageID int
+}
+
+// bulkSelections holds the reminder IDs ticked in each multi-select message.
+var bulkSelections = make(map[bulkSelectionKey]map[int]bool)
+
+func truncateLabel(s string) string {
+	runes := []rune(s)
+	if len(runes) <= maxButtonLabel {
+		return s
+	}
+
+	return string(runes[:maxButtonLabel-1]) + "…"
+}
+
+func bulkCancelKeyboard(chatID int64, reminders []Reminder, selected map[int]bool) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, reminder := range reminders {
+		box := "☐"
+		if selected[reminder.ID] {
+			box = "☑"
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf("%s %d. %s", box, i+1, truncateLabel(reminder.DisplayTitle())),
+			fmt.Sprintf("bulk:%d", reminder.ID))))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(tr(chatID, "bulkcancel.button", len(selected)), "bulk:cancel"),
+	))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func handleBulkCancel(chatID int64, bot BotClient) {
+	pending := pendingReminders(chatID)
+	if len(pending) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "reminders.empty"))
+		send(bot, msg)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "bulkcancel.prompt"))
+	msg.ReplyMarkup = bulkCancelKeyboard(chatID, pending, nil)
+	sent, err := send(bot, msg)
+	if err != nil {
+		return
+	}
+
+	// Only the newest menu of a chat stays live; older ones go stale.
+	for key := range bulkSelections {
+		if key.ChatID == chatID {
+			delete(bulkSelections, key)
+		}
+	}
+	bulkSelections[bulkSelectionKey{ChatID: chatID, MessageID: sent.MessageID}] = make(map[int]bool)
+}
+
+func toggleBulkSelection(selected map[int]bool, id int) {
+	if selected[id] {
+		delete(selected, id)
+	} else {
+		selected[id] = true
+	}
+}
+
+func handleBulkButton(chatID int64, arg string, messageID int, bot BotClient) {
+	key := bulkSelectionKey{ChatID: chatID, MessageID: messageID}
+	selected, exists := bulkSelections[key]
+	if !exists {
+		send(bot, tgbotapi.NewEditMessageText(chatID, messageID, tr(chatID, "error.stale_list")))
+		return
+	}
+
+	if arg == "cancel" {
+		delete(bulkSelections, key)
+		removed := cancelReminders(chatID, func(reminder Reminder) bool {
+			return selected[reminder.ID]
+		})
+		send(bot, tgbotapi.NewEditMessageText(chatID, messageID, tr(chatID, "reminders.cancelled", removed)))
+
+		if removed > 0 {
+			if err := saveUserData(); err != nil {
+				slog.Error("Failed to save user data", "err", err)
+			}
+		}
+		return
+	}
+
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return
+	}
+	toggleBulkSelection(selected, id)
+
+	send(bot, tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, bulkCancelKeyboard(chatID, pendingReminders(chatID), selected)))
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// A new /remindbulkcancel menu replaces the chat's previous one, so
+// selections of abandoned menus don't pile up.
+func TestBulkCancelKeepsNewestMenu(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 9501
+
+	at := time.Now().Add(time.Hour)
+	todoData[chatID] = &UserData{
+		Todos:          []Todo{},
+		Reminders:      []Reminder{{ID: 1, Content: "water", Time: at}, {ID: 2, Content: "stretch", Time: at}},
+		NextReminderID: 2,
+	}
+
+	var menus []int
+	for i := 0; i < 3; i++ {
+		dispatch(command(chatID, "/remindbulkcancel"), bot)
+		for key := range bulkSelections {
+			if key.ChatID == chatID {
+				menus = append(menus, key.MessageID)
+			}
+		}
+	}
+	if len(menus) != 3 {
+		t.Fatalf("chat had %d menus after each /remindbulkcancel, want one each time: %v", len(menus), menus)
+	}
+
+	first, newest := menus[0], menus[2]
+	pressOn := func(messageID int, data string) {
+		update := button(chatID, chatID, data)
+		update.CallbackQuery.Message.MessageID = messageID
+		dispatch(update, bot)
+	}
+	pressOn(first, "bulk:1")
+	pressOn(first, "bulk:cancel")
+	pressOn(newest, "bulk:2")
+	pressOn(newest, "bulk:cancel")
+
+	var left []string
+	for _, reminder := range pendingReminders(chatID) {
+		left = append(left, reminder.Content)
+	}
+	if fmt.Sprint(left) != "[water]" {
+		t.Errorf("left %q, want only the reminder the newest menu didn't cancel", left)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type BulkLine struct {
+	Number   int
+	Reminder Reminder
+	Err      error
+}
+
+// parseBulkReminders parses one "<time> <message>" reminder per line,
+// keeping going past bad lines so each can be reported on its own.
+func parseBulkReminders(chatID int64, text string, now time.Time) []BulkLine {
+	var lines []BulkLine
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		timeStr, content, _ := strings.Cut(line, " ")
+		content = strings.TrimSpace(content)
+		if content == "" {
+			lines = append(lines, BulkLine{Number: i + 1, Err: errors.New(tr(chatID, "bulkremind.no_text"))})
+			continue
+		}
+
+		reminderTime, err := parseReminderTime(chatID, timeStr, now)
+		if err != nil || !reminderTime.After(now) {
+			lines = append(lines, BulkLine{Number: i + 1, Err: errors.New(tr(chatID, "bulkremind.bad_time", timeStr))})
+			continue
+		}
+
+		lines = append(lines, BulkLine{Number: i + 1, Reminder: Reminder{Content: content, Time: reminderTime}})
+	}
+
+	return lines
+}
+
+func handleBulkRemind(chatID int64, text string, bot BotClient) {
+	lines := parseBulkReminders(chatID, text, time.Now())
+	if len(lines) == 0 {
+		sendUsage(chatID, "bulkremind", bot)
+		return
+	}
+
+	size := 0
+	for _, line := range lines {
+		size += len(line.Reminder.Content)
+	}
+	if !checkQuota(chatID, size, bot) {
+		return
+	}
+
+	created := 0
+	var report string
+	for _, line := range lines {
+		if line.Err != nil {
+			report += tr(chatID, "bulkremind.line_failed", line.Number, line.Err) + "\n"
+			continue
+		}
+
+		reminder, err := addReminder(chatID, line.Reminder)
+		if err != nil {
+			report += tr(chatID, "bulkremind.line_over_limit", line.Number, maxRemindersPerChat) + "\n"
+			continue
+		}
+		scheduleReminder(chatID, reminder, bot)
+		created++
+		report += tr(chatID, "bulkremind.line_created", line.Number, reminder.DisplayTitle(), formatTime(chatID, reminder.Time)) + "\n"
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "bulkremind.created", created, len(lines), report))
+	send(bot, msg)
+
+	if created > 0 {
+		if err := saveUserData(); err != nil {
+			slog.Error("Failed to save user data", "err", err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// BusinessHours is the daily working window, in minutes after midnight, that
+// business-hour reminders count time in. Weekends are skipped entirely.
+type BusinessHours struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+var defaultBusinessHours = BusinessHours{Start: 9 * 60, End: 18 * 60}
+
+func parseBusinessHours(s string) (BusinessHours, error) {
+	startStr, endStr, ok := strings.Cut(s, "-")
+	if !ok {
+		return BusinessHours{}, fmt.Errorf("invalid business hours %q", s)
+	}
+
+	start, err := parseClock(startStr)
+	if err != nil {
+		return BusinessHours{}, err
+	}
+	end, err := parseClock(endStr)
+	if err != nil {
+		return BusinessHours{}, err
+	}
+	if start >= end {
+		return BusinessHours{}, fmt.Errorf("business hours must end after they start")
+	}
+
+	return BusinessHours{Start: start, End: end}, nil
+}
+
+func (b BusinessHours) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", b.Start/60, b.Start%60, b.End/60, b.End%60)
+}
+
+func isWeekend(t time.Time) bool {
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// addBusinessDuration returns the time d of working time after now, only
+// counting time inside the business hours of weekdays.
+func addBusinessDuration(now time.Time, d time.Duration, hours BusinessHours) time.Time {
+	t := now
+	for {
+		year, month, day := t.Date()
+		dayStart := time.Date(year, month, day, hours.Start/60, hours.Start%60, 0, 0, t.Location())
+		dayEnd := time.Date(year, month, day, hours.End/60, hours.End%60, 0, 0, t.Location())
+		nextDay := time.Date(year, month, day+1, 0, 0, 0, 0, t.Location())
+
+		if isWeekend(t) || !t.Before(dayEnd) {
+			t = nextDay
+			continue
+		}
+		if t.Before(dayStart) {
+			t = dayStart
+		}
+
+		remaining := dayEnd.Sub(t)
+		if d <= remaining {
+			return t.Add(d)
+		}
+		d -= remaining
+		t = nextDay
+	}
+}
+
+func chatBusinessHours(chatID int64) BusinessHours {
+	if userData, exists := todoData[chatID]; exists && userData.Settings.BusinessHours != nil {
+		return *userData.Settings.BusinessHours
+	}
+
+	return defaultBusinessHours
+}
+
+// parseBusinessTime handles reminder times like "2bh", meaning two business
+// hours from now.
+func parseBusinessTime(chatID int64, timeStr string, now time.Time) (time.Time, bool, error) {
+	hoursStr, ok := strings.CutSuffix(timeStr, "bh")
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	hours, err := strconv.Atoi(hoursStr)
+	if err != nil || hours < 1 {
+		return time.Time{}, true, fmt.Errorf("invalid business hours %q", timeStr)
+	}
+
+	return addBusinessDuration(now, time.Duration(hours)*time.Hour, chatBusinessHours(chatID)), true, nil
+}
+
+func handleBusinessHoursSetting(chatID int64, value string, bot BotClient) {
+	hours, err := parseBusinessHours(value)
+	if err != nil {
+		sendUsage(chatID, "businesshours", bot)
+		return
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	todoData[chatID].Settings.BusinessHours = &hours
+
+	send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "business.set", hours)))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
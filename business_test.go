@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddBusinessDuration(t *testing.T) {
+	// March 2024: the 14th is a Thursday, the 15th a Friday.
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.March, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		d    time.Duration
+		want time.Time
+	}{
+		{"within the day", at(14, 10, 0), 2 * time.Hour, at(14, 12, 0)},
+		{"up to closing", at(14, 17, 0), time.Hour, at(14, 18, 0)},
+		{"overnight", at(14, 17, 0), 2 * time.Hour, at(15, 10, 0)},
+		{"before opening", at(14, 7, 0), time.Hour, at(14, 10, 0)},
+		{"after closing", at(14, 20, 0), 30 * time.Minute, at(15, 9, 30)},
+		{"over the weekend", at(15, 17, 0), 3 * time.Hour, at(18, 11, 0)},
+		{"from saturday", at(16, 12, 0), time.Hour, at(18, 10, 0)},
+		{"a full week", at(14, 9, 0), 45 * time.Hour, at(20, 18, 0)},
+	}
+	for _, test := range tests {
+		if got := addBusinessDuration(test.now, test.d, defaultBusinessHours); !got.Equal(test.want) {
+			t.Errorf("%s: %v after %v is %v, want %v", test.name, test.d, test.now.Format("Mon 15:04"), got.Format("Mon Jan 2 15:04"), test.want.Format("Mon Jan 2 15:04"))
+		}
+	}
+}
+
+func TestParseBusinessHours(t *testing.T) {
+	if hours, err := parseBusinessHours("08:30-17:00"); err != nil || hours != (BusinessHours{Start: 8*60 + 30, End: 17 * 60}) {
+		t.Errorf("parseBusinessHours(08:30-17:00) = %v, %v", hours, err)
+	}
+	for _, s := range []string{"", "9-17", "17:00-09:00", "09:00-09:00", "09:00-25:00"} {
+		if hours, err := parseBusinessHours(s); err == nil {
+			t.Errorf("parseBusinessHours(%q) = %v, want an error", s, hours)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const icsTimeLayout = "20060102T150405"
+
+// calendarURL is the public base URL of the calendar feed, or empty if the
+// feed isn't served. Each chat's feed lives at calendarURL/<token>.ics.
+var calendarURL string
+
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`, "\r", "")
+
+// foldICSLine splits a content line into 75-octet pieces as RFC 5545
+// requires, without cutting a UTF-8 sequence in half.
+func foldICSLine(line string) string {
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+
+	return b.String()
+}
+
+// cronRRULE translates the cron specs the bot itself creates into an RRULE:
+// fixed intervals, every few days, daily, weekly and monthly schedules.
+// Other specs have no exact equivalent and are exported as their next
+// occurrence only.
+func cronRRULE(spec string) (string, bool) {
+	spec = stripCronTZ(spec)
+	if every, ok := strings.CutPrefix(spec, "@every "); ok {
+		interval, err := time.ParseDuration(every)
+		if err != nil || interval < time.Minute {
+			return "", false
+		}
+		switch {
+		case interval%(24*time.Hour) == 0:
+			return fmt.Sprintf("FREQ=DAILY;INTERVAL=%d", interval/(24*time.Hour)), true
+		case interval%time.Hour == 0:
+			return fmt.Sprintf("FREQ=HOURLY;INTERVAL=%d", interval/time.Hour), true
+		case interval%time.Minute == 0:
+			return fmt.Sprintf("FREQ=MINUTELY;INTERVAL=%d", interval/time.Minute), true
+		}
+		return "", false
+	}
+
+	if args, ok := strings.CutPrefix(spec, daysSchedulePrefix+" "); ok {
+		days, _, _ := strings.Cut(args, " ")
+		if _, err := strconv.Atoi(days); err != nil {
+			return "", false
+		}
+		return "FREQ=DAILY;INTERVAL=" + days, true
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return "", false
+	}
+	for _, field := range fields[:2] {
+		if _, err := strconv.Atoi(field); err != nil {
+			return "", false
+		}
+	}
+	dom, month, dow := fields[2], fields[3], fields[4]
+
+	switch {
+	case dom == "*" && month == "*" && dow == "*":
+		return "FREQ=DAILY", true
+	case dom == "*" && month == "*":
+		var days []string
+		for _, day := range strings.Split(dow, ",") {
+			n, err := strconv.Atoi(day)
+			if err != nil || n < 0 || n > 7 {
+				return "", false
+			}
+			for name, value := range rruleDays {
+				if value == n%7 {
+					days = append(days, name)
+				}
+			}
+		}
+		return "FREQ=WEEKLY;BYDAY=" + strings.Join(days, ","), true
+	case dow == "*":
+		if _, err := strconv.Atoi(dom); err != nil {
+			return "", false
+		}
+		if month == "*" {
+			return "FREQ=MONTHLY;BYMONTHDAY=" + dom, true
+		}
+		if _, step, ok := strings.Cut(month, "/"); ok {
+			if _, err := strconv.Atoi(step); err == nil {
+				return fmt.Sprintf("FREQ=MONTHLY;INTERVAL=%s;BYMONTHDAY=%s", step, dom), true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// scheduleLocation returns the timezone a cron spec is pinned to.
+func scheduleLocation(spec string) *time.Location {
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if rest, ok := strings.CutPrefix(spec, prefix); ok {
+			name, _, _ := strings.Cut(rest, " ")
+			if loc, err := parseTimezone(name); err == nil {
+				return loc
+			}
+		}
+	}
+
+	return time.UTC
+}
+
+func icsTime(property string, t time.Time) string {
+	if t.Location() == time.UTC {
+		return fmt.Sprintf("%s:%sZ", property, t.Format(icsTimeLayout))
+	}
+
+	return fmt.Sprintf("%s;TZID=%s:%s", property, t.Location(), t.Format(icsTimeLayout))
+}
+
+// renderICS renders the chat's pending reminders as an iCalendar feed.
+// Recurring reminders become events with an RRULE starting at their next
+// occurrence; reminders that fire on bot start have no time and are left
+// out.
+func renderICS(chatID int64, now time.Time) string {
+	var lines []string
+	lines = append(lines,
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Remindeer-Bot//Reminders//EN",
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:Remindeer",
+	)
+
+	var reminders []Reminder
+	if userData, exists := todoData[chatID]; exists {
+		reminders = userData.Reminders
+	}
+	for _, reminder := range reminders {
+		if isOnStart(reminder) {
+			continue
+		}
+
+		start := reminder.Time.UTC()
+		var rrule string
+		if reminder.Recurring {
+			next, err := nextFireTime(reminder, now)
+			if err != nil || next.IsZero() {
+				continue
+			}
+			start = next.In(scheduleLocation(reminder.Schedule))
+			if rule, ok := cronRRULE(reminder.Schedule); ok {
+				rrule = rule
+				if reminder.RemainingFires > 0 {
+					rrule += fmt.Sprintf(";COUNT=%d", reminder.RemainingFires)
+				} else if reminder.ExpiresAt != nil {
+					rrule += ";UNTIL=" + reminder.ExpiresAt.UTC().Format(icsTimeLayout) + "Z"
+				}
+			}
+		}
+
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			fmt.Sprintf("UID:reminder-%d-%d@remindeer", chatID, reminder.ID),
+			"DTSTAMP:"+now.UTC().Format(icsTimeLayout)+"Z",
+			icsTime("DTSTART", start),
+			"SUMMARY:"+icsEscaper.Replace(reminder.DisplayTitle()),
+		)
+		if reminder.Title != "" && reminder.Content != "" {
+			lines = append(lines, "DESCRIPTION:"+icsEscaper.Replace(reminder.Content))
+		}
+		if reminder.URL != "" {
+			lines = append(lines, "URL:"+reminder.URL)
+		}
+		if rrule != "" {
+			lines = append(lines, "RRULE:"+rrule)
+		}
+		lines = append(lines, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(foldICSLine(line))
+	}
+
+	return b.String()
+}
+
+func newCalendarToken() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(raw), nil
+}
+
+// calendarChat finds the chat a feed token belongs to.
+func calendarChat(token string) (int64, bool) {
+	for chatID, userData := range todoData {
+		own := userData.Settings.CalendarToken
+		if own != "" && subtle.ConstantTimeCompare([]byte(own), []byte(token)) == 1 {
+			return chatID, true
+		}
+	}
+
+	return 0, false
+}
+
+// handleCalendar sends the reminders as an .ics file. "link" replies with
+// the chat's subscription URL, creating the secret token on first use, and
+// "reset" replaces the token so the old URL stops working.
+func handleCalendar(chatID int64, args string, bot BotClient) {
+	switch args {
+	case "":
+		doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: "reminders.ics", Bytes: []byte(renderICS(chatID, time.Now()))})
+		send(bot, doc)
+		return
+	case "link", "reset":
+	default:
+		sendUsage(chatID, "calendar", bot)
+		return
+	}
+
+	if calendarURL == "" {
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "calendar.disabled")))
+		return
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	settings := &todoData[chatID].Settings
+	if settings.CalendarToken == "" || args == "reset" {
+		token, err := newCalendarToken()
+		if err != nil {
+			chatLog(chatID).Error("Failed to create calendar token", "err", err)
+			return
+		}
+		settings.CalendarToken = token
+	}
+
+	link := strings.TrimSuffix(calendarURL, "/") + "/" + settings.CalendarToken + ".ics"
+	send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "calendar.link", link)))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+// startCalendarServer serves every chat's feed on cfg.CalendarListen under
+// the path of cfg.CalendarURL. The token in the URL is the only
+// authentication, so it is compared in constant time and never logged.
+func startCalendarServer(cfg Config) (func(), error) {
+	base, err := url.Parse(cfg.CalendarURL)
+	if err != nil {
+		return nil, err
+	}
+	prefix := strings.TrimSuffix(base.Path, "/") + "/"
+
+	listener, err := net.Listen("tcp", cfg.CalendarListen)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(prefix, func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, prefix), ".ics")
+		if !ok || token == "" || r.Method != http.MethodGet {
+			http.NotFound(w, r)
+			return
+		}
+
+		dataMu.Lock()
+		chatID, found := calendarChat(token)
+		var feed string
+		if found {
+			feed = renderICS(chatID, time.Now())
+		}
+		dataMu.Unlock()
+
+		if !found {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Write([]byte(feed))
+	})
+	server := &http.Server{Handler: mux}
+
+	go func() {
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Calendar server stopped", "err", err)
+		}
+	}()
+	slog.Info("Serving calendar feeds", "addr", cfg.CalendarListen, "path", prefix)
+
+	stop := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			slog.Error("Failed to stop calendar server", "err", err)
+		}
+	}
+
+	return stop, nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type Conversation struct {
+	Action        string
+	TodoID        int
+	ListMessageID int
+	ListPage      int
+	Content       string
+	Mention       *Mention
+}
+
+// conversationKey identifies whose answer a conversation waits for. In a
+// group each member has their own, so one member's next message doesn't
+// complete another's /remind or edit.
+type conversationKey struct {
+	ChatID int64
+	UserID int64
+}
+
+var conversations = make(map[conversationKey]*Conversation)
+
+func handleCallback(query *tgbotapi.CallbackQuery, bot BotClient) {
+	if query.Message == nil {
+		return
+	}
+	chatID := query.Message.Chat.ID
+	key := conversationKey{ChatID: chatID, UserID: query.From.ID}
+
+	action, arg, _ := strings.Cut(query.Data, ":")
+	switch action {
+	case "edit":
+		handleEditButton(key, arg, query.Message.MessageID, bot)
+	case "confirm":
+		handleConfirmButton(key, arg, query.Message.MessageID, bot)
+	case "snooze":
+		handleSnoozeButton(chatID, arg, query.Message.MessageID, bot)
+	case "bulk":
+		handleBulkButton(chatID, arg, query.Message.MessageID, bot)
+	case "ack":
+		handleAckButton(chatID, arg, query.Message.MessageID, query.Message.Text, bot)
+	case "freq":
+		handleFrequentButton(key, arg, query.Message.MessageID, bot)
+	case "done":
+		handleDoneButton(chatID, arg, query.Message.MessageID, bot)
+	case "page":
+		handlePageButton(chatID, arg, query.Message.MessageID, bot)
+	case "flow":
+		handleFlowButton(key, arg, query.Message.MessageID, bot)
+	default:
+		chatLog(chatID).Warn("Unknown callback data", "data", query.Data)
+	}
+
+	request(bot, tgbotapi.NewCallback(query.ID, ""))
+}
+
+// refreshTodoPage redraws a todo list message after its todos changed.
+func refreshTodoPage(chatID int64, messageID int, page int, bot BotClient) {
+	userData, exists := todoData[chatID]
+	if !exists || len(userData.Todos) == 0 {
+		send(bot, tgbotapi.NewEditMessageText(chatID, messageID, tr(chatID, "todos.empty")))
+		return
+	}
+
+	text, keyboard := todoPage(chatID, userData, page)
+	send(bot, tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, keyboard))
+}
+
+func handlePageButton(chatID int64, pageStr string, messageID int, bot BotClient) {
+	page, err := strconv.Atoi(pageStr)
+	if err != nil {
+		return
+	}
+
+	refreshTodoPage(chatID, messageID, page, bot)
+}
+
+// handleDoneButton marks a todo done from the list. The button carries the
+// todo's ID rather than its position, which may have changed since the list
+// was sent.
+func handleDoneButton(chatID int64, arg string, messageID int, bot BotClient) {
+	idStr, pageStr, _ := strings.Cut(arg, ":")
+	id, err := strconv.Atoi(idStr)
+	page, _ := strconv.Atoi(pageStr)
+	userData, exists := todoData[chatID]
+	if err != nil || !exists {
+		return
+	}
+
+	if index := findTodoIndex(userData, id); index >= 0 {
+		handleMarkDone(chatID, strconv.Itoa(index+1), bot)
+	}
+	refreshTodoPage(chatID, messageID, page, bot)
+}
+
+// handleEditButton asks for a todo's new text. Like the done button, the
+// button carries the todo's ID, and the conversation keeps the ID so the
+// answer edits the same todo even if the list changed in between.
+func handleEditButton(key conversationKey, arg string, listMessageID int, bot BotClient) {
+	chatID := key.ChatID
+	idStr, pageStr, _ := strings.Cut(arg, ":")
+	page, _ := strconv.Atoi(pageStr)
+	userData, exists := todoData[chatID]
+	id, err := strconv.Atoi(idStr)
+	index := -1
+	if exists && err == nil {
+		index = findTodoIndex(userData, id)
+	}
+	if index < 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	conversations[key] = &Conversation{
+		Action:        "edit",
+		TodoID:        id,
+		ListMessageID: listMessageID,
+		ListPage:      page,
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "todo.edit_prompt", index+1, userData.Todos[index].Text))
+	msg.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true, Selective: true}
+	send(bot, msg)
+}
+
+func handleConversation(key conversationKey, conversation *Conversation, text string, bot BotClient) {
+	chatID := key.ChatID
+	delete(conversations, key)
+
+	switch conversation.Action {
+	case "edit":
+		applyTodoEdit(chatID, conversation, text, bot)
+	case "frequent":
+		handleReminder(chatID, key.UserID, strings.TrimSpace(text), Reminder{Content: conversation.Content}, bot)
+	case flowRemindWhat, flowRemindWhen:
+		continueRemindFlow(key, conversation, text, bot)
+	}
+}
+
+func applyTodoEdit(chatID int64, conversation *Conversation, text string, bot BotClient) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.empty_todo"))
+		send(bot, msg)
+		return
+	}
+
+	userData, exists := todoData[chatID]
+	index := -1
+	if exists {
+		index = findTodoIndex(userData, conversation.TodoID)
+	}
+	if index < 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	if !checkQuota(chatID, len(text)-len(userData.Todos[index].Text), bot) {
+		return
+	}
+
+	userData.Todos[index].Text = text
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "todo.edited", index+1, text))
+	send(bot, msg)
+
+	refreshTodoPage(chatID, conversation.ListMessageID, conversation.ListPage, bot)
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
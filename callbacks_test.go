@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// button builds the update for userID pressing a button with data under a
+// message in chatID.
+func button(chatID int64, userID int64, data string) tgbotapi.Update {
+	message := groupMessage(chatID, userID, "").Message
+	return tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+		ID:      fmt.Sprint(message.MessageID),
+		From:    message.From,
+		Message: message,
+		Data:    data,
+	}}
+}
+
+// The edit button edits the todo it was shown next to, even when someone
+// else in the group shifted the list before the new text arrived.
+func TestEditButtonFollowsTodo(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID, alice, bob = -8001, 21, 22
+
+	todoData[chatID] = &UserData{
+		Todos:      []Todo{{ID: 1, Text: "buy milk"}, {ID: 2, Text: "call the bank"}, {ID: 3, Text: "water plants"}},
+		NextTodoID: 3,
+	}
+	userData := todoData[chatID]
+	target := userData.Todos[2]
+
+	_, keyboard := todoPage(chatID, userData, 0)
+	var data string
+	for _, row := range keyboard.InlineKeyboard {
+		for _, b := range row {
+			if b.CallbackData != nil && *b.CallbackData == fmt.Sprintf("edit:%d:0", target.ID) {
+				data = *b.CallbackData
+			}
+		}
+	}
+	if data == "" {
+		t.Fatalf("no edit button carrying todo ID %d in %+v", target.ID, keyboard.InlineKeyboard)
+	}
+
+	dispatch(button(chatID, alice, data), bot)
+	dispatch(groupMessage(chatID, bob, "/done 1"), bot)
+	dispatch(groupMessage(chatID, alice, "repot the plants"), bot)
+
+	for _, todo := range todoData[chatID].Todos {
+		if todo.ID == target.ID && todo.Text != "repot the plants" {
+			t.Errorf("todo %d is %q, want the new text", todo.ID, todo.Text)
+		}
+		if todo.ID != target.ID && todo.Text == "repot the plants" {
+			t.Errorf("edit landed on todo %d instead of %d", todo.ID, target.ID)
+		}
+	}
+}
+
+func TestEditButtonForGoneTodo(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 8002
+
+	dispatch(command(chatID, "/set buy milk"), bot)
+	id := todoData[chatID].Todos[0].ID
+	dispatch(command(chatID, "/done 1"), bot)
+
+	dispatch(button(chatID, chatID, fmt.Sprintf("edit:%d:0", id)), bot)
+	if _, open := conversations[conversationKey{ChatID: chatID, UserID: chatID}]; open {
+		t.Error("edit started for a todo that is done")
+	}
+}
+
+// Each confirmation prompt has its own buttons, and only the member who
+// asked for the reminder can answer it.
+func TestConfirmButtons(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID, alice, bob = 8101, 31, 32
+
+	promptOf := func(userID int64) int {
+		for key, pending := range pendingConfirmations {
+			if key.ChatID == chatID && pending.UserID == userID {
+				return key.ID
+			}
+		}
+		t.Fatalf("no prompt open for user %d", userID)
+		return 0
+	}
+	contents := func() []string {
+		var contents []string
+		for _, reminder := range pendingReminders(chatID) {
+			contents = append(contents, reminder.Content)
+		}
+		return contents
+	}
+
+	dispatch(groupMessage(chatID, alice, "/remind 60d water the plants"), bot)
+	dispatch(groupMessage(chatID, bob, "/remind 90d pay the rent"), bot)
+	alicePrompt, bobPrompt := promptOf(alice), promptOf(bob)
+	if alicePrompt == bobPrompt {
+		t.Fatalf("both prompts got ID %d", alicePrompt)
+	}
+
+	steps := []struct {
+		userID int64
+		data   string
+		want   string
+	}{
+		{bob, fmt.Sprintf("confirm:yes:%d", alicePrompt), "[]"},
+		{alice, "confirm:yes", "[]"},
+		{alice, fmt.Sprintf("confirm:yes:%d", alicePrompt), "[water the plants]"},
+		{alice, fmt.Sprintf("confirm:yes:%d", alicePrompt), "[water the plants]"},
+		{bob, fmt.Sprintf("confirm:no:%d", bobPrompt), "[water the plants]"},
+	}
+	for _, step := range steps {
+		dispatch(button(chatID, step.userID, step.data), bot)
+		if got := fmt.Sprint(contents()); got != step.want {
+			t.Errorf("after user %d pressed %q: reminders %s, want %s", step.userID, step.data, got, step.want)
+		}
+	}
+	for key := range pendingConfirmations {
+		if key.ChatID == chatID {
+			t.Errorf("prompt %d still open after both were answered", key.ID)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Command is an entry of the command menu. Its description is the
+// "commands.<name>" catalog key.
+type Command struct {
+	Name   string
+	Syntax string
+}
+
+var commands = []Command{
+	{Name: "help", Syntax: ""},
+	{Name: "start", Syntax: ""},
+	{Name: "remind", Syntax: "[@username] <duration|HH:MM|tomorrow 9am|2024-12-24 18:00|every <duration>|cron <spec>> <message> [--before <lead>,...] [--also email,webhook]"},
+	{Name: "r", Syntax: "<text, e.g. remind me to pay rent on the 1st of every month at noon>"},
+	{Name: "remindat", Syntax: "<HH:MM|9am|9:30pm> <message>"},
+	{Name: "preview", Syntax: "<time> <message>"},
+	{Name: "nag", Syntax: "<time> <repeat every> <message>"},
+	{Name: "remindpin", Syntax: "<time> <message>"},
+	{Name: "remindto", Syntax: "<chat id>[,<chat id>...] <time> <message>"},
+	{Name: "remindexpire", Syntax: "<time> <window|today> <message>"},
+	{Name: "remindevery", Syntax: "[n] <minute|hour|day|week|month> <message>"},
+	{Name: "recurring", Syntax: "[<cron spec|@daily|@every 2h|@onstart|every 2h|daily 09:30> <message>]"},
+	{Name: "rrule", Syntax: "<FREQ=DAILY|WEEKLY;BYDAY=..;INTERVAL=..;COUNT=..;UNTIL=..> <message>"},
+	{Name: "clonerecurring", Syntax: "<index> <message>"},
+	{Name: "clearrecurring", Syntax: ""},
+	{Name: "bulkremind", Syntax: "\n<time> <message>\n<time> <message>..."},
+	{Name: "countdown", Syntax: "<datetime> <message>"},
+	{Name: "again", Syntax: "<index> <time>"},
+	{Name: "share", Syntax: "<index>"},
+	{Name: "priority", Syntax: "<index> high|normal|low"},
+	{Name: "recat", Syntax: "<index> <category>|none"},
+	{Name: "snooze", Syntax: "<index> <time>"},
+	{Name: "snoozeuntil", Syntax: "<index> <datetime>"},
+	{Name: "cancel", Syntax: "<index> [<lead>]"},
+	{Name: "editreminder", Syntax: "<index> <time>|<new text>"},
+	{Name: "cancelbetween", Syntax: "<from> <to>"},
+	{Name: "cancelbefore", Syntax: "<date>"},
+	{Name: "cancelmatch", Syntax: "<pattern, e.g. buy*>"},
+	{Name: "cancellabel", Syntax: "<#label>"},
+	{Name: "remindbulkcancel", Syntax: ""},
+	{Name: "dedupe", Syntax: ""},
+	{Name: "undo", Syntax: ""},
+	{Name: "nextfires", Syntax: fmt.Sprintf("[1-%d]", maxNextFires)},
+	{Name: "deferred", Syntax: ""},
+	{Name: "agenda", Syntax: ""},
+	{Name: "frequent", Syntax: ""},
+	{Name: "pattern", Syntax: "[days|hours]"},
+	{Name: "summary", Syntax: ""},
+	{Name: "digest", Syntax: "daily HH:MM|weekly <day> HH:MM|off"},
+	{Name: "list", Syntax: "[new|delete <name>]"},
+	{Name: "lists", Syntax: ""},
+	{Name: "search", Syntax: "<query>"},
+	{Name: "notify", Syntax: "[email <address>|confirm <code>|webhook <https url>|<channel> off]"},
+	{Name: "reminders", Syntax: ""},
+	{Name: "listjson", Syntax: ""},
+	{Name: "listmd", Syntax: ""},
+	{Name: "clearhistory", Syntax: ""},
+	{Name: "log", Syntax: ""},
+	{Name: "mylog", Syntax: "export"},
+	{Name: "deliverystats", Syntax: ""},
+	{Name: "savetemplate", Syntax: "<name>\n<time> <message>\n<time> <message>..."},
+	{Name: "applytemplate", Syntax: "<name>"},
+	{Name: "templates", Syntax: ""},
+	{Name: "anchor", Syntax: "<name> <HH:MM>|off"},
+	{Name: "export"},
+	{Name: "calendar", Syntax: "[link|reset]"},
+	{Name: "import", Syntax: "(as the caption of an /export file)"},
+	{Name: "importtodos", Syntax: "(as the caption of a Google Tasks or Todoist JSON export)"},
+	{Name: "todo", Syntax: "[overdue|today|<list>]"},
+	{Name: "set", Syntax: "[<list>] [!high|!low] <task> [due <date>]"},
+	{Name: "setmy", Syntax: "<task>"},
+	{Name: "mytodos", Syntax: ""},
+	{Name: "done", Syntax: "[<list>] <index>..."},
+	{Name: "history", Syntax: "[<n>|keep <days|off>]"},
+	{Name: "undone", Syntax: "<id>"},
+	{Name: "edit", Syntax: "<index> <new text>"},
+	{Name: "move", Syntax: "<index> <position|top|bottom>"},
+	{Name: "top", Syntax: "<index>"},
+	{Name: "bottom", Syntax: "<index>"},
+	{Name: "repeat", Syntax: "<index> <time>|off"},
+	{Name: "nudge", Syntax: "<index> <time>|off"},
+	{Name: "progress", Syntax: "<index> <0-100>"},
+	{Name: "due", Syntax: "<index> <datetime>|off"},
+	{Name: "arm", Syntax: ""},
+	{Name: "remindtodo", Syntax: "<todo index> <time>"},
+	{Name: "link", Syntax: "<todo index> <reminder index>"},
+	{Name: "snoozebuttons", Syntax: "<time|tomorrow>...|off"},
+	{Name: "linkpreview", Syntax: "on|off"},
+	{Name: "confirmafter", Syntax: "<time>|off"},
+	{Name: "defaultcontent", Syntax: "<text>|off"},
+	{Name: "autoremind", Syntax: "<time>|off"},
+	{Name: "quietdone", Syntax: "on|off"},
+	{Name: "delay", Syntax: "<time>|off"},
+	{Name: "quiet", Syntax: "HH:MM-HH:MM|off"},
+	{Name: "businesshours", Syntax: "HH:MM-HH:MM"},
+	{Name: "quiettest", Syntax: "<HH:MM|datetime>"},
+	{Name: "language", Syntax: "uk|en"},
+	{Name: "clock", Syntax: "12|24"},
+	{Name: "timezone", Syntax: "<IANA timezone or UTC offset, e.g. Europe/Kyiv, UTC+2>"},
+	{Name: "tz", Syntax: "<IANA timezone or UTC offset, e.g. Europe/Kyiv, UTC+2>"},
+	{Name: "weekstart", Syntax: "mon|sun"},
+	{Name: "alias", Syntax: "[<name> <command> [args]|<name> off]"},
+	{Name: "maintenance", Syntax: "on|off"},
+	{Name: "remindcleanup", Syntax: ""},
+	{Name: "remindsync", Syntax: ""},
+	{Name: "stats", Syntax: ""},
+	{Name: "broadcast", Syntax: "<text>"},
+	{Name: "backup", Syntax: ""},
+}
+
+// captionCommand returns the command a media caption starts with, without
+// the leading slash or bot username.
+func captionCommand(message *tgbotapi.Message) string {
+	fields := strings.Fields(message.Caption)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return ""
+	}
+
+	command, _, _ := strings.Cut(fields[0][1:], "@")
+	return command
+}
+
+// Telegram shows at most this many commands in the menu.
+const maxBotCommands = 100
+
+// registerCommands publishes the command list to Telegram so clients can
+// offer autocompletion. The default list is in defaultLanguage; users whose
+// Telegram is set to another catalog's language get that one.
+func registerCommands(bot BotClient) {
+	for _, language := range languages() {
+		var botCommands []tgbotapi.BotCommand
+		for _, command := range commands {
+			if len(botCommands) == maxBotCommands {
+				break
+			}
+			botCommands = append(botCommands, tgbotapi.BotCommand{Command: command.Name, Description: message(language, "commands."+command.Name)})
+		}
+
+		config := tgbotapi.NewSetMyCommands(botCommands...)
+		if language != defaultLanguage {
+			config = tgbotapi.NewSetMyCommandsWithScopeAndLanguage(tgbotapi.NewBotCommandScopeDefault(), language, botCommands...)
+		}
+		if _, err := requestDirect(bot, config); err != nil {
+			slog.Error("Failed to register commands", "language", language, "err", err)
+		}
+	}
+}
+
+func findCommand(name string) (Command, bool) {
+	for _, command := range commands {
+		if command.Name == name {
+			return command, true
+		}
+	}
+
+	return Command{}, false
+}
+
+// commandUsage builds the usage line for a command from its registered
+// syntax.
+func commandUsage(chatID int64, name string) string {
+	line := "/" + name
+	if command, _ := findCommand(name); command.Syntax != "" {
+		line += " " + command.Syntax
+	}
+
+	return tr(chatID, "command.usage", line)
+}
+
+func sendUsage(chatID int64, name string, bot BotClient) {
+	send(bot, tgbotapi.NewMessage(chatID, commandUsage(chatID, name)))
+}
+
+func handleHelp(chatID int64, bot BotClient) {
+	var lines []string
+	for _, command := range commands {
+		line := "/" + command.Name
+		if command.Syntax != "" {
+			line += " " + command.Syntax
+		}
+		lines = append(lines, fmt.Sprintf("%s — %s", line, tr(chatID, "commands."+command.Name)))
+	}
+
+	text := tr(chatID, "help.header") + "\n" + strings.Join(lines, "\n")
+	for _, chunk := range splitMessage(text, maxMessageLength) {
+		send(bot, tgbotapi.NewMessage(chatID, chunk))
+	}
+}
+
+// maxSuggestionDistance is how many edits away a typo may be from a command
+// for it to be suggested. Short names get less slack, roughly one edit per
+// three characters, so that "/ab" doesn't suggest "/arm".
+const maxSuggestionDistance = 2
+
+func suggestionThreshold(name string) int {
+	return min(maxSuggestionDistance, len([]rune(name))/3)
+}
+
+// editDistance returns the Levenshtein distance between a and b, counting a
+// swap of two adjacent characters ("lsit") as a single edit.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
+
+// closestCommand returns the candidate nearest to name, if any is close
+// enough to be a likely typo. Ties go to the earlier candidate.
+func closestCommand(name string, candidates []string) (string, bool) {
+	best, bestDistance := "", suggestionThreshold(name)+1
+	for _, candidate := range candidates {
+		if distance := editDistance(name, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	return best, best != ""
+}
+
+// commandNames lists the registered commands followed by the chat's aliases.
+func commandNames(chatID int64) []string {
+	names := make([]string, 0, len(commands))
+	for _, command := range commands {
+		names = append(names, command.Name)
+	}
+	if userData, exists := todoData[chatID]; exists {
+		aliases := make([]string, 0, len(userData.Aliases))
+		for alias := range userData.Aliases {
+			aliases = append(aliases, alias)
+		}
+		sort.Strings(aliases)
+		names = append(names, aliases...)
+	}
+
+	return names
+}
+
+func handleUnknownCommand(chatID int64, name string, bot BotClient) {
+	text := tr(chatID, "command.unknown")
+	if suggestion, ok := closestCommand(name, commandNames(chatID)); ok && name != "" {
+		text += " " + tr(chatID, "command.suggestion", suggestion)
+	}
+
+	send(bot, tgbotapi.NewMessage(chatID, text))
+}
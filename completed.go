@@ -0,0 +1,187 @@
+package main
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	maxCompleted         = 200
+	defaultHistoryLength = 10
+	purgeCompletedSpec   = "@every 1h"
+)
+
+// CompletedTodo is a todo marked done, kept so /undone can bring it back.
+// List is the named list it was on, or empty for the default list.
+type CompletedTodo struct {
+	Todo        Todo      `json:"todo"`
+	List        string    `json:"list,omitempty"`
+	CompletedAt time.Time `json:"completed_at"`
+}
+
+// archiveTodo keeps a todo marked done in the chat's archive, dropping the
+// oldest entries past maxCompleted. Repeating todos are not archived: they
+// come back by themselves.
+func archiveTodo(userData *UserData, todo Todo, list string, now time.Time) {
+	if todo.RepeatEvery != nil {
+		return
+	}
+
+	todo.ReminderIDs = nil
+	userData.Completed = append(userData.Completed, CompletedTodo{Todo: todo, List: list, CompletedAt: now})
+	if len(userData.Completed) > maxCompleted {
+		userData.Completed = userData.Completed[len(userData.Completed)-maxCompleted:]
+	}
+}
+
+// purgeExpiredCompleted drops archived todos older than the chat's
+// KeepCompleted setting. Without the setting they are kept until pushed out
+// by newer ones.
+func purgeExpiredCompleted(userData *UserData, now time.Time) bool {
+	keep := userData.Settings.KeepCompleted
+	if keep == 0 {
+		return false
+	}
+
+	kept := userData.Completed[:0]
+	for _, completed := range userData.Completed {
+		if now.Sub(completed.CompletedAt) < keep {
+			kept = append(kept, completed)
+		}
+	}
+	purged := len(kept) != len(userData.Completed)
+	userData.Completed = kept
+
+	return purged
+}
+
+func purgeCompleted(now time.Time) {
+	purged := false
+	for _, userData := range todoData {
+		if purgeExpiredCompleted(userData, now) {
+			purged = true
+		}
+	}
+
+	if purged {
+		if err := saveUserData(); err != nil {
+			slog.Error("Failed to save user data", "err", err)
+		}
+	}
+}
+
+// handleHistory lists the most recently completed todos, newest first.
+// "keep <days>|off" sets how long the chat keeps them instead.
+func handleHistory(chatID int64, args string, bot BotClient) {
+	fields := strings.Fields(args)
+	if len(fields) == 2 && fields[0] == "keep" {
+		handleKeepCompletedSetting(chatID, fields[1], bot)
+		return
+	}
+
+	count := defaultHistoryLength
+	if len(fields) == 1 {
+		n, err := strconv.Atoi(fields[0])
+		if err != nil || n < 1 {
+			sendUsage(chatID, "history", bot)
+			return
+		}
+		count = min(n, maxCompleted)
+	} else if len(fields) > 1 {
+		sendUsage(chatID, "history", bot)
+		return
+	}
+
+	userData, exists := todoData[chatID]
+	if !exists || len(userData.Completed) == 0 {
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "history.empty")))
+		return
+	}
+
+	text := tr(chatID, "history.title")
+	for i := len(userData.Completed) - 1; i >= 0 && count > 0; i-- {
+		completed := userData.Completed[i]
+		text += "\n" + tr(chatID, "history.item", completed.Todo.ID, completed.Todo.Text, formatTime(chatID, completed.CompletedAt))
+		if completed.List != "" {
+			text += " " + tr(chatID, "history.item_list", completed.List)
+		}
+		count--
+	}
+	text += "\n\n" + tr(chatID, "history.hint")
+	send(bot, tgbotapi.NewMessage(chatID, text))
+}
+
+func handleKeepCompletedSetting(chatID int64, value string, bot BotClient) {
+	var keep time.Duration
+	if value != "off" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 1 {
+			sendUsage(chatID, "history", bot)
+			return
+		}
+		keep = time.Duration(days) * 24 * time.Hour
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	userData := todoData[chatID]
+	userData.Settings.KeepCompleted = keep
+	purgeExpiredCompleted(userData, time.Now())
+
+	text := tr(chatID, "history.keep_days", value)
+	if keep == 0 {
+		text = tr(chatID, "history.keep_all", maxCompleted)
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+// handleUndone puts an archived todo back on the list it was completed
+// from, or on the default list if that list is gone. Reminders the todo had
+// were cancelled when it was done and are not restored.
+func handleUndone(chatID int64, args string, bot BotClient) {
+	id, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(args), "#"))
+	if err != nil {
+		sendUsage(chatID, "undone", bot)
+		return
+	}
+
+	userData, exists := todoData[chatID]
+	index := -1
+	if exists {
+		for i, completed := range userData.Completed {
+			if completed.Todo.ID == id {
+				index = i
+			}
+		}
+	}
+	if index < 0 {
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "history.not_found", id)))
+		return
+	}
+	if !checkTodoLimit(chatID, bot) {
+		return
+	}
+
+	completed := userData.Completed[index]
+	userData.Completed = append(userData.Completed[:index], userData.Completed[index+1:]...)
+	if _, exists := userData.Lists[completed.List]; completed.List != "" && exists {
+		userData.Lists[completed.List] = append(userData.Lists[completed.List], completed.Todo)
+	} else {
+		userData.Todos = append(userData.Todos, completed.Todo)
+	}
+
+	send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "history.restored", completed.Todo.Text)))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
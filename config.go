@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var configKeys = []string{
+	"API_TOKEN", "LOG_LEVEL", "POLL_TIMEOUT", "DEFAULT_LANGUAGE", "DEFAULT_TIMEZONE",
+	"STORAGE_BACKEND", "USERDATA_PATH", "SQLITE_PATH", "REDIS_ADDR",
+	"USER_QUOTA_BYTES", "AUDIT_LOG_SIZE", "TRANSCRIBER_URL", "MAINTENANCE_PATH",
+	"ADMIN_IDS", "ADMIN_CHAT_ID",
+	"WEBHOOK_URL", "WEBHOOK_LISTEN", "WEBHOOK_CERT", "WEBHOOK_KEY", "WEBHOOK_SECRET",
+	"CALENDAR_URL", "CALENDAR_LISTEN", "METRICS_LISTEN",
+	"SMTP_ADDR", "SMTP_USERNAME", "SMTP_PASSWORD", "SMTP_FROM", "NOTIFY_WEBHOOKS",
+}
+
+// webhookSecretPattern is what Telegram accepts as a secret_token.
+var webhookSecretPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,256}$`)
+
+type Config struct {
+	BotToken        string
+	LogLevel        string
+	PollTimeout     int
+	DefaultLanguage string
+	DefaultLocation *time.Location
+	StorageBackend  string
+	DataPath        string
+	SQLitePath      string
+	RedisAddr       string
+	UserQuota       int
+	AuditLogSize    int
+	TranscriberURL  string
+	AdminIDs        map[int64]bool
+	AdminChatID     int64
+	MaintenancePath string
+	WebhookURL      string
+	WebhookListen   string
+	WebhookCert     string
+	WebhookKey      string
+	WebhookSecret   string
+	CalendarURL     string
+	CalendarListen  string
+	MetricsListen   string
+	SMTPAddr        string
+	SMTPUsername    string
+	SMTPPassword    string
+	SMTPFrom        string
+	NotifyWebhooks  bool
+}
+
+// configSource looks settings up in the environment first and then in the
+// config file, if there is one.
+type configSource map[string]string
+
+func (c configSource) get(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	if value := c[key]; value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+// loadConfigFile reads a flat TOML file of key = value lines. Keys are the
+// environment variable names in lower case, e.g. storage_backend = "redis".
+func loadConfigFile(path string) (configSource, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	known := make(map[string]bool)
+	for _, key := range configKeys {
+		known[key] = true
+	}
+
+	values := make(configSource)
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected key = value", path, n)
+		}
+		key = strings.ToUpper(strings.TrimSpace(key))
+		if !known[key] {
+			return nil, fmt.Errorf("%s:%d: unknown setting %q", path, n, strings.ToLower(key))
+		}
+
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			end := strings.LastIndex(value, `"`)
+			rest := strings.TrimSpace(value[end+1:])
+			if end == 0 || (rest != "" && !strings.HasPrefix(rest, "#")) {
+				return nil, fmt.Errorf("%s:%d: malformed string", path, n)
+			}
+			value, err = strconv.Unquote(value[:end+1])
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: malformed string: %v", path, n, err)
+			}
+		} else if before, _, found := strings.Cut(value, "#"); found {
+			value = strings.TrimSpace(before)
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// loadConfig reads the configuration from the environment and the optional
+// file named by CONFIG_FILE; environment variables take precedence.
+func loadConfig() (Config, error) {
+	source := configSource{}
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		var err error
+		if source, err = loadConfigFile(path); err != nil {
+			return Config{}, fmt.Errorf("reading config file: %v", err)
+		}
+	}
+
+	cfg := Config{
+		BotToken:        source.get("API_TOKEN", ""),
+		LogLevel:        source.get("LOG_LEVEL", "info"),
+		PollTimeout:     60,
+		DefaultLanguage: source.get("DEFAULT_LANGUAGE", defaultLanguage),
+		DefaultLocation: time.UTC,
+		StorageBackend:  source.get("STORAGE_BACKEND", "sqlite"),
+		DataPath:        source.get("USERDATA_PATH", "userdata.json"),
+		SQLitePath:      source.get("SQLITE_PATH", "userdata.db"),
+		RedisAddr:       source.get("REDIS_ADDR", "localhost:6379"),
+		UserQuota:       defaultUserQuota,
+		AuditLogSize:    defaultAuditLogSize,
+		TranscriberURL:  source.get("TRANSCRIBER_URL", ""),
+		MaintenancePath: source.get("MAINTENANCE_PATH", "maintenance.flag"),
+		WebhookURL:      source.get("WEBHOOK_URL", ""),
+		WebhookListen:   source.get("WEBHOOK_LISTEN", ":8080"),
+		WebhookCert:     source.get("WEBHOOK_CERT", ""),
+		WebhookKey:      source.get("WEBHOOK_KEY", ""),
+		WebhookSecret:   source.get("WEBHOOK_SECRET", ""),
+		CalendarURL:     source.get("CALENDAR_URL", ""),
+		CalendarListen:  source.get("CALENDAR_LISTEN", ":8081"),
+		MetricsListen:   source.get("METRICS_LISTEN", ""),
+		SMTPAddr:        source.get("SMTP_ADDR", ""),
+		SMTPUsername:    source.get("SMTP_USERNAME", ""),
+		SMTPPassword:    source.get("SMTP_PASSWORD", ""),
+		SMTPFrom:        source.get("SMTP_FROM", ""),
+	}
+
+	if cfg.BotToken == "" {
+		return Config{}, fmt.Errorf("API_TOKEN is not set")
+	}
+	if _, ok := logLevels[cfg.LogLevel]; !ok {
+		return Config{}, fmt.Errorf("LOG_LEVEL must be debug, info, warn or error, got %q", cfg.LogLevel)
+	}
+	if _, ok := catalogs[cfg.DefaultLanguage]; !ok {
+		return Config{}, fmt.Errorf("DEFAULT_LANGUAGE must be one of %s, got %q", strings.Join(languages(), ", "), cfg.DefaultLanguage)
+	}
+	if value := source.get("DEFAULT_TIMEZONE", ""); value != "" {
+		loc, err := parseTimezone(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("DEFAULT_TIMEZONE must be an IANA timezone or UTC offset, got %q", value)
+		}
+		cfg.DefaultLocation = loc
+	}
+	if value := source.get("POLL_TIMEOUT", ""); value != "" {
+		timeout, err := strconv.Atoi(value)
+		if err != nil || timeout <= 0 {
+			return Config{}, fmt.Errorf("POLL_TIMEOUT must be a positive number of seconds, got %q", value)
+		}
+		cfg.PollTimeout = timeout
+	}
+
+	switch cfg.StorageBackend {
+	case "json", "sqlite", "redis":
+	default:
+		return Config{}, fmt.Errorf("STORAGE_BACKEND must be json, sqlite or redis, got %q", cfg.StorageBackend)
+	}
+	for key, value := range map[string]string{"WEBHOOK_URL": cfg.WebhookURL, "CALENDAR_URL": cfg.CalendarURL} {
+		if value == "" {
+			continue
+		}
+		if u, err := url.Parse(value); err != nil || u.Scheme != "https" && u.Scheme != "http" || u.Host == "" {
+			return Config{}, fmt.Errorf("%s must be an absolute http(s) URL, got %q", key, value)
+		}
+	}
+
+	if (cfg.WebhookCert == "") != (cfg.WebhookKey == "") {
+		return Config{}, fmt.Errorf("WEBHOOK_CERT and WEBHOOK_KEY must be set together")
+	}
+	if cfg.WebhookSecret != "" && !webhookSecretPattern.MatchString(cfg.WebhookSecret) {
+		return Config{}, fmt.Errorf("WEBHOOK_SECRET must be 1-256 letters, digits, _ or -")
+	}
+
+	if cfg.SMTPAddr != "" {
+		if _, _, err := net.SplitHostPort(cfg.SMTPAddr); err != nil {
+			return Config{}, fmt.Errorf("SMTP_ADDR must be host:port, got %q", cfg.SMTPAddr)
+		}
+		if _, err := mail.ParseAddress(cfg.SMTPFrom); err != nil {
+			return Config{}, fmt.Errorf("SMTP_FROM must be an email address when SMTP_ADDR is set, got %q", cfg.SMTPFrom)
+		}
+	}
+	if value := source.get("NOTIFY_WEBHOOKS", ""); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("NOTIFY_WEBHOOKS must be true or false, got %q", value)
+		}
+		cfg.NotifyWebhooks = enabled
+	}
+
+	adminIDs, err := parseAdminIDs(source.get("ADMIN_IDS", ""))
+	if err != nil {
+		return Config{}, fmt.Errorf("ADMIN_IDS must be a comma-separated list of user ids: %v", err)
+	}
+	cfg.AdminIDs = adminIDs
+
+	if value := source.get("ADMIN_CHAT_ID", ""); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return Config{}, fmt.Errorf("ADMIN_CHAT_ID must be a chat id, got %q", value)
+		}
+		cfg.AdminChatID = id
+	}
+
+	if value := source.get("USER_QUOTA_BYTES", ""); value != "" {
+		quota, err := strconv.Atoi(value)
+		if err != nil || quota <= 0 {
+			return Config{}, fmt.Errorf("USER_QUOTA_BYTES must be a positive integer, got %q", value)
+		}
+		cfg.UserQuota = quota
+	}
+
+	if value := source.get("AUDIT_LOG_SIZE", ""); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size <= 0 {
+			return Config{}, fmt.Errorf("AUDIT_LOG_SIZE must be a positive integer, got %q", value)
+		}
+		cfg.AuditLogSize = size
+	}
+
+	return cfg, nil
+}
+
+func NewStore(cfg Config) (Store, error) {
+	switch cfg.StorageBackend {
+	case "json":
+		return &JSONStore{Path: cfg.DataPath}, nil
+	case "sqlite":
+		store, err := NewSQLiteStore(cfg.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		if err := store.migrateFromJSON(cfg.DataPath); err != nil {
+			store.Close()
+			return nil, fmt.Errorf("migrating %s: %v", cfg.DataPath, err)
+		}
+		return store, nil
+	case "redis":
+		return NewRedisStore(cfg.RedisAddr)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.StorageBackend)
+	}
+}
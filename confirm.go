@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type PendingReminder struct {
+	TimeStr   string
+	Reminder  Reminder
+	UserID    int64
+	CreatedAt time.Time
+}
+
+// confirmationKey identifies one confirmation prompt. A chat can have
+// several open at once, e.g. one per member of a group, and each prompt's
+// buttons carry its ID.
+type confirmationKey struct {
+	ChatID int64
+	ID     int
+}
+
+// Prompts nobody answered within this time are dropped and their buttons
+// go stale.
+const confirmationTTL = 24 * time.Hour
+
+var pendingConfirmations = make(map[confirmationKey]PendingReminder)
+var nextConfirmationID int
+
+// addPendingConfirmation stores a reminder awaiting userID's answer and
+// returns the keyboard for its prompt.
+func addPendingConfirmation(chatID int64, userID int64, timeStr string, reminder Reminder) tgbotapi.InlineKeyboardMarkup {
+	now := time.Now()
+	for key, pending := range pendingConfirmations {
+		if now.Sub(pending.CreatedAt) > confirmationTTL {
+			delete(pendingConfirmations, key)
+		}
+	}
+
+	nextConfirmationID++
+	key := confirmationKey{ChatID: chatID, ID: nextConfirmationID}
+	pendingConfirmations[key] = PendingReminder{TimeStr: timeStr, Reminder: reminder, UserID: userID, CreatedAt: now}
+
+	return confirmKeyboard(chatID, key.ID)
+}
+
+func requestReminderConfirmation(chatID int64, userID int64, timeStr string, reminder Reminder, bot BotClient) {
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "confirm.prompt",
+		reminder.Content, formatTime(chatID, reminder.Time)))
+	msg.ReplyMarkup = addPendingConfirmation(chatID, userID, timeStr, reminder)
+	send(bot, msg)
+}
+
+func confirmKeyboard(chatID int64, id int) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(tr(chatID, "confirm.yes"), "confirm:yes:"+strconv.Itoa(id)),
+		tgbotapi.NewInlineKeyboardButtonData(tr(chatID, "confirm.no"), "confirm:no:"+strconv.Itoa(id)),
+	))
+}
+
+// handleConfirmButton answers the prompt named in arg ("yes:<id>" or
+// "no:<id>"). Only the member who asked for the reminder can answer it;
+// presses by anyone else are ignored.
+func handleConfirmButton(key conversationKey, arg string, messageID int, bot BotClient) {
+	chatID := key.ChatID
+	answer, idStr, _ := strings.Cut(arg, ":")
+	id, err := strconv.Atoi(idStr)
+	confirmation := confirmationKey{ChatID: chatID, ID: id}
+	pending, exists := pendingConfirmations[confirmation]
+	if err != nil || !exists {
+		send(bot, tgbotapi.NewEditMessageText(chatID, messageID, tr(chatID, "confirm.stale")))
+		return
+	}
+	if pending.UserID != key.UserID {
+		return
+	}
+	delete(pendingConfirmations, confirmation)
+
+	if answer != "yes" {
+		send(bot, tgbotapi.NewEditMessageText(chatID, messageID, tr(chatID, "confirm.cancelled")))
+		return
+	}
+
+	send(bot, tgbotapi.NewEditMessageText(chatID, messageID, tr(chatID, "confirm.confirmed")))
+	if pending.Reminder.Schedule != "" {
+		addRecurringReminder(chatID, pending.Reminder, bot)
+		return
+	}
+	createReminder(chatID, pending.TimeStr, pending.Reminder, bot)
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func handleCountdown(chatID int64, dateTimeStr string, content string, bot BotClient) {
+	if !checkReminderLimit(chatID, bot) || !checkQuota(chatID, len(content), bot) {
+		return
+	}
+
+	eventTime, err := parseDateTime(dateTimeStr, chatLocation(chatID))
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "countdown.invalid_date"))
+		send(bot, msg)
+		return
+	}
+	if !eventTime.After(time.Now()) {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.date_passed"))
+		send(bot, msg)
+		return
+	}
+
+	sent, err := send(bot, tgbotapi.NewMessage(chatID, renderCountdown(chatID, content, time.Until(eventTime))))
+	if err != nil {
+		chatLog(chatID).Error("Failed to send countdown message", "err", err)
+		return
+	}
+
+	_, err = request(bot, tgbotapi.PinChatMessageConfig{
+		ChatID:              chatID,
+		MessageID:           sent.MessageID,
+		DisableNotification: true,
+	})
+	if err != nil {
+		chatLog(chatID).Warn("Failed to pin countdown message", "err", err)
+	}
+
+	reminder := Reminder{
+		Content:            content,
+		Time:               eventTime,
+		CountdownMessageID: sent.MessageID,
+	}
+
+	reminder, err = addReminder(chatID, reminder)
+	if err != nil {
+		// The chat filled up while the message was being posted; take the
+		// message down again since nothing will keep it current.
+		request(bot, tgbotapi.UnpinChatMessageConfig{ChatID: chatID, MessageID: sent.MessageID})
+		request(bot, tgbotapi.NewDeleteMessage(chatID, sent.MessageID))
+		sendReminderLimit(chatID, bot)
+		return
+	}
+	scheduleReminder(chatID, reminder, bot)
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+// countdownInterval returns how long to wait before the next edit of the
+// countdown message: hourly while the event is days away, then more often
+// as it gets closer.
+func countdownInterval(remaining time.Duration) time.Duration {
+	switch {
+	case remaining > 24*time.Hour:
+		return time.Hour
+	case remaining > time.Hour:
+		return 10 * time.Minute
+	default:
+		return time.Minute
+	}
+}
+
+func scheduleCountdownUpdate(chatID int64, reminder Reminder, bot BotClient) {
+	remaining := time.Until(reminder.Time)
+	interval := countdownInterval(remaining)
+	if interval >= remaining {
+		// The final edit is done by fireReminder.
+		return
+	}
+
+	time.AfterFunc(interval, locked(func() {
+		current, exists := findReminder(chatID, reminder.ID)
+		if !exists || !current.Time.Equal(reminder.Time) {
+			// Cancelled or rescheduled; the new schedule runs its own updates.
+			return
+		}
+
+		edit := tgbotapi.NewEditMessageText(chatID, reminder.CountdownMessageID,
+			renderCountdown(chatID, reminder.Content, time.Until(reminder.Time)))
+		send(bot, edit)
+
+		scheduleCountdownUpdate(chatID, reminder, bot)
+	}))
+}
+
+func finishCountdown(chatID int64, reminder Reminder, bot BotClient) {
+	edit := tgbotapi.NewEditMessageText(chatID, reminder.CountdownMessageID,
+		tr(chatID, "countdown.reached", reminder.Content))
+	send(bot, edit)
+
+	request(bot, tgbotapi.UnpinChatMessageConfig{
+		ChatID:    chatID,
+		MessageID: reminder.CountdownMessageID,
+	})
+}
+
+func renderCountdown(chatID int64, content string, remaining time.Duration) string {
+	return tr(chatID, "countdown.remaining", content, formatRemaining(chatID, remaining))
+}
+
+func formatRemaining(chatID int64, remaining time.Duration) string {
+	remaining = remaining.Round(time.Minute)
+	days := int(remaining / (24 * time.Hour))
+	hours := int(remaining % (24 * time.Hour) / time.Hour)
+	minutes := int(remaining % time.Hour / time.Minute)
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, tr(chatID, "countdown.days", days))
+	}
+	if hours > 0 {
+		parts = append(parts, tr(chatID, "countdown.hours", hours))
+	}
+	if minutes > 0 || len(parts) == 0 {
+		parts = append(parts, tr(chatID, "countdown.minutes", minutes))
+	}
+
+	return strings.Join(parts, " ")
+}
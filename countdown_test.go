@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCountdownInterval(t *testing.T) {
+	tests := []struct {
+		remaining time.Duration
+		want      time.Duration
+	}{
+		{30 * 24 * time.Hour, time.Hour},
+		{24*time.Hour + time.Minute, time.Hour},
+		{24 * time.Hour, 10 * time.Minute},
+		{2 * time.Hour, 10 * time.Minute},
+		{time.Hour, time.Minute},
+		{5 * time.Minute, time.Minute},
+	}
+	for _, test := range tests {
+		if got := countdownInterval(test.remaining); got != test.want {
+			t.Errorf("countdownInterval(%v) = %v, want %v", test.remaining, got, test.want)
+		}
+	}
+}
+
+// When the countdown is due, its message says so and is unpinned.
+func TestCountdownFinishes(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID, messageID = 9301, 77
+
+	reminder := Reminder{ID: 1, Content: "launch", Time: time.Now().Add(100 * time.Millisecond), CountdownMessageID: messageID}
+	todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{reminder}, NextReminderID: 1, Settings: Settings{Language: "en"}}
+	dataMu.Lock()
+	scheduleReminder(chatID, reminder, bot)
+	dataMu.Unlock()
+
+	chats := map[int64][]string{chatID: {reminder.Content}}
+	for deadline := time.Now().Add(5 * time.Second); !allFired(chats); time.Sleep(20 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("countdown never fired")
+		}
+	}
+
+	edited := false
+	for _, params := range fake.requests("editMessageText") {
+		if params.Get("message_id") == strconv.Itoa(messageID) && params.Get("text") == "⏰ launch: it's time!" {
+			edited = true
+		}
+	}
+	if !edited {
+		t.Errorf("countdown message not edited to the final text; edits %v", fake.requests("editMessageText"))
+	}
+	if unpins := fake.requests("unpinChatMessage"); len(unpins) != 1 || unpins[0].Get("message_id") != strconv.Itoa(messageID) {
+		t.Errorf("unpinned %v, want message %d", unpins, messageID)
+	}
+}
+
+// If the chat reaches its reminder limit while the countdown message is
+// being posted, the message is taken down again.
+func TestCountdownWithdrawnAtLimit(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 9302
+
+	fake.onCall = func(method string) {
+		if method == "pinChatMessage" {
+			dataMu.Lock()
+			fullChat(chatID)
+			dataMu.Unlock()
+		}
+	}
+	dispatch(command(chatID, "/countdown 2099-01-01T10:00 party"), bot)
+
+	pins := fake.requests("pinChatMessage")
+	if len(pins) != 1 {
+		t.Fatalf("%d pins, want 1", len(pins))
+	}
+	posted := pins[0].Get("message_id")
+	for _, method := range []string{"unpinChatMessage", "deleteMessage"} {
+		calls := fake.requests(method)
+		if len(calls) != 1 || calls[0].Get("message_id") != posted {
+			t.Errorf("%s calls %v, want one for message %s", method, calls, posted)
+		}
+	}
+	if got := len(todoData[chatID].Reminders); got != maxRemindersPerChat {
+		t.Errorf("%d reminders, want the limit of %d", got, maxRemindersPerChat)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// DelayWindow postpones by By every reminder that comes due before Until.
+type DelayWindow struct {
+	Until time.Time     `json:"until"`
+	By    time.Duration `json:"by"`
+}
+
+// delayedDelivery reports when a reminder coming due at now is delivered
+// under the chat's delay window, and whether it is delayed at all.
+func delayedDelivery(window *DelayWindow, now time.Time) (time.Time, bool) {
+	if window == nil || !now.Before(window.Until) {
+		return now, false
+	}
+
+	return now.Add(window.By), true
+}
+
+func chatDelay(chatID int64) *DelayWindow {
+	if userData, exists := todoData[chatID]; exists {
+		return userData.Delay
+	}
+
+	return nil
+}
+
+func handleDelay(chatID int64, value string, bot BotClient) {
+	if value == "" {
+		sendUsage(chatID, "delay", bot)
+		return
+	}
+
+	var window *DelayWindow
+	if value != "off" {
+		duration, err := parseDuration(value)
+		if err != nil || duration <= 0 {
+			msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.time_format"))
+			send(bot, msg)
+			return
+		}
+		window = &DelayWindow{Until: time.Now().Add(duration), By: duration}
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	todoData[chatID].Delay = window
+
+	text := tr(chatID, "delay.off")
+	if window != nil {
+		text = tr(chatID, "delay.on", formatTime(chatID, window.Until), value)
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
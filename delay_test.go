@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDelayedDelivery(t *testing.T) {
+	now := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	window := &DelayWindow{Until: now.Add(time.Hour), By: time.Hour}
+
+	tests := []struct {
+		name    string
+		window  *DelayWindow
+		due     time.Time
+		want    time.Time
+		delayed bool
+	}{
+		{"no window", nil, now, now, false},
+		{"inside window", window, now, now.Add(time.Hour), true},
+		{"just before the end", window, now.Add(59 * time.Minute), now.Add(119 * time.Minute), true},
+		{"at the end", window, now.Add(time.Hour), now.Add(time.Hour), false},
+		{"after the end", window, now.Add(2 * time.Hour), now.Add(2 * time.Hour), false},
+	}
+	for _, test := range tests {
+		got, delayed := delayedDelivery(test.window, test.due)
+		if !got.Equal(test.want) || delayed != test.delayed {
+			t.Errorf("%s: got %v, %v; want %v, %v", test.name, got, delayed, test.want, test.delayed)
+		}
+	}
+}
+
+func TestDelayCommand(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 10821
+	todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}, Settings: Settings{Language: "en"}}
+
+	before := time.Now()
+	dispatch(command(chatID, "/delay 2h"), bot)
+	window := todoData[chatID].Delay
+	if window == nil {
+		t.Fatalf("/delay 2h set no window; sent %q", fake.sent(chatID))
+	}
+	if window.By != 2*time.Hour || window.Until.Before(before.Add(2*time.Hour)) || window.Until.After(time.Now().Add(2*time.Hour)) {
+		t.Errorf("/delay 2h set %+v", window)
+	}
+
+	dispatch(command(chatID, "/delay soon"), bot)
+	if todoData[chatID].Delay != window {
+		t.Errorf("an invalid duration replaced the window with %+v", todoData[chatID].Delay)
+	}
+
+	dispatch(command(chatID, "/delay off"), bot)
+	if todoData[chatID].Delay != nil {
+		t.Errorf("/delay off left %+v", todoData[chatID].Delay)
+	}
+}
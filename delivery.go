@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// retryDelays is the backoff between attempts to deliver a one-off reminder
+// whose send failed with a temporary error.
+var retryDelays = []time.Duration{30 * time.Second, 2 * time.Minute, 10 * time.Minute, 30 * time.Minute}
+
+// isPermanentSendError reports whether sending again can't help, e.g.
+// because the user blocked the bot or the chat no longer exists.
+func isPermanentSendError(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code >= 400 && apiErr.Code < 500 && apiErr.RetryAfter == 0
+}
+
+// retryDelivery keeps a one-off reminder whose delivery failed pending and
+// schedules another attempt. It reports false if the reminder should be
+// given up on instead. Recurring and nagging reminders aren't retried since
+// they fire again anyway.
+func retryDelivery(chatID int64, reminder Reminder, label string, err error, bot BotClient) bool {
+	if reminder.Recurring || isNagging(reminder) || isPermanentSendError(err) {
+		return false
+	}
+	if reminder.DeliveryAttempts >= len(retryDelays) {
+		chatLog(chatID).Error("Giving up on reminder", "reminder_id", reminder.ID, "attempts", reminder.DeliveryAttempts+1)
+		return false
+	}
+
+	userData := todoData[chatID]
+	for i := range userData.Reminders {
+		if userData.Reminders[i].ID == reminder.ID {
+			userData.Reminders[i].DeliveryAttempts++
+			reminder = userData.Reminders[i]
+			break
+		}
+	}
+
+	delay := retryDelays[reminder.DeliveryAttempts-1]
+	key := reminderKey{ChatID: chatID, ReminderID: reminder.ID}
+	reminderTimers[key] = time.AfterFunc(delay, locked(func() {
+		if current, exists := findReminder(chatID, reminder.ID); exists {
+			deliverReminder(chatID, current, label, bot)
+		}
+	}))
+
+	return true
+}
+
+// digestible reports whether a missed reminder can be folded into the
+// catch-up digest. Reminders that pin, carry media, nag or go to other
+// chats are delivered one by one as usual.
+func digestible(reminder Reminder) bool {
+	return !reminder.Pin && reminder.MediaFileID == "" && reminder.CountdownMessageID == 0 &&
+		len(reminder.Targets) == 0 && !isNagging(reminder) &&
+		(reminder.ExpiresAt == nil || time.Now().Before(*reminder.ExpiresAt))
+}
+
+// catchUpReminders delivers reminders that came due while the bot was down.
+// Several plain ones are sent as a single digest rather than a burst of
+// messages. Delivering a one-shot reminder removes it from the chat's list,
+// so each is only sent once.
+func catchUpReminders(chatID int64, missed []Reminder, bot BotClient) {
+	remindersMissed.Add(float64(len(missed)))
+	sortForDelivery(missed)
+
+	var digest, single []Reminder
+	for _, reminder := range missed {
+		if digestible(reminder) {
+			digest = append(digest, reminder)
+		} else {
+			single = append(single, reminder)
+		}
+	}
+
+	now := time.Now()
+	_, delayed := delayedDelivery(chatDelay(chatID), now)
+	_, quiet := deferredDelivery(chatQuietHours(chatID), now.In(chatLocation(chatID)))
+	if len(digest) < 2 || maintenance || delayed || quiet || !sendMissedDigest(chatID, digest, bot) {
+		single = append(single, digest...)
+		sortForDelivery(single)
+	}
+
+	for _, reminder := range single {
+		deliverReminder(chatID, reminder, "reminder.missed", bot)
+	}
+}
+
+// sendMissedDigest sends one message listing the missed reminders and marks
+// them as fired. It reports false if the message couldn't be sent.
+func sendMissedDigest(chatID int64, missed []Reminder, bot BotClient) bool {
+	var b strings.Builder
+	b.WriteString(tr(chatID, "reminder.missed_digest") + "\n")
+	for _, reminder := range missed {
+		fmt.Fprintf(&b, "• %s — %s\n", formatTime(chatID, reminder.Time), reminder.Content)
+	}
+
+	for _, chunk := range splitMessage(b.String(), maxMessageLength) {
+		_, err := send(bot, tgbotapi.NewMessage(chatID, chunk))
+		recordDelivery(chatID, err)
+		if err != nil {
+			chatLog(chatID).Error("Failed to send missed reminders digest", "err", err)
+			return false
+		}
+	}
+
+	now := time.Now()
+	for _, reminder := range missed {
+		recordFiredReminder(chatID, reminder, now)
+	}
+
+	return true
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/robfig/cron/v3"
+)
+
+// A daily digest covers everything due within the next day, a weekly one
+// the next week.
+const (
+	digestWindow       = 24 * time.Hour
+	weeklyDigestWindow = 7 * 24 * time.Hour
+)
+
+var digestEntries = make(map[int64]cron.EntryID)
+
+// digestSpec builds the cron spec for a digest at the given minutes after
+// midnight in loc, every day or only on day if it is set.
+func digestSpec(minutes int, day *time.Weekday, loc *time.Location) string {
+	dow := "*"
+	if day != nil {
+		dow = fmt.Sprint(int(*day))
+	}
+
+	return fmt.Sprintf("CRON_TZ=%s %d %d * * %s", loc, minutes%60, minutes/60, dow)
+}
+
+// parseDigestDay accepts weekday names and their three-letter short forms.
+func parseDigestDay(word string) (time.Weekday, bool) {
+	word = strings.ToLower(word)
+	if wd, ok := parseWeekday(word); ok {
+		return wd, true
+	}
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if word == strings.ToLower(wd.String()[:3]) {
+			return wd, true
+		}
+	}
+
+	return 0, false
+}
+
+func scheduleDigest(chatID int64, bot BotClient) {
+	if entryID, exists := digestEntries[chatID]; exists {
+		reminderScheduler.Remove(entryID)
+		delete(digestEntries, chatID)
+	}
+
+	userData, exists := todoData[chatID]
+	if !exists || userData.Settings.DigestAt == nil {
+		return
+	}
+
+	spec := digestSpec(*userData.Settings.DigestAt, userData.Settings.DigestDay, chatLocation(chatID))
+	entryID, err := reminderScheduler.AddFunc(spec, locked(func() {
+		sendDigest(chatID, bot)
+	}))
+	if err != nil {
+		chatLog(chatID).Error("Failed to schedule digest", "spec", spec, "err", err)
+		return
+	}
+	digestEntries[chatID] = entryID
+}
+
+func setupDigests(bot BotClient) {
+	for chatID := range todoData {
+		scheduleDigest(chatID, bot)
+	}
+}
+
+// composeDigest lists the reminders firing and todos falling due within
+// window, todos already overdue, and todos completed since the previous
+// digest, or returns an empty string if there are none.
+func composeDigest(chatID int64, now time.Time, window time.Duration) string {
+	userData, exists := todoData[chatID]
+	if !exists {
+		return ""
+	}
+	end := now.Add(window)
+
+	var fires []UpcomingFire
+	for _, reminder := range pendingReminders(chatID) {
+		if reminder.Time.Before(end) {
+			fires = append(fires, UpcomingFire{Reminder: reminder, Time: reminder.Time})
+		}
+	}
+	for _, fire := range upcomingFires(userData.Reminders, now, maxNextFires) {
+		if fire.Time.Before(end) {
+			fires = append(fires, fire)
+		}
+	}
+	sort.SliceStable(fires, func(i, j int) bool {
+		return fires[i].Time.Before(fires[j].Time)
+	})
+
+	var sections []string
+	if len(fires) > 0 {
+		section := tr(chatID, "digest.reminders")
+		for _, fire := range fires {
+			section += fmt.Sprintf("\n• %s — %s", formatTime(chatID, fire.Time), fire.Reminder.DisplayTitle())
+		}
+		sections = append(sections, section)
+	}
+
+	var overdue, due string
+	for _, todo := range userData.Todos {
+		switch {
+		case todo.Due == nil:
+		case todo.Due.Before(now):
+			overdue += fmt.Sprintf("\n• %s — %s", formatTime(chatID, *todo.Due), todo.Text)
+		case todo.Due.Before(end):
+			due += fmt.Sprintf("\n• %s — %s", formatTime(chatID, *todo.Due), todo.Text)
+		}
+	}
+	if overdue != "" {
+		sections = append(sections, tr(chatID, "digest.overdue")+overdue)
+	}
+	if due != "" {
+		sections = append(sections, tr(chatID, "digest.due")+due)
+	}
+
+	since := userData.LastDigestAt
+	if since.IsZero() {
+		since = now.Add(-window)
+	}
+	var completed string
+	for _, item := range userData.Completed {
+		if item.CompletedAt.After(since) {
+			completed += "\n• " + item.Todo.Text
+		}
+	}
+	if completed != "" {
+		sections = append(sections, tr(chatID, "digest.completed")+completed)
+	}
+
+	return strings.Join(sections, "\n\n")
+}
+
+func sendDigest(chatID int64, bot BotClient) {
+	userData, exists := todoData[chatID]
+	if !exists {
+		return
+	}
+
+	now := time.Now()
+	window, header := digestWindow, tr(chatID, "digest.daily_header")
+	if userData.Settings.DigestDay != nil {
+		window, header = weeklyDigestWindow, tr(chatID, "digest.weekly_header")
+	}
+	digest := composeDigest(chatID, now, window)
+	if digest == "" {
+		digest = tr(chatID, "digest.empty")
+	}
+
+	if _, err := send(bot, tgbotapi.NewMessage(chatID, header+"\n\n"+digest)); err != nil {
+		return
+	}
+	userData.LastDigestAt = now
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+// handleDigestSetting turns the digest on as "daily HH:MM" or
+// "weekly <day> HH:MM", or off. A bare "HH:MM" still means daily.
+func handleDigestSetting(chatID int64, value string, bot BotClient) {
+	fields := strings.Fields(value)
+	if len(fields) == 1 && fields[0] != "off" {
+		fields = []string{"daily", fields[0]}
+	}
+
+	var digestAt *int
+	var digestDay *time.Weekday
+	switch {
+	case len(fields) == 1 && fields[0] == "off":
+	case len(fields) == 2 && fields[0] == "daily":
+		minutes, err := parseClock(fields[1])
+		if err != nil {
+			sendUsage(chatID, "digest", bot)
+			return
+		}
+		digestAt = &minutes
+	case len(fields) == 3 && fields[0] == "weekly":
+		day, ok := parseDigestDay(fields[1])
+		minutes, err := parseClock(fields[2])
+		if !ok || err != nil {
+			sendUsage(chatID, "digest", bot)
+			return
+		}
+		digestAt, digestDay = &minutes, &day
+	default:
+		sendUsage(chatID, "digest", bot)
+		return
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	settings := &todoData[chatID].Settings
+	settings.DigestAt = digestAt
+	settings.DigestDay = digestDay
+	scheduleDigest(chatID, bot)
+
+	var text string
+	switch {
+	case digestAt == nil:
+		text = tr(chatID, "digest.off")
+	case digestDay == nil:
+		text = tr(chatID, "digest.daily_on", fields[1])
+	default:
+		text = tr(chatID, "digest.weekly_on", fields[1], fields[2])
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
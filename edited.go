@@ -0,0 +1,110 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleEditedMessage applies an edit of a /set or /remind command to the
+// todo or reminder it created. Edits of other messages are acknowledged so
+// the user knows they had no effect.
+func handleEditedMessage(message *tgbotapi.Message, bot BotClient) {
+	chatID := message.Chat.ID
+	args := message.CommandArguments()
+
+	switch message.Command() {
+	case "set":
+		applyEditedTodo(chatID, message.MessageID, strings.TrimSpace(args), bot)
+	case "remind", "remindpin":
+		timeStr, content := cutReminderTime(args)
+		if content == "" {
+			sendUsage(chatID, "remind", bot)
+			return
+		}
+		applyEditedReminder(chatID, message, timeStr, content, bot)
+	default:
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "edited.not_applied"))
+		send(bot, msg)
+	}
+}
+
+func applyEditedTodo(chatID int64, messageID int, text string, bot BotClient) {
+	userData, exists := todoData[chatID]
+	if !exists {
+		return
+	}
+
+	for i := range userData.Todos {
+		todo := &userData.Todos[i]
+		if todo.SourceMessageID != messageID {
+			continue
+		}
+
+		if text == "" {
+			msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.empty_todo"))
+			send(bot, msg)
+			return
+		}
+		if !checkQuota(chatID, len(text)-len(todo.Text), bot) {
+			return
+		}
+
+		todo.Text = text
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "edited.todo_updated", i+1, text)))
+
+		if err := saveUserData(); err != nil {
+			slog.Error("Failed to save user data", "err", err)
+		}
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "edited.todo_missing"))
+	send(bot, msg)
+}
+
+// applyEditedReminder updates the reminder created by the edited message.
+// A relative time is counted from when the original message was sent.
+func applyEditedReminder(chatID int64, message *tgbotapi.Message, timeStr string, content string, bot BotClient) {
+	var reminder Reminder
+	found := false
+	for _, pending := range pendingReminders(chatID) {
+		if pending.SourceMessageID == message.MessageID {
+			reminder = pending
+			found = true
+			break
+		}
+	}
+	if !found {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "edited.reminder_missing"))
+		send(bot, msg)
+		return
+	}
+
+	newTime, err := addDuration(message.Time(), timeStr)
+	if err != nil || !newTime.After(message.Time()) {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.time_format"))
+		send(bot, msg)
+		return
+	}
+	if !checkQuota(chatID, len(content)-len(reminder.Content), bot) {
+		return
+	}
+
+	userData := todoData[chatID]
+	for i := range userData.Reminders {
+		if userData.Reminders[i].ID == reminder.ID {
+			setReminderText(&userData.Reminders[i], content)
+			break
+		}
+	}
+	rescheduleReminder(chatID, reminder.ID, newTime, bot)
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "edited.reminder_updated", content, formatTime(chatID, newTime)))
+	send(bot, msg)
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
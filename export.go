@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Telegram rejects messages longer than 4096 characters; bigger exports are
+// sent as a file instead.
+const maxMessageLength = 4096
+
+func handleListJSON(chatID int64, bot BotClient) {
+	reminders := []Reminder{}
+	if userData, exists := todoData[chatID]; exists {
+		reminders = append(reminders, userData.Reminders...)
+	}
+
+	raw, err := json.MarshalIndent(reminders, "", "  ")
+	if err != nil {
+		chatLog(chatID).Error("Failed to encode reminders", "err", err)
+		return
+	}
+
+	text := fmt.Sprintf("<pre><code class=\"language-json\">%s</code></pre>", html.EscapeString(string(raw)))
+	if len([]rune(text)) <= maxMessageLength {
+		msg := tgbotapi.NewMessage(chatID, text)
+		msg.ParseMode = tgbotapi.ModeHTML
+		send(bot, msg)
+		return
+	}
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: "reminders.json", Bytes: raw})
+	send(bot, doc)
+}
+
+// Characters are only escaped where they'd change inline formatting; the
+// content never starts a line, so list and heading markers are safe.
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\", "`", "\\`", "*", "\\*", "_", "\\_", "~", "\\~",
+	"[", "\\[", "]", "\\]", "<", "\\<", ">", "\\>", "|", "\\|",
+	"\n", " ", "\r", "",
+)
+
+// escapeMarkdown makes s safe to use as a single line of Markdown text.
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// renderMarkdownList renders reminders as a Markdown checklist: one-shot
+// reminders with their time, recurring ones with their schedule.
+func renderMarkdownList(chatID int64, reminders []Reminder) string {
+	var b strings.Builder
+	b.WriteString("# " + tr(chatID, "export.markdown_title") + "\n\n")
+	for _, reminder := range reminders {
+		when := formatTime(chatID, reminder.Time)
+		if reminder.Recurring {
+			when = fmt.Sprintf("🔁 `%s`", stripCronTZ(reminder.Schedule))
+		}
+		fmt.Fprintf(&b, "- [ ] %s — %s\n", when, escapeMarkdown(reminder.Content))
+	}
+
+	return b.String()
+}
+
+func handleListMarkdown(chatID int64, bot BotClient) {
+	var reminders []Reminder
+	if userData, exists := todoData[chatID]; exists {
+		reminders = userData.Reminders
+	}
+	if len(reminders) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "reminders.empty"))
+		send(bot, msg)
+		return
+	}
+
+	text := renderMarkdownList(chatID, reminders)
+	if len([]rune(text)) <= maxMessageLength {
+		send(bot, tgbotapi.NewMessage(chatID, text))
+		return
+	}
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: "reminders.md", Bytes: []byte(text)})
+	send(bot, doc)
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const fakeBotToken = "123:fake"
+
+// fakeCall is one Bot API request the bot made.
+type fakeCall struct {
+	Method string
+	Params url.Values
+}
+
+// fakeBotAPI is a stand-in for api.telegram.org. It records every request
+// and answers the methods the handlers use with just enough of a result
+// for the library to decode.
+type fakeBotAPI struct {
+	t      *testing.T
+	server *httptest.Server
+
+	mu        sync.Mutex
+	calls     []fakeCall
+	messageID int
+
+	// onCall, if set, runs for every request before it is answered.
+	onCall func(method string)
+}
+
+func newFakeBotAPI(t *testing.T) *fakeBotAPI {
+	f := &fakeBotAPI{t: t}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.server.Close)
+
+	return f
+}
+
+func (f *fakeBotAPI) serve(w http.ResponseWriter, r *http.Request) {
+	method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	if err := r.ParseMultipartForm(1 << 20); err != nil && err != http.ErrNotMultipart {
+		f.t.Errorf("%s: %v", method, err)
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, fakeCall{Method: method, Params: r.Form})
+	f.messageID++
+	messageID := f.messageID
+	onCall := f.onCall
+	f.mu.Unlock()
+	if onCall != nil {
+		onCall(method)
+	}
+
+	var result interface{} = true
+	switch method {
+	case "getMe":
+		result = tgbotapi.User{ID: 1, IsBot: true, UserName: "remindeer_test_bot"}
+	case "sendMessage", "sendDocument", "editMessageText":
+		chatID, _ := strconv.ParseInt(r.Form.Get("chat_id"), 10, 64)
+		result = tgbotapi.Message{
+			MessageID: messageID,
+			Chat:      &tgbotapi.Chat{ID: chatID},
+			Text:      r.Form.Get("text"),
+			Date:      int(time.Now().Unix()),
+		}
+	case "getChatMember":
+		result = tgbotapi.ChatMember{Status: "member"}
+	}
+
+	raw, err := json.Marshal(result)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tgbotapi.APIResponse{Ok: true, Result: raw})
+}
+
+// bot returns a real *tgbotapi.BotAPI talking to the fake server.
+func (f *fakeBotAPI) bot() *tgbotapi.BotAPI {
+	bot, err := tgbotapi.NewBotAPIWithAPIEndpoint(fakeBotToken, f.server.URL+"/bot%s/%s")
+	if err != nil {
+		f.t.Fatal(err)
+	}
+
+	return bot
+}
+
+// sent returns the texts of the messages sent to chatID so far.
+func (f *fakeBotAPI) sent(chatID int64) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var texts []string
+	for _, call := range f.calls {
+		if call.Method == "sendMessage" && call.Params.Get("chat_id") == strconv.FormatInt(chatID, 10) {
+			texts = append(texts, call.Params.Get("text"))
+		}
+	}
+
+	return texts
+}
+
+// requests returns the parameters of every call of method so far.
+func (f *fakeBotAPI) requests(method string) []url.Values {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var params []url.Values
+	for _, call := range f.calls {
+		if call.Method == method {
+			params = append(params, call.Params)
+		}
+	}
+
+	return params
+}
+
+// useTestData points the global data and store at a fresh temporary file
+// for the length of the test.
+func useTestData(t *testing.T) *JSONStore {
+	backend := &JSONStore{Path: filepath.Join(t.TempDir(), "userdata.json")}
+	oldData, oldStore := todoData, store
+	todoData = make(map[int64]*UserData)
+	store = NewCachedStore(backend, time.Hour)
+	t.Cleanup(func() {
+		for key := range reminderTimers {
+			unscheduleReminder(key.ChatID, key.ReminderID)
+		}
+		todoData, store = oldData, oldStore
+	})
+
+	return backend
+}
+
+var testMessageID int
+
+// command builds the update Telegram sends for a command typed in a
+// private chat.
+func command(chatID int64, text string) tgbotapi.Update {
+	return groupMessage(chatID, chatID, text)
+}
+
+// groupMessage builds the update for text sent by userID in chatID. Text
+// starting with a slash is marked as a command.
+func groupMessage(chatID int64, userID int64, text string) tgbotapi.Update {
+	testMessageID++
+	chatType := "group"
+	if chatID == userID {
+		chatType = "private"
+	}
+	message := &tgbotapi.Message{
+		MessageID: testMessageID,
+		From:      &tgbotapi.User{ID: userID},
+		Chat:      &tgbotapi.Chat{ID: chatID, Type: chatType},
+		Date:      int(time.Now().Unix()),
+		Text:      text,
+	}
+	if strings.HasPrefix(text, "/") {
+		length := len(text)
+		if i := strings.IndexAny(text, " \n"); i >= 0 {
+			length = i
+		}
+		message.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: length}}
+	}
+
+	return tgbotapi.Update{Message: message}
+}
+
+// dispatch hands the update to the handlers the way a chat worker does.
+func dispatch(update tgbotapi.Update, bot BotClient) {
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	handleUpdate(update, bot)
+}
+
+func TestFakeBotAPI(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	if bot.Self.UserName != "remindeer_test_bot" {
+		t.Errorf("getMe gave %q", bot.Self.UserName)
+	}
+
+	if _, err := sendDirect(bot, tgbotapi.NewMessage(7, "hi")); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if got := fake.sent(7); fmt.Sprint(got) != "[hi]" {
+		t.Errorf("sent %q, want [hi]", got)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// /remind without arguments asks for the text and then the time instead of
+// expecting both on one line. The steps are conversation actions, so any
+// command or the cancel button ends the flow.
+const (
+	flowRemindWhat = "remind_what"
+	flowRemindWhen = "remind_when"
+)
+
+func flowCancelKeyboard(chatID int64) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(tr(chatID, "flow.cancel_button"), "flow:cancel"),
+	))
+}
+
+func sendFlowPrompt(chatID int64, text string, bot BotClient) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = flowCancelKeyboard(chatID)
+	send(bot, msg)
+}
+
+func startRemindFlow(key conversationKey, mention *Mention, bot BotClient) {
+	chatID := key.ChatID
+	conversations[key] = &Conversation{Action: flowRemindWhat, Mention: mention}
+	sendFlowPrompt(chatID, tr(chatID, "flow.remind_what"), bot)
+}
+
+// continueRemindFlow takes the answer to the current step. An answer that
+// doesn't work keeps the flow at the same step so the user can try again.
+func continueRemindFlow(key conversationKey, conversation *Conversation, text string, bot BotClient) {
+	chatID := key.ChatID
+	text = strings.TrimSpace(text)
+	if text == "" {
+		conversations[key] = conversation
+		return
+	}
+
+	if conversation.Action == flowRemindWhat {
+		conversation.Content = reminderContent(chatID, text)
+		conversation.Action = flowRemindWhen
+		conversations[key] = conversation
+		sendFlowPrompt(chatID, tr(chatID, "flow.remind_when", conversation.Content), bot)
+		return
+	}
+
+	now := time.Now()
+	t, err := parseReminderTime(chatID, text, now)
+	if err != nil || !t.After(now) {
+		reply := tr(chatID, "error.time_format")
+		var unitErr *UnknownUnitError
+		if errors.As(err, &unitErr) {
+			reply = tr(chatID, "error.unknown_unit", unitErr.Unit)
+		} else if err == nil {
+			reply = tr(chatID, "error.time_passed")
+		}
+		conversations[key] = conversation
+		sendFlowPrompt(chatID, reply+" "+tr(chatID, "flow.try_again"), bot)
+		return
+	}
+
+	handleReminder(chatID, key.UserID, text, Reminder{Content: conversation.Content, Mention: conversation.Mention}, bot)
+}
+
+// handleFlowButton cancels the flow of whoever pressed the button; other
+// members of a group can't cancel it for them.
+func handleFlowButton(key conversationKey, arg string, messageID int, bot BotClient) {
+	if _, exists := conversations[key]; arg != "cancel" || !exists {
+		return
+	}
+
+	delete(conversations, key)
+	send(bot, tgbotapi.NewEditMessageText(key.ChatID, messageID, tr(key.ChatID, "flow.cancelled")))
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const frequentLimit = 5
+
+// frequentMenus remembers the phrases offered by each /frequent message, so
+// a button keeps meaning the same phrase even if the counts change.
+var frequentMenus = make(map[bulkSelectionKey][]string)
+
+func countPhrase(userData *UserData, content string) {
+	if userData.PhraseCounts == nil {
+		userData.PhraseCounts = make(map[string]int)
+	}
+	userData.PhraseCounts[content]++
+}
+
+// topPhrases returns up to n of the most used reminder texts, most used
+// first and alphabetically among equals.
+func topPhrases(counts map[string]int, n int) []string {
+	phrases := make([]string, 0, len(counts))
+	for phrase := range counts {
+		phrases = append(phrases, phrase)
+	}
+	sort.Slice(phrases, func(i, j int) bool {
+		if counts[phrases[i]] != counts[phrases[j]] {
+			return counts[phrases[i]] > counts[phrases[j]]
+		}
+		return phrases[i] < phrases[j]
+	})
+	if len(phrases) > n {
+		phrases = phrases[:n]
+	}
+
+	return phrases
+}
+
+func handleFrequent(chatID int64, bot BotClient) {
+	var phrases []string
+	if userData, exists := todoData[chatID]; exists {
+		phrases = topPhrases(userData.PhraseCounts, frequentLimit)
+	}
+	if len(phrases) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "frequent.empty"))
+		send(bot, msg)
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, phrase := range phrases {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf("%s (%d)", truncateLabel(phrase), todoData[chatID].PhraseCounts[phrase]),
+			fmt.Sprintf("freq:%d", i))))
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "frequent.title"))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	sent, err := send(bot, msg)
+	if err != nil {
+		return
+	}
+	// As with bulk-cancel menus, only the newest one of a chat stays live.
+	for key := range frequentMenus {
+		if key.ChatID == chatID {
+			delete(frequentMenus, key)
+		}
+	}
+	frequentMenus[bulkSelectionKey{ChatID: chatID, MessageID: sent.MessageID}] = phrases
+}
+
+func handleFrequentButton(key conversationKey, indexStr string, messageID int, bot BotClient) {
+	chatID := key.ChatID
+	phrases, exists := frequentMenus[bulkSelectionKey{ChatID: chatID, MessageID: messageID}]
+	index, err := strconv.Atoi(indexStr)
+	if !exists || err != nil || index < 0 || index >= len(phrases) {
+		send(bot, tgbotapi.NewEditMessageText(chatID, messageID, tr(chatID, "error.stale_list")))
+		return
+	}
+
+	conversations[key] = &Conversation{
+		Action:  "frequent",
+		Content: phrases[index],
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "frequent.when", phrases[index]))
+	msg.ReplyMarkup = tgbotapi.ForceReply{ForceReply: true, Selective: true}
+	send(bot, msg)
+}
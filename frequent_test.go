@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+// Each /frequent menu replaces the chat's previous one.
+func TestFrequentKeepsNewestMenu(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 9601
+
+	todoData[chatID] = &UserData{Todos: []Todo{}, PhraseCounts: map[string]int{"water the plants": 3, "stretch": 1}}
+	for i := 0; i < 3; i++ {
+		dispatch(command(chatID, "/frequent"), bot)
+	}
+
+	menus := 0
+	for key, phrases := range frequentMenus {
+		if key.ChatID != chatID {
+			continue
+		}
+		menus++
+		if len(phrases) != 2 || phrases[0] != "water the plants" {
+			t.Errorf("menu offers %q", phrases)
+		}
+	}
+	if menus != 1 {
+		t.Errorf("%d menus kept for the chat, want 1", menus)
+	}
+}
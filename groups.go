@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Mention is the group member a reminder is for or a todo belongs to.
+// Members without a username are mentioned by name with a text_mention
+// entity, which still notifies them.
+type Mention struct {
+	UserID   int64  `json:"user_id,omitempty"`
+	Username string `json:"username,omitempty"`
+	Name     string `json:"name,omitempty"`
+}
+
+func isGroupChat(chat *tgbotapi.Chat) bool {
+	return chat != nil && (chat.IsGroup() || chat.IsSuperGroup())
+}
+
+// senderMention returns the sender of a group message, or nil in private
+// chats where everything belongs to the one user anyway.
+func senderMention(message *tgbotapi.Message) *Mention {
+	if !isGroupChat(message.Chat) || message.From == nil {
+		return nil
+	}
+
+	return &Mention{
+		UserID:   message.From.ID,
+		Username: message.From.UserName,
+		Name:     message.From.FirstName,
+	}
+}
+
+// cutMention splits a leading "@username" off args, as in
+// "/remind @bob 1h review PR".
+func cutMention(args string) (*Mention, string, bool) {
+	fields, rest := cutFields(args, 1)
+	if len(fields) == 0 {
+		return nil, args, false
+	}
+
+	entities := mentionEntities(fields[0])
+	if len(entities) != 1 || entities[0].Offset != 0 || entities[0].Length != len(fields[0]) {
+		return nil, args, false
+	}
+
+	return &Mention{Username: fields[0][1:]}, rest, true
+}
+
+func (m Mention) String() string {
+	if m.Username != "" {
+		return "@" + m.Username
+	}
+
+	return m.Name
+}
+
+// appendMention adds the mention to the end of msg.
+func appendMention(msg *tgbotapi.MessageConfig, m *Mention) {
+	if m == nil {
+		return
+	}
+
+	offset := len(utf16.Encode([]rune(msg.Text))) + 1
+	msg.Text += " " + m.String()
+	if m.Username != "" {
+		msg.Entities = append(msg.Entities, tgbotapi.MessageEntity{
+			Type:   "mention",
+			Offset: offset,
+			Length: len(m.Username) + 1,
+		})
+		return
+	}
+
+	msg.Entities = append(msg.Entities, tgbotapi.MessageEntity{
+		Type:   "text_mention",
+		Offset: offset,
+		Length: len(utf16.Encode([]rune(m.Name))),
+		User:   &tgbotapi.User{ID: m.UserID, FirstName: m.Name},
+	})
+}
+
+func handleMyTodos(chatID int64, userID int64, bot BotClient) {
+	var list string
+	if userData, exists := todoData[chatID]; exists {
+		now := time.Now()
+		for i, todo := range userData.Todos {
+			if todo.Owner != nil && todo.Owner.UserID == userID {
+				list += fmt.Sprintf("%d. %s (%s)\n", i+1, todo.Text, formatAge(chatID, todo.CreatedAt, now))
+			}
+		}
+	}
+
+	if list == "" {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "groups.my_empty"))
+		send(bot, msg)
+		return
+	}
+
+	send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "groups.my_title")+"\n"+list))
+}
+
+// handleMyChatMember reacts to the bot being added to or removed from a
+// chat. While removed, the chat's reminders are unscheduled but kept, so
+// adding the bot back picks up where it left off.
+func handleMyChatMember(change *tgbotapi.ChatMemberUpdated, bot BotClient) {
+	chatID := change.Chat.ID
+	wasMember := !change.OldChatMember.HasLeft() && !change.OldChatMember.WasKicked()
+	isMember := !change.NewChatMember.HasLeft() && !change.NewChatMember.WasKicked()
+	userData, exists := todoData[chatID]
+
+	switch {
+	case !wasMember && isMember:
+		chatLog(chatID).Info("Added to chat")
+		if exists && userData.RemovedFromChat {
+			userData.RemovedFromChat = false
+			setupChatReminders(chatID, userData, bot)
+		}
+		if isGroupChat(&change.Chat) {
+			msg := tgbotapi.NewMessage(chatID, tr(chatID, "groups.welcome"))
+			send(bot, msg)
+		}
+	case wasMember && !isMember:
+		chatLog(chatID).Info("Removed from chat")
+		if !exists {
+			return
+		}
+		userData.RemovedFromChat = true
+		for _, reminder := range userData.Reminders {
+			unscheduleReminder(chatID, reminder.ID)
+		}
+	default:
+		return
+	}
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+// remindArgs strips a leading "@username" off /remind arguments in groups.
+// Without one, the reminder mentions whoever set it.
+func remindArgs(message *tgbotapi.Message, args string) (*Mention, string) {
+	if isGroupChat(message.Chat) && strings.HasPrefix(args, "@") {
+		if mention, rest, ok := cutMention(args); ok {
+			return mention, rest
+		}
+	}
+
+	return senderMention(message), args
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// withDoneButton adds a "done" button to a reminder's keyboard. On a
+// recurring reminder it counts how often the user actually did it; on a
+// one-off reminder it clears whatever is still pending for it.
+func withDoneButton(chatID int64, keyboard *tgbotapi.InlineKeyboardMarkup, reminder Reminder) *tgbotapi.InlineKeyboardMarkup {
+	if keyboard == nil {
+		keyboard = &tgbotapi.InlineKeyboardMarkup{}
+	}
+
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(tr(chatID, "habit.done_button"), fmt.Sprintf("ack:%d", reminder.ID))))
+
+	return keyboard
+}
+
+// acknowledgeReminder bumps the done counter of a recurring reminder and
+// returns the new total.
+func acknowledgeReminder(userData *UserData, id int) (int, bool) {
+	for i := range userData.Reminders {
+		if userData.Reminders[i].ID == id && userData.Reminders[i].Recurring {
+			userData.Reminders[i].DoneCount++
+			return userData.Reminders[i].DoneCount, true
+		}
+	}
+
+	return 0, false
+}
+
+// completeReminder marks a delivered one-off reminder as done: a nagging
+// reminder stops nagging and a snoozed copy is cancelled. It reports false if
+// the reminder was never delivered.
+func completeReminder(chatID int64, userData *UserData, id int) bool {
+	if reminder, pending := findReminder(chatID, id); pending {
+		if isNagging(reminder) {
+			unscheduleReminder(chatID, id)
+			recordFiredReminder(chatID, reminder, time.Now())
+		} else {
+			removeReminders(chatID, func(r Reminder) bool { return r.ID == id })
+		}
+		return true
+	}
+
+	_, fired := firedReminder(userData, id)
+	return fired
+}
+
+func handleAckButton(chatID int64, idStr string, messageID int, text string, bot BotClient) {
+	id, err := strconv.Atoi(idStr)
+	userData, exists := todoData[chatID]
+	if err != nil || !exists {
+		return
+	}
+
+	// Editing the text also drops the keyboard, so each delivery is counted
+	// at most once.
+	if count, ok := acknowledgeReminder(userData, id); ok {
+		send(bot, tgbotapi.NewEditMessageText(chatID, messageID,
+			text+"\n"+trPlural(chatID, "habit.done_count", count, count)))
+	} else if completeReminder(chatID, userData, id) {
+		send(bot, tgbotapi.NewEditMessageText(chatID, messageID, text+"\n"+tr(chatID, "habit.completed")))
+	} else {
+		send(bot, tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, tgbotapi.NewInlineKeyboardMarkup()))
+		return
+	}
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const maxHeadsUps = 5
+
+var headsUpTimers = make(map[reminderKey][]*time.Timer)
+
+// cutBeforeFlag takes a "--before 1d,1h" option out of /remind arguments
+// and returns the lead times it lists, longest first.
+func cutBeforeFlag(args string) (string, []time.Duration, error) {
+	args, value, found := cutFlag(args, "--before")
+	if !found {
+		return args, nil, nil
+	}
+	if value == "" {
+		return "", nil, fmt.Errorf("missing lead times")
+	}
+
+	var leads []time.Duration
+	for _, value := range strings.Split(value, ",") {
+		lead, err := parseDuration(value)
+		if err != nil || lead <= 0 {
+			return "", nil, fmt.Errorf("invalid lead time %q", value)
+		}
+		if !slices.Contains(leads, lead) {
+			leads = append(leads, lead)
+		}
+	}
+	if len(leads) > maxHeadsUps {
+		return "", nil, fmt.Errorf("too many lead times")
+	}
+	slices.SortFunc(leads, func(a, b time.Duration) int { return cmp.Compare(b, a) })
+
+	return args, leads, nil
+}
+
+// formatLead writes a lead time in the largest unit that divides it, the
+// way it would be typed after --before.
+func formatLead(lead time.Duration) string {
+	switch {
+	case lead%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", lead/(24*time.Hour))
+	case lead%time.Hour == 0:
+		return fmt.Sprintf("%dh", lead/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", lead/time.Minute)
+	}
+}
+
+func formatLeads(leads []time.Duration) string {
+	names := make([]string, len(leads))
+	for i, lead := range leads {
+		names[i] = formatLead(lead)
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// scheduleHeadsUps sets a timer for each lead time of a one-shot reminder
+// that is still ahead. Heads-ups whose time passed while the bot was down
+// are not sent late; the reminder itself still is.
+func scheduleHeadsUps(chatID int64, reminder Reminder, bot BotClient) {
+	key := reminderKey{ChatID: chatID, ReminderID: reminder.ID}
+	for _, lead := range reminder.Before {
+		wait := time.Until(reminder.Time.Add(-lead))
+		if wait <= 0 {
+			continue
+		}
+
+		timer := time.AfterFunc(wait, locked(func() {
+			current, exists := findReminder(chatID, reminder.ID)
+			if !exists || !current.Time.Equal(reminder.Time) || !slices.Contains(current.Before, lead) {
+				return
+			}
+			text := tr(chatID, "headsup.message", formatLead(lead), current.DisplayTitle(), formatTime(chatID, current.Time))
+			if _, err := send(bot, tgbotapi.NewMessage(chatID, text)); err != nil {
+				chatLog(chatID).Error("Failed to send heads-up", "reminder_id", reminder.ID, "lead", lead, "err", err)
+			}
+		}))
+		headsUpTimers[key] = append(headsUpTimers[key], timer)
+	}
+}
+
+func unscheduleHeadsUps(key reminderKey) {
+	for _, timer := range headsUpTimers[key] {
+		timer.Stop()
+	}
+	delete(headsUpTimers, key)
+}
+
+// handleCancelHeadsUp drops one lead time of a pending reminder and keeps
+// the reminder and its other heads-ups.
+func handleCancelHeadsUp(chatID int64, indexStr string, leadStr string, bot BotClient) {
+	reminder, ok := pendingReminderByIndex(chatID, indexStr)
+	if !ok {
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index")))
+		return
+	}
+	lead, err := parseDuration(leadStr)
+	if err != nil || !slices.Contains(reminder.Before, lead) {
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "headsup.not_found", leadStr, reminder.DisplayTitle())))
+		return
+	}
+
+	userData := todoData[chatID]
+	for i := range userData.Reminders {
+		if userData.Reminders[i].ID == reminder.ID {
+			before := slices.DeleteFunc(slices.Clone(reminder.Before), func(d time.Duration) bool { return d == lead })
+			if len(before) == 0 {
+				before = nil
+			}
+			userData.Reminders[i].Before = before
+			key := reminderKey{ChatID: chatID, ReminderID: reminder.ID}
+			unscheduleHeadsUps(key)
+			scheduleHeadsUps(chatID, userData.Reminders[i], bot)
+			break
+		}
+	}
+
+	send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "headsup.cancelled", formatLead(lead), reminder.DisplayTitle())))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
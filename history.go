@@ -0,0 +1,73 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const maxHistory = 100
+
+type FiredReminder struct {
+	Reminder Reminder  `json:"reminder"`
+	FiredAt  time.Time `json:"fired_at"`
+}
+
+// recordFiredReminder moves a fired one-shot reminder from the pending list
+// into the chat's history. Recurring reminders stay pending and only get a
+// history entry, unless they were limited to a number of fires and this was
+// the last one.
+func recordFiredReminder(chatID int64, reminder Reminder, firedAt time.Time) {
+	userData, exists := todoData[chatID]
+	if !exists {
+		return
+	}
+
+	if reminder.Recurring && reminder.RemainingFires > 0 {
+		for i := range userData.Reminders {
+			if userData.Reminders[i].ID == reminder.ID {
+				userData.Reminders[i].RemainingFires--
+				if userData.Reminders[i].RemainingFires == 0 {
+					removeReminders(chatID, func(r Reminder) bool { return r.ID == reminder.ID })
+				}
+				break
+			}
+		}
+	}
+
+	if !reminder.Recurring {
+		for i := range userData.Reminders {
+			if userData.Reminders[i].ID == reminder.ID {
+				userData.Reminders = append(userData.Reminders[:i], userData.Reminders[i+1:]...)
+				unlinkReminder(userData, reminder)
+				break
+			}
+		}
+		key := reminderKey{ChatID: chatID, ReminderID: reminder.ID}
+		delete(reminderTimers, key)
+		unscheduleHeadsUps(key)
+	}
+
+	userData.History = append(userData.History, FiredReminder{Reminder: reminder, FiredAt: firedAt})
+	if len(userData.History) > maxHistory {
+		userData.History = userData.History[len(userData.History)-maxHistory:]
+	}
+}
+
+func handleClearHistory(chatID int64, bot BotClient) {
+	cleared := 0
+	if userData, exists := todoData[chatID]; exists {
+		cleared = len(userData.History)
+		userData.History = nil
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "history.cleared", cleared))
+	send(bot, msg)
+
+	if cleared > 0 {
+		if err := saveUserData(); err != nil {
+			slog.Error("Failed to save user data", "err", err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Each file in locales/ is a flat JSON object of message keys for the
+// language named by the file, e.g. locales/en.json. Adding a language only
+// takes a new file; keys it leaves out fall back to defaultLanguage.
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	entries, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		fatal("Failed to read message catalogs", "err", err)
+	}
+
+	catalogs := make(map[string]map[string]string)
+	for _, entry := range entries {
+		data, err := localeFiles.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			fatal("Failed to read message catalog", "file", entry.Name(), "err", err)
+		}
+
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			fatal("Failed to parse message catalog", "file", entry.Name(), "err", err)
+		}
+		catalogs[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+
+	return catalogs
+}
+
+func languages() []string {
+	names := make([]string, 0, len(catalogs))
+	for name := range catalogs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// message looks key up for the language. A key missing from every catalog
+// is returned as is, so a forgotten translation shows up without breaking
+// the reply.
+func message(language string, key string) string {
+	if text, ok := catalogs[language][key]; ok {
+		return text
+	}
+	if text, ok := catalogs[defaultLanguage][key]; ok {
+		return text
+	}
+
+	return key
+}
+
+// tr returns the chat's translation of key, formatted with args if given.
+func tr(chatID int64, key string, args ...any) string {
+	text := message(chatLanguage(chatID), key)
+	if len(args) == 0 {
+		return text
+	}
+
+	return fmt.Sprintf(text, args...)
+}
+
+// trPlural is tr for a message that depends on the count n. The catalogs
+// keep one key per plural form: key.one, key.few and key.many for
+// Ukrainian, key.one and key.many for other languages.
+func trPlural(chatID int64, key string, n int, args ...any) string {
+	language := chatLanguage(chatID)
+	return fmt.Sprintf(message(language, key+"."+pluralForm(language, n)), args...)
+}
+
+// pluralForm picks the plural form of a language for n. Ukrainian has one
+// ("1 день"), few ("2 дні") and many ("5 днів"); English and the default
+// case only tell one from many.
+func pluralForm(language string, n int) string {
+	if language != "uk" {
+		if n == 1 {
+			return "one"
+		}
+		return "many"
+	}
+
+	n %= 100
+	if n >= 11 && n <= 14 {
+		return "many"
+	}
+
+	switch n % 10 {
+	case 1:
+		return "one"
+	case 2, 3, 4:
+		return "few"
+	default:
+		return "many"
+	}
+}
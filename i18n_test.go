@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Every key the code asks for is in the default catalog, and the other
+// catalogs translate the same keys.
+func TestCatalogsHaveEveryKey(t *testing.T) {
+	keys := map[string]bool{}
+	for _, command := range commands {
+		keys["commands."+command.Name] = true
+	}
+	// Keys put together at run time, like "media."+kind, are skipped.
+	literal := regexp.MustCompile(`\btr(?:Plural)?\(\s*[^,]+,\s*"([^"]+)"\s*[,)]`)
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, match := range literal.FindAllStringSubmatch(string(src), -1) {
+			keys[match[1]] = true
+		}
+	}
+
+	for key := range keys {
+		if _, ok := catalogs[defaultLanguage][key]; ok {
+			continue
+		}
+		if _, ok := catalogs[defaultLanguage][key+".many"]; !ok {
+			t.Errorf("%s is missing from the %s catalog", key, defaultLanguage)
+		}
+	}
+
+	for _, language := range languages() {
+		for key := range catalogs[defaultLanguage] {
+			if strings.HasSuffix(key, ".few") && language != "uk" {
+				continue
+			}
+			if _, ok := catalogs[language][key]; !ok {
+				t.Errorf("%s is missing from the %s catalog", key, language)
+			}
+		}
+	}
+}
+
+func TestPluralForm(t *testing.T) {
+	tests := []struct {
+		language string
+		n        int
+		want     string
+	}{
+		{"uk", 1, "one"},
+		{"uk", 2, "few"},
+		{"uk", 4, "few"},
+		{"uk", 5, "many"},
+		{"uk", 11, "many"},
+		{"uk", 12, "many"},
+		{"uk", 21, "one"},
+		{"uk", 22, "few"},
+		{"uk", 111, "many"},
+		{"en", 1, "one"},
+		{"en", 0, "many"},
+		{"en", 2, "many"},
+		{"en", 21, "many"},
+	}
+	for _, test := range tests {
+		if got := pluralForm(test.language, test.n); got != test.want {
+			t.Errorf("pluralForm(%s, %d) = %s, want %s", test.language, test.n, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const maxImportSize = 1 << 20
+
+type googleTask struct {
+	Kind    string `json:"kind"`
+	Title   string `json:"title"`
+	Status  string `json:"status"`
+	Created string `json:"created"`
+}
+
+// googleTasksExport covers both a Takeout export (a list of task lists)
+// and a single task list as returned by the Tasks API.
+type googleTasksExport struct {
+	Kind  string `json:"kind"`
+	Items []struct {
+		googleTask
+		Items []googleTask `json:"items"`
+	} `json:"items"`
+}
+
+type todoistTask struct {
+	Content     string `json:"content"`
+	IsCompleted bool   `json:"is_completed"`
+	Checked     any    `json:"checked"`
+	CreatedAt   string `json:"created_at"`
+	AddedAt     string `json:"added_at"`
+}
+
+type ImportResult struct {
+	Format  string
+	Todos   []Todo
+	Skipped int
+}
+
+// parseTodoImport detects whether raw is a Google Tasks or Todoist export
+// and maps its open tasks to todos. Completed tasks are skipped.
+func parseTodoImport(raw []byte) (ImportResult, error) {
+	trimmed := strings.TrimSpace(string(raw))
+
+	if strings.HasPrefix(trimmed, "[") {
+		var tasks []todoistTask
+		if err := json.Unmarshal(raw, &tasks); err != nil {
+			return ImportResult{}, err
+		}
+		return importTodoistTasks(tasks), nil
+	}
+
+	var probe struct {
+		Kind  string            `json:"kind"`
+		Items []json.RawMessage `json:"items"`
+	}
+	if err := json.Unmarshal(raw, &probe); err != nil {
+		return ImportResult{}, err
+	}
+
+	if strings.HasPrefix(probe.Kind, "tasks#") {
+		var export googleTasksExport
+		if err := json.Unmarshal(raw, &export); err != nil {
+			return ImportResult{}, err
+		}
+		return importGoogleTasks(export), nil
+	}
+
+	if probe.Items != nil {
+		var backup struct {
+			Items []todoistTask `json:"items"`
+		}
+		if err := json.Unmarshal(raw, &backup); err != nil {
+			return ImportResult{}, err
+		}
+		return importTodoistTasks(backup.Items), nil
+	}
+
+	return ImportResult{}, fmt.Errorf("unrecognized export format")
+}
+
+func importGoogleTasks(export googleTasksExport) ImportResult {
+	result := ImportResult{Format: "Google Tasks"}
+	add := func(task googleTask) {
+		if task.Title == "" || task.Status == "completed" {
+			result.Skipped++
+			return
+		}
+		result.Todos = append(result.Todos, Todo{Text: task.Title, CreatedAt: parseImportTime(task.Created)})
+	}
+
+	for _, item := range export.Items {
+		if item.Kind == "tasks#taskList" || item.Items != nil {
+			for _, task := range item.Items {
+				add(task)
+			}
+			continue
+		}
+		add(item.googleTask)
+	}
+
+	return result
+}
+
+func importTodoistTasks(tasks []todoistTask) ImportResult {
+	result := ImportResult{Format: "Todoist"}
+	for _, task := range tasks {
+		checked := task.Checked == true || task.Checked == float64(1)
+		if task.Content == "" || task.IsCompleted || checked {
+			result.Skipped++
+			continue
+		}
+
+		created := task.CreatedAt
+		if created == "" {
+			created = task.AddedAt
+		}
+		result.Todos = append(result.Todos, Todo{Text: task.Content, CreatedAt: parseImportTime(created)})
+	}
+
+	return result
+}
+
+func parseImportTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return t
+}
+
+func downloadFile(bot BotClient, fileID string, maxSize int64) ([]byte, error) {
+	url, err := bot.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download failed: %s", resp.Status)
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(raw)) > maxSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxSize)
+	}
+
+	return raw, nil
+}
+
+func handleImportTodos(chatID int64, document *tgbotapi.Document, bot BotClient) {
+	if document == nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "importer.send_file"))
+		send(bot, msg)
+		return
+	}
+	if document.FileSize > maxImportSize {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "backup.too_large"))
+		send(bot, msg)
+		return
+	}
+
+	var raw []byte
+	var err error
+	unlocked(func() {
+		raw, err = downloadFile(bot, document.FileID, maxImportSize)
+	})
+	if err != nil {
+		chatLog(chatID).Error("Failed to download import file", "err", err)
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "backup.download_failed"))
+		send(bot, msg)
+		return
+	}
+
+	result, err := parseTodoImport(raw)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "importer.invalid"))
+		send(bot, msg)
+		return
+	}
+
+	size := 0
+	for _, todo := range result.Todos {
+		size += len(todo.Text)
+	}
+	if !checkQuota(chatID, size, bot) {
+		return
+	}
+
+	now := time.Now()
+	added := 0
+	for _, todo := range result.Todos {
+		if todo.CreatedAt.IsZero() {
+			todo.CreatedAt = now
+		}
+		if _, err := addTodo(chatID, todo); err != nil {
+			break
+		}
+		added++
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "importer.done",
+		result.Format, added, result.Skipped))
+	send(bot, msg)
+	if dropped := len(result.Todos) - added; dropped > 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "importer.over_limit", maxTodosPerChat, dropped))
+		send(bot, msg)
+	}
+
+	if added > 0 {
+		if err := saveUserData(); err != nil {
+			slog.Error("Failed to save user data", "err", err)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Inline results depend on the current time only through absolute times,
+// which don't change within a few seconds.
+const inlineCacheTime = 5
+
+// inlineReminder parses an inline query like "30m call Alice" the way
+// /remind parses its arguments. Reminders are set in the user's private
+// chat with the bot, so that chat's timezone applies.
+func inlineReminder(userID int64, query string, now time.Time) (string, string, time.Time, bool) {
+	timeStr, content := cutReminderTime(strings.TrimSpace(query))
+	if timeStr == "" || content == "" {
+		return "", "", time.Time{}, false
+	}
+
+	at, err := parseReminderTime(userID, timeStr, now)
+	if err != nil || !at.After(now) {
+		return "", "", time.Time{}, false
+	}
+
+	return timeStr, content, at, true
+}
+
+// handleInlineQuery offers to set the reminder typed after the bot's
+// username. Queries that don't parse get no results, only a button that
+// opens the private chat with the bot.
+func handleInlineQuery(query *tgbotapi.InlineQuery, bot BotClient) {
+	userID := query.From.ID
+	answer := tgbotapi.InlineConfig{
+		InlineQueryID: query.ID,
+		CacheTime:     inlineCacheTime,
+		IsPersonal:    true,
+		Results:       []interface{}{},
+	}
+
+	timeStr, content, at, ok := inlineReminder(userID, query.Query, time.Now())
+	if !ok {
+		answer.SwitchPMText = tr(userID, "inline.help")
+		answer.SwitchPMParameter = "inline"
+		request(bot, answer)
+		return
+	}
+
+	title := tr(userID, "inline.title_at", formatTime(userID, at))
+	posted := tr(userID, "inline.posted_at", formatTime(userID, at), content)
+	if _, err := parseDuration(timeStr); err == nil {
+		title = tr(userID, "inline.title_in", timeStr)
+		posted = tr(userID, "inline.posted_in", timeStr, content)
+	}
+
+	result := tgbotapi.NewInlineQueryResultArticle("remind", title, posted)
+	result.Description = content
+	answer.Results = []interface{}{result}
+	request(bot, answer)
+}
+
+// handleChosenInlineResult sets the reminder the user picked. Telegram only
+// reports chosen results if inline feedback is turned on for the bot in
+// @BotFather. The query is parsed again rather than trusted from the
+// result, and the reminder goes to the user's private chat.
+func handleChosenInlineResult(result *tgbotapi.ChosenInlineResult, bot BotClient) {
+	userID := result.From.ID
+	timeStr, content, _, ok := inlineReminder(userID, result.Query, time.Now())
+	if !ok {
+		return
+	}
+
+	if _, exists := todoData[userID]; !exists {
+		todoData[userID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	content = reminderContent(userID, content)
+	if !checkQuota(userID, len(content), bot) {
+		return
+	}
+
+	createReminder(userID, timeStr, Reminder{Content: content}, bot)
+}
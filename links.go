@@ -0,0 +1,151 @@
+package main
+
+import (
+	"log/slog"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func findTodoIndex(userData *UserData, id int) int {
+	for i := range userData.Todos {
+		if userData.Todos[i].ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// linkedReminders returns the todo's reminders that still exist.
+func linkedReminders(chatID int64, todo Todo) []Reminder {
+	var linked []Reminder
+	for _, id := range todo.ReminderIDs {
+		if reminder, exists := findReminder(chatID, id); exists {
+			linked = append(linked, reminder)
+		}
+	}
+
+	return linked
+}
+
+// unlinkReminder drops a removed reminder from the todo it was linked to.
+func unlinkReminder(userData *UserData, reminder Reminder) {
+	if reminder.TodoID == 0 {
+		return
+	}
+	i := findTodoIndex(userData, reminder.TodoID)
+	if i < 0 {
+		return
+	}
+
+	todo := &userData.Todos[i]
+	for j, id := range todo.ReminderIDs {
+		if id == reminder.ID {
+			todo.ReminderIDs = append(todo.ReminderIDs[:j], todo.ReminderIDs[j+1:]...)
+			break
+		}
+	}
+}
+
+func relinkReminder(userData *UserData, reminder Reminder) {
+	if reminder.TodoID == 0 {
+		return
+	}
+	if i := findTodoIndex(userData, reminder.TodoID); i >= 0 {
+		userData.Todos[i].ReminderIDs = append(userData.Todos[i].ReminderIDs, reminder.ID)
+	}
+}
+
+func handleLink(chatID int64, todoIndexStr string, reminderIndexStr string, bot BotClient) {
+	userData, exists := todoData[chatID]
+	todoIndex, err := strconv.Atoi(todoIndexStr)
+	if !exists || err != nil || todoIndex < 1 || todoIndex > len(userData.Todos) {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+	reminder, ok := pendingReminderByIndex(chatID, reminderIndexStr)
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	for i := range userData.Reminders {
+		if userData.Reminders[i].ID == reminder.ID {
+			unlinkReminder(userData, userData.Reminders[i])
+			userData.Reminders[i].TodoID = userData.Todos[todoIndex-1].ID
+			relinkReminder(userData, userData.Reminders[i])
+		}
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "links.linked", reminder.Content, userData.Todos[todoIndex-1].Text))
+	send(bot, msg)
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+// cancelLinkedReminders cancels the reminders of todos that were just
+// completed, through the undo log so /undo can bring them back.
+func cancelLinkedReminders(chatID int64, reminders []Reminder, bot BotClient) {
+	ids := make(map[int]bool)
+	for _, reminder := range reminders {
+		ids[reminder.ID] = true
+	}
+
+	removed := cancelReminders(chatID, func(reminder Reminder) bool {
+		return ids[reminder.ID]
+	})
+	send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "links.cancelled", removed)))
+}
+
+// linkedTodo finds the todo a reminder was set for.
+func linkedTodo(chatID int64, reminder Reminder) (Todo, bool) {
+	userData, exists := todoData[chatID]
+	if !exists || reminder.TodoID == 0 {
+		return Todo{}, false
+	}
+	if i := findTodoIndex(userData, reminder.TodoID); i >= 0 {
+		return userData.Todos[i], true
+	}
+
+	return Todo{}, false
+}
+
+func handleRemindTodo(chatID int64, indexStr string, timeStr string, bot BotClient) {
+	userData, exists := todoData[chatID]
+	index, err := strconv.Atoi(indexStr)
+	if !exists || err != nil || index < 1 || index > len(userData.Todos) {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	now := time.Now()
+	at, err := parseReminderTime(chatID, timeStr, now)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.time_format"))
+		send(bot, msg)
+		return
+	}
+	if !at.After(now) {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.time_passed"))
+		send(bot, msg)
+		return
+	}
+	todo := userData.Todos[index-1]
+	reminder, err := remindTodoAt(chatID, todo, at, bot)
+	if err != nil {
+		sendReminderLimit(chatID, bot)
+		return
+	}
+	send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "links.todo_reminder", todo.Text, formatTime(chatID, reminder.Time))))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
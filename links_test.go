@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestFindTodoIndex(t *testing.T) {
+	userData := &UserData{Todos: []Todo{{ID: 3, Text: "a"}, {ID: 7, Text: "b"}, {ID: 4, Text: "c"}}}
+
+	tests := []struct {
+		id   int
+		want int
+	}{
+		{3, 0},
+		{7, 1},
+		{4, 2},
+		{5, -1},
+		{0, -1},
+	}
+	for _, test := range tests {
+		if got := findTodoIndex(userData, test.id); got != test.want {
+			t.Errorf("findTodoIndex(%d) = %d, want %d", test.id, got, test.want)
+		}
+	}
+
+	if got := findTodoIndex(&UserData{}, 1); got != -1 {
+		t.Errorf("findTodoIndex on no todos = %d, want -1", got)
+	}
+}
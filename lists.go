@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const maxTodoLists = 20
+
+// Named lists live next to the default list, which stays in UserData.Todos
+// so data saved before lists existed needs no migration. List names must not
+// clash with the /todo filters or the /list subcommands.
+var listNamePattern = regexp.MustCompile(`^[\p{L}\d_-]{1,32}$`)
+
+var reservedListNames = map[string]bool{
+	"new": true, "delete": true, "overdue": true, "today": true,
+}
+
+// todoList looks up a named list; names are case-insensitive.
+func todoList(userData *UserData, name string) (string, bool) {
+	name = strings.ToLower(name)
+	_, exists := userData.Lists[name]
+
+	return name, exists
+}
+
+// cutListName splits a leading list name off args if it names an existing
+// list of the chat.
+func cutListName(chatID int64, args string) (string, string, bool) {
+	userData, exists := todoData[chatID]
+	if !exists || len(userData.Lists) == 0 {
+		return "", args, false
+	}
+	fields, rest := cutFields(args, 1)
+	if len(fields) == 0 {
+		return "", args, false
+	}
+	name, exists := todoList(userData, fields[0])
+	if !exists {
+		return "", args, false
+	}
+
+	return name, rest, true
+}
+
+func handleListCommand(chatID int64, args string, bot BotClient) {
+	fields := strings.Fields(args)
+	if len(fields) != 2 || (fields[0] != "new" && fields[0] != "delete") {
+		sendUsage(chatID, "list", bot)
+		return
+	}
+	name := strings.ToLower(fields[1])
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	userData := todoData[chatID]
+
+	if fields[0] == "new" {
+		if !listNamePattern.MatchString(name) || reservedListNames[name] {
+			msg := tgbotapi.NewMessage(chatID, tr(chatID, "lists.invalid_name"))
+			send(bot, msg)
+			return
+		}
+		if _, exists := userData.Lists[name]; exists {
+			send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "lists.exists", name)))
+			return
+		}
+		if len(userData.Lists) >= maxTodoLists {
+			send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "lists.limit", maxTodoLists)))
+			return
+		}
+		if userData.Lists == nil {
+			userData.Lists = make(map[string][]Todo)
+		}
+		userData.Lists[name] = []Todo{}
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "lists.created", name, name)))
+	} else {
+		todos, exists := userData.Lists[name]
+		if !exists {
+			send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "lists.not_found", name)))
+			return
+		}
+		delete(userData.Lists, name)
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "lists.deleted", name, len(todos))))
+	}
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func handleListsOverview(chatID int64, bot BotClient) {
+	userData, exists := todoData[chatID]
+	if !exists || len(userData.Lists) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "lists.only_main"))
+		send(bot, msg)
+		return
+	}
+
+	names := make([]string, 0, len(userData.Lists))
+	for name := range userData.Lists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	text := tr(chatID, "lists.title", len(userData.Todos)) + "\n"
+	for _, name := range names {
+		text += fmt.Sprintf("• %s (%d) — /todo %s\n", name, len(userData.Lists[name]), name)
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+}
+
+func handleSetListTodo(chatID int64, name string, todo Todo, bot BotClient) {
+	if !checkTodoLimit(chatID, bot) || !checkQuota(chatID, len(todo.Text), bot) {
+		return
+	}
+
+	userData := todoData[chatID]
+	todo.CreatedAt = time.Now()
+	todo = parseTodoAttributes(todo, todo.CreatedAt.In(chatLocation(chatID)))
+	userData.NextTodoID++
+	todo.ID = userData.NextTodoID
+	userData.Lists[name] = append(userData.Lists[name], todo)
+
+	send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "todo.added_to_list", todo.Text, name)))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func handleNamedTodoList(chatID int64, name string, bot BotClient) {
+	todos := todoData[chatID].Lists[name]
+	if len(todos) == 0 {
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "lists.empty", name)))
+		return
+	}
+
+	text := tr(chatID, "lists.list_title", name) + "\n" + renderTodos(chatID, todos, sortedTodoIndexes(todos, nil))
+	send(bot, tgbotapi.NewMessage(chatID, text))
+}
+
+func handleMarkListDone(chatID int64, name string, indexStr string, bot BotClient) {
+	userData := todoData[chatID]
+	todos := userData.Lists[name]
+
+	indexes := make(map[int]bool)
+	for _, field := range strings.Fields(indexStr) {
+		index, err := strconv.Atoi(field)
+		if err != nil || index < 1 || index > len(todos) {
+			msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+			send(bot, msg)
+			return
+		}
+		indexes[index] = true
+	}
+	if len(indexes) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	kept := todos[:0]
+	now := time.Now()
+	for i, todo := range todos {
+		if !indexes[i+1] {
+			kept = append(kept, todo)
+			continue
+		}
+		archiveTodo(userData, todo, name, now)
+	}
+	userData.Lists[name] = kept
+
+	if !userData.Settings.QuietDone {
+		text := tr(chatID, "todo.done")
+		if len(indexes) > 1 {
+			text = tr(chatID, "todo.done_many", len(indexes))
+		}
+		send(bot, tgbotapi.NewMessage(chatID, text))
+	}
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Chats that haven't picked a language or timezone get these, which
+// DEFAULT_LANGUAGE and DEFAULT_TIMEZONE can change.
+var (
+	defaultLanguage = "uk"
+	defaultLocation = time.UTC
+)
+
+var clockFormats = map[int]string{
+	12: "3:04 PM",
+	24: "15:04",
+}
+
+var weekStarts = map[string]time.Weekday{
+	"mon": time.Monday,
+	"sun": time.Sunday,
+}
+
+func chatLanguage(chatID int64) string {
+	if userData, exists := todoData[chatID]; exists && userData.Settings.Language != "" {
+		return userData.Settings.Language
+	}
+
+	return defaultLanguage
+}
+
+func chatClock(chatID int64) int {
+	if userData, exists := todoData[chatID]; exists && userData.Settings.Clock != 0 {
+		return userData.Settings.Clock
+	}
+
+	clock, _ := strconv.Atoi(tr(chatID, "format.clock"))
+	return clock
+}
+
+// chatLocation returns the chat's timezone, falling back to the default.
+func chatLocation(chatID int64) *time.Location {
+	if userData, exists := todoData[chatID]; exists && userData.Settings.Timezone != "" {
+		if loc, err := parseTimezone(userData.Settings.Timezone); err == nil {
+			return loc
+		}
+	}
+
+	return defaultLocation
+}
+
+func chatWeekStart(chatID int64) time.Weekday {
+	if userData, exists := todoData[chatID]; exists && userData.Settings.WeekStart != "" {
+		return weekStarts[userData.Settings.WeekStart]
+	}
+
+	return weekStarts[tr(chatID, "format.week_start")]
+}
+
+// startOfWeek returns midnight of the day the week containing t begins on.
+func startOfWeek(t time.Time, weekStart time.Weekday) time.Time {
+	offset := (int(t.Weekday()) - int(weekStart) + 7) % 7
+	year, month, day := t.Date()
+	return time.Date(year, month, day-offset, 0, 0, 0, 0, t.Location())
+}
+
+func formatTime(chatID int64, t time.Time) string {
+	layout := tr(chatID, "format.date") + " " + clockFormats[chatClock(chatID)]
+	return t.In(chatLocation(chatID)).Format(layout)
+}
+
+func handleLanguageSetting(chatID int64, language string, bot BotClient) {
+	if _, ok := catalogs[language]; !ok {
+		sendUsage(chatID, "language", bot)
+		return
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	todoData[chatID].Settings.Language = language
+
+	send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "settings.language", language)))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func handleClockSetting(chatID int64, value string, bot BotClient) {
+	clock, err := strconv.Atoi(value)
+	if _, ok := clockFormats[clock]; err != nil || !ok {
+		sendUsage(chatID, "clock", bot)
+		return
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	todoData[chatID].Settings.Clock = clock
+
+	send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "settings.clock", clock)))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func handleWeekStartSetting(chatID int64, value string, bot BotClient) {
+	if _, ok := weekStarts[value]; !ok {
+		sendUsage(chatID, "weekstart", bot)
+		return
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	todoData[chatID].Settings.WeekStart = value
+
+	text := tr(chatID, "settings.week_mon")
+	if value == "sun" {
+		text = tr(chatID, "settings.week_sun")
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+// parseTimezone accepts an IANA name or a UTC offset such as "+3", "UTC-5"
+// or "+5:30". Whole-hour offsets map to the Etc/GMT zones, whose sign is
+// inverted; the rest, like India's +5:30 or Nepal's +5:45, get a fixed zone
+// named "UTC+05:30", which parseTimezone reads back when a saved setting or
+// CRON_TZ schedule is loaded.
+func parseTimezone(name string) (*time.Location, error) {
+	offset := strings.TrimPrefix(strings.TrimPrefix(strings.ToUpper(name), "UTC"), "GMT")
+	if offset == "" || (offset[0] != '+' && offset[0] != '-') {
+		return time.LoadLocation(name)
+	}
+
+	hoursStr, minutesStr, hasMinutes := strings.Cut(offset[1:], ":")
+	hours, err := strconv.Atoi(hoursStr)
+	minutes := 0
+	if err == nil && hasMinutes {
+		minutes, err = strconv.Atoi(minutesStr)
+	}
+	if err != nil || hours < 0 || minutes < 0 || minutes > 59 || hasMinutes && len(minutesStr) != 2 {
+		return nil, fmt.Errorf("invalid UTC offset %q", name)
+	}
+	if hours == 0 && minutes == 0 {
+		return time.UTC, nil
+	}
+
+	sign, seconds := offset[:1], (hours*60+minutes)*60
+	if sign == "-" {
+		seconds = -seconds
+	}
+	if seconds < -12*3600 || seconds > 14*3600 {
+		return nil, fmt.Errorf("UTC offset %q out of range", name)
+	}
+	if minutes != 0 {
+		return time.FixedZone(fmt.Sprintf("UTC%s%02d:%02d", sign, hours, minutes), seconds), nil
+	}
+
+	etcSign := "-"
+	if sign == "-" {
+		etcSign = "+"
+	}
+
+	return time.LoadLocation(fmt.Sprintf("Etc/GMT%s%d", etcSign, hours))
+}
+
+func handleTimezoneSetting(chatID int64, name string, bot BotClient) {
+	if name == "" {
+		sendUsage(chatID, "timezone", bot)
+		return
+	}
+	loc, err := parseTimezone(name)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "settings.unknown_timezone", name))
+		send(bot, msg)
+		return
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	userData := todoData[chatID]
+	old := chatLocation(chatID)
+	userData.Settings.Timezone = loc.String()
+
+	for i := range userData.Reminders {
+		reminder := &userData.Reminders[i]
+		if !reminder.Recurring {
+			continue
+		}
+		// A zone other than the chat's was written by the user and stays.
+		if zone := cronTZ(reminder.Schedule); zone != "" && zone != old.String() {
+			continue
+		}
+		unscheduleReminder(chatID, reminder.ID)
+		reminder.Schedule = withCronTZ(stripCronTZ(reminder.Schedule), loc)
+		scheduleReminder(chatID, *reminder, bot)
+	}
+
+	scheduleDigest(chatID, bot)
+
+	now := time.Now().In(loc)
+	send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "settings.timezone", loc, now.Format("15:04"))))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimezone(t *testing.T) {
+	at := time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		offset time.Duration
+	}{
+		{"Europe/Kyiv", 2 * time.Hour},
+		{"America/New_York", -5 * time.Hour},
+		{"UTC", 0},
+		{"+3", 3 * time.Hour},
+		{"-5", -5 * time.Hour},
+		{"UTC+2", 2 * time.Hour},
+		{"utc-7", -7 * time.Hour},
+		{"GMT+1", time.Hour},
+		{"UTC+3:00", 3 * time.Hour},
+		{"UTC+0", 0},
+		{"+5:30", 5*time.Hour + 30*time.Minute},
+		{"UTC+05:45", 5*time.Hour + 45*time.Minute},
+		{"-3:30", -3*time.Hour - 30*time.Minute},
+		{"UTC-09:30", -9*time.Hour - 30*time.Minute},
+	}
+	for _, test := range tests {
+		loc, err := parseTimezone(test.name)
+		if err != nil {
+			t.Errorf("parseTimezone(%q): %v", test.name, err)
+			continue
+		}
+		if _, offset := at.In(loc).Zone(); time.Duration(offset)*time.Second != test.offset {
+			t.Errorf("parseTimezone(%q) is UTC%+v, want UTC%+v", test.name, time.Duration(offset)*time.Second, test.offset)
+		}
+		// The stored name must load back as the same zone.
+		if again, err := parseTimezone(loc.String()); err != nil || at.In(again).String() != at.In(loc).String() {
+			t.Errorf("parseTimezone(%q) does not read back its name %q: %v", test.name, loc, err)
+		}
+	}
+}
+
+func TestParseTimezoneRejects(t *testing.T) {
+	for _, name := range []string{"Mars/Olympus", "UTC+x", "+", "UTC+15", "+5:61", "+5:3", "-12:30"} {
+		if loc, err := parseTimezone(name); err == nil {
+			t.Errorf("parseTimezone(%q) = %v, want an error", name, loc)
+		}
+	}
+}
+
+// A recurring reminder in a half-hour zone fires at its local time.
+func TestParseCronWithFixedZone(t *testing.T) {
+	schedule, err := parseCron("CRON_TZ=UTC+05:30 0 9 * * *")
+	if err != nil {
+		t.Fatalf("parseCron: %v", err)
+	}
+	from := time.Date(2024, time.January, 15, 0, 0, 0, 0, time.UTC)
+	if got, want := schedule.Next(from).UTC(), time.Date(2024, time.January, 15, 3, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("next run %v, want %v", got, want)
+	}
+}
+
+// Changing the timezone moves the zones the bot added to recurring
+// schedules, and leaves a CRON_TZ the user wrote alone.
+func TestTimezoneSettingMovesOnlyAddedZones(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 9701
+
+	tests := []struct {
+		schedule string
+		want     string
+	}{
+		{"CRON_TZ=Europe/Kyiv 0 9 * * *", "CRON_TZ=America/New_York 0 9 * * *"},
+		{"0 9 * * *", "CRON_TZ=America/New_York 0 9 * * *"},
+		{"CRON_TZ=Asia/Tokyo 0 9 * * *", "CRON_TZ=Asia/Tokyo 0 9 * * *"},
+		{"TZ=Asia/Tokyo 30 8 * * 1", "TZ=Asia/Tokyo 30 8 * * 1"},
+		{"@every 2h", "@every 2h"},
+	}
+	userData := &UserData{Todos: []Todo{}, Settings: Settings{Timezone: "Europe/Kyiv"}}
+	for i, test := range tests {
+		userData.Reminders = append(userData.Reminders, Reminder{ID: i + 1, Content: "stretch", Recurring: true, Schedule: test.schedule})
+	}
+	userData.NextReminderID = len(tests)
+	todoData[chatID] = userData
+
+	dispatch(command(chatID, "/timezone America/New_York"), bot)
+	for i, test := range tests {
+		if got := todoData[chatID].Reminders[i].Schedule; got != test.want {
+			t.Errorf("%q became %q, want %q", test.schedule, got, test.want)
+		}
+	}
+}
+
+// /clock picks 12- or 24-hour times over the language's default.
+func TestClockSetting(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	at := time.Date(2024, time.March, 14, 21, 5, 0, 0, time.UTC)
+
+	tests := []struct {
+		language string
+		command  string
+		want     string
+	}{
+		{"en", "", "Mar 14, 2024 9:05 PM"},
+		{"uk", "", "14.03.2024 21:05"},
+		{"en", "/clock 24", "Mar 14, 2024 21:05"},
+		{"uk", "/clock 12", "14.03.2024 9:05 PM"},
+		{"en", "/clock 13", "Mar 14, 2024 9:05 PM"},
+	}
+	for i, test := range tests {
+		chatID := int64(10301 + i)
+		todoData[chatID] = &UserData{Todos: []Todo{}, Settings: Settings{Language: test.language}}
+		if test.command != "" {
+			dispatch(command(chatID, test.command), bot)
+		}
+
+		if got := formatTime(chatID, at); got != test.want {
+			t.Errorf("%s chat after %q shows %q, want %q", test.language, test.command, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+)
+
+var logLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// setupLogging makes a key=value slog logger at level the default. Calls
+// to the log package go through it too and are logged at info.
+func setupLogging(level string) {
+	handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevels[level]})
+	slog.SetDefault(slog.New(handler))
+}
+
+// fatal logs msg at error level and exits, for failures the bot can't
+// start without.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// chatLog returns a logger that tags every record with the chat.
+func chatLog(chatID int64) *slog.Logger {
+	return slog.With("chat_id", chatID)
+}
@@ -1,12 +1,15 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"os/signal"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -14,221 +17,1313 @@ import (
 )
 
 type Reminder struct {
-	Content string    `json:"content"`
-	Time    time.Time `json:"time"`
+	ID                 int             `json:"id"`
+	Title              string          `json:"title,omitempty"`
+	Content            string          `json:"content"`
+	Time               time.Time       `json:"time"`
+	CountdownMessageID int             `json:"countdown_message_id,omitempty"`
+	Recurring          bool            `json:"recurring,omitempty"`
+	Schedule           string          `json:"schedule,omitempty"`
+	URL                string          `json:"url,omitempty"`
+	SnoozeCount        int             `json:"snooze_count,omitempty"`
+	Pin                bool            `json:"pin,omitempty"`
+	SourceMessageID    int             `json:"source_message_id,omitempty"`
+	ExpiresAt          *time.Time      `json:"expires_at,omitempty"`
+	Priority           int             `json:"priority,omitempty"`
+	RemainingFires     int             `json:"remaining_fires,omitempty"`
+	Targets            []int64         `json:"targets,omitempty"`
+	Labels             []string        `json:"labels,omitempty"`
+	TodoID             int             `json:"todo_id,omitempty"`
+	Category           string          `json:"category,omitempty"`
+	DoneCount          int             `json:"done_count,omitempty"`
+	NagEvery           time.Duration   `json:"nag_every,omitempty"`
+	Nags               int             `json:"nags,omitempty"`
+	Before             []time.Duration `json:"before,omitempty"`
+	Channels           []string        `json:"channels,omitempty"`
+	MediaType          string          `json:"media_type,omitempty"`
+	MediaFileID        string          `json:"document_file_id,omitempty"`
+	DeliveryAttempts   int             `json:"delivery_attempts,omitempty"`
+	Mention            *Mention        `json:"mention,omitempty"`
 }
 
 type UserData struct {
-	Todos     []string   `json:"todos"`
-	Reminders []Reminder `json:"reminders"`
+	Todos          []Todo                    `json:"todos"`
+	Reminders      []Reminder                `json:"reminders"`
+	NextReminderID int                       `json:"next_reminder_id"`
+	NextTodoID     int                       `json:"next_todo_id,omitempty"`
+	Settings       Settings                  `json:"settings"`
+	ScheduledTodos []ScheduledTodo           `json:"scheduled_todos,omitempty"`
+	History        []FiredReminder           `json:"history,omitempty"`
+	Templates      map[string][]TemplateItem `json:"templates,omitempty"`
+	Anchors        map[string]int            `json:"anchors,omitempty"`
+	AuditLog       []AuditEntry              `json:"audit_log,omitempty"`
+	Deleted        []DeletedReminder         `json:"deleted,omitempty"`
+	DeliveryStats  DeliveryStats             `json:"delivery_stats"`
+	Aliases        map[string]string         `json:"aliases,omitempty"`
+	PhraseCounts   map[string]int            `json:"phrase_counts,omitempty"`
+	Delay          *DelayWindow              `json:"delay,omitempty"`
+	Lists          map[string][]Todo         `json:"lists,omitempty"`
+	Completed      []CompletedTodo           `json:"completed,omitempty"`
+	LastDigestAt   time.Time                 `json:"last_digest_at,omitempty"`
+
+	RemovedFromChat bool `json:"removed_from_chat,omitempty"`
 }
 
 var todoData = make(map[int64]*UserData)
-var reminderScheduler = cron.New()
+var store *CachedStore
+
+// dataMu guards todoData together with the timers and cron entries kept
+// next to it. It is taken once per update and once per timer or cron
+// callback, so handlers never lock it themselves. send and request
+// release it while talking to Telegram, and the few handlers that make
+// other slow network calls release it with unlocked.
+var dataMu sync.Mutex
+
+// locked wraps f so that it runs while holding dataMu, for use as a timer or
+// cron callback.
+func locked(f func()) func() {
+	return func() {
+		dataMu.Lock()
+		defer dataMu.Unlock()
+		f()
+	}
+}
+
+// unlocked runs f with dataMu released so other chats aren't held up by a
+// slow download or API call. Updates of the same chat are still handled in
+// order, but timers may run meanwhile, so the caller must look up any chat
+// state again afterwards.
+func unlocked(f func()) {
+	dataMu.Unlock()
+	defer dataMu.Lock()
+	f()
+}
+
+// Specs without a CRON_TZ prefix are interpreted in UTC, the default
+// timezone for users who haven't set one.
+var reminderScheduler = cron.New(cron.WithLocation(time.UTC), cron.WithParser(cronParser{}))
+
+type durationPart struct {
+	Value int
+	Unit  byte
+}
+
+// splitDuration breaks a duration such as "90m", "1h30m" or "2d12h" into
+// its value+unit pairs.
+func splitDuration(durationStr string) ([]durationPart, error) {
+	if durationStr == "" {
+		return nil, fmt.Errorf("empty duration")
+	}
+
+	var parts []durationPart
+	for rest := durationStr; rest != ""; {
+		digits := 0
+		for digits < len(rest) && isDigit(rest[digits]) {
+			digits++
+		}
+		if digits == 0 || digits == len(rest) {
+			return nil, fmt.Errorf("invalid duration %q", durationStr)
+		}
+		end := digits
+		for end < len(rest) && !isDigit(rest[end]) {
+			end++
+		}
+
+		value, err := strconv.Atoi(rest[:digits])
+		if err != nil {
+			return nil, err
+		}
+		unit := rest[digits:end]
+		if _, err := durationUnit(unit[0]); err != nil || len(unit) != 1 {
+			return nil, &UnknownUnitError{Unit: unit}
+		}
+
+		parts = append(parts, durationPart{Value: value, Unit: unit[0]})
+		rest = rest[end:]
+	}
+
+	return parts, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
 
+// UnknownUnitError is returned for durations like "5mm" or "2x" whose unit
+// isn't one of s, m, h, d, w, M or y.
+type UnknownUnitError struct {
+	Unit string
+}
+
+func (e *UnknownUnitError) Error() string {
+	return fmt.Sprintf("unknown time unit %q", e.Unit)
+}
+
+// parseDuration returns the total length of a duration expression. Months
+// and years count as 30 and 365 days; use addDuration when the result is a
+// point in time.
 func parseDuration(durationStr string) (time.Duration, error) {
-	unit := durationStr[len(durationStr)-1]
-	value, err := strconv.Atoi(durationStr[:len(durationStr)-1])
+	parts, err := splitDuration(durationStr)
 	if err != nil {
 		return 0, err
 	}
 
+	var total time.Duration
+	for _, part := range parts {
+		unit, _ := durationUnit(part.Unit)
+		total += time.Duration(part.Value) * unit
+	}
+
+	return total, nil
+}
+
+// addDuration adds a duration expression to t, moving months and years
+// along the calendar with AddDate so that "1M" from the 15th lands on the
+// 15th of next month.
+func addDuration(t time.Time, durationStr string) (time.Time, error) {
+	parts, err := splitDuration(durationStr)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var years, months int
+	var rest time.Duration
+	for _, part := range parts {
+		switch part.Unit {
+		case 'y':
+			years += part.Value
+		case 'M':
+			months += part.Value
+		default:
+			unit, _ := durationUnit(part.Unit)
+			rest += time.Duration(part.Value) * unit
+		}
+	}
+
+	return t.AddDate(years, months, 0).Add(rest), nil
+}
+
+func durationUnit(unit byte) (time.Duration, error) {
 	switch unit {
 	case 's': // seconds
-		return time.Duration(value) * time.Second, nil
+		return time.Second, nil
 	case 'm': // minutes
-		return time.Duration(value) * time.Minute, nil
+		return time.Minute, nil
 	case 'h': // hours
-		return time.Duration(value) * time.Hour, nil
+		return time.Hour, nil
 	case 'd': // days
-		return time.Duration(value) * 24 * time.Hour, nil
+		return 24 * time.Hour, nil
 	case 'w': // weeks
-		return time.Duration(value) * 7 * 24 * time.Hour, nil
+		return 7 * 24 * time.Hour, nil
 	case 'M': // months
-		return time.Duration(value) * 30 * 24 * time.Hour, nil
+		return 30 * 24 * time.Hour, nil
 	case 'y': // years
-		return time.Duration(value) * 365 * 24 * time.Hour, nil
+		return 365 * 24 * time.Hour, nil
 	default:
 		return 0, fmt.Errorf("invalid time unit")
 	}
 }
 
+var dateTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// parseDateTime parses an absolute date/time; values without an explicit
+// offset are taken to be in loc.
+func parseDateTime(dateTimeStr string, loc *time.Location) (time.Time, error) {
+	for _, layout := range dateTimeLayouts {
+		t, err := time.ParseInLocation(layout, dateTimeStr, loc)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date/time %q", dateTimeStr)
+}
+
+func pinReminderMessage(chatID int64, messageID int, bot BotClient) {
+	_, err := request(bot, tgbotapi.PinChatMessageConfig{
+		ChatID:    chatID,
+		MessageID: messageID,
+	})
+	if err != nil {
+		chatLog(chatID).Warn("Failed to pin reminder", "err", err)
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "reminder.pin_failed"))
+		send(bot, msg)
+	}
+}
+
 func loadUserData() error {
-	file, err := os.Open("userdata.json")
+	data, err := store.Load()
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	todoData = data
+
+	for _, userData := range todoData {
+		for i := range userData.Reminders {
+			if userData.Reminders[i].ID == 0 {
+				userData.NextReminderID++
+				userData.Reminders[i].ID = userData.NextReminderID
+			}
+		}
+		for i := range userData.Todos {
+			if userData.Todos[i].ID == 0 {
+				userData.NextTodoID++
+				userData.Todos[i].ID = userData.NextTodoID
+			}
+		}
+	}
 
-	return json.NewDecoder(file).Decode(&todoData)
+	return nil
 }
 
 func saveUserData() error {
-	file, err := os.Create("userdata.json")
-	if err != nil {
-		return err
+	return store.Save(todoData)
+}
+
+// setupReminders schedules all stored reminders and delivers the ones that
+// came due while the bot was down. Calling it again is harmless: reminders
+// are rescheduled rather than doubled, and missed ones were already removed.
+func setupReminders(bot BotClient) {
+	for chatID, userData := range todoData {
+		if !userData.RemovedFromChat {
+			setupChatReminders(chatID, userData, bot)
+		}
 	}
-	defer file.Close()
+}
 
-	return json.NewEncoder(file).Encode(todoData)
+func setupChatReminders(chatID int64, userData *UserData, bot BotClient) {
+	now := time.Now()
+	var missed, onStart []Reminder
+	for _, reminder := range userData.Reminders {
+		if isOnStart(reminder) {
+			onStart = append(onStart, reminder)
+		} else if reminder.Recurring || reminder.Time.After(now) {
+			scheduleReminder(chatID, reminder, bot)
+		} else {
+			missed = append(missed, reminder)
+		}
+	}
+	catchUpReminders(chatID, missed, bot)
+	for _, reminder := range onStart {
+		fireReminder(chatID, reminder, bot)
+	}
+	for _, scheduled := range userData.ScheduledTodos {
+		scheduleTodoReadd(chatID, scheduled, bot)
+	}
 }
 
-func setupReminders(bot *tgbotapi.BotAPI) {
-	for chatID, userData := range todoData {
-		for _, reminder := range userData.Reminders {
-			duration := reminder.Time.Sub(time.Now())
-			if duration > 0 {
-				time.AfterFunc(duration, func() {
-					msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Нагадування: %s", reminder.Content))
-					bot.Send(msg)
-				})
+func scheduleReminder(chatID int64, reminder Reminder, bot BotClient) {
+	key := reminderKey{ChatID: chatID, ReminderID: reminder.ID}
+	unscheduleReminder(chatID, reminder.ID)
+
+	if isOnStart(reminder) {
+		// Fired by setupReminders; there's nothing to schedule.
+		return
+	}
+	if reminder.Recurring {
+		entryID, err := reminderScheduler.AddFunc(reminder.Schedule, locked(func() {
+			fireReminder(chatID, reminder, bot)
+		}))
+		if err != nil {
+			chatLog(chatID).Error("Failed to schedule recurring reminder", "reminder_id", reminder.ID, "schedule", reminder.Schedule, "err", err)
+			return
+		}
+		reminderEntries[key] = entryID
+		remindersScheduled.Inc()
+		return
+	}
+
+	reminderTimers[key] = time.AfterFunc(time.Until(reminder.Time), locked(func() {
+		fireReminder(chatID, reminder, bot)
+	}))
+	remindersScheduled.Inc()
+	scheduleHeadsUps(chatID, reminder, bot)
+
+	if reminder.CountdownMessageID != 0 {
+		scheduleCountdownUpdate(chatID, reminder, bot)
+	}
+}
+
+func fireReminder(chatID int64, reminder Reminder, bot BotClient) {
+	remindersFired.Inc()
+	deliverReminder(chatID, reminder, "reminder.label", bot)
+}
+
+// reminderMessage renders the message a reminder of chatID is delivered as
+// to target. label is a message key such as "reminder.label".
+func reminderMessage(chatID int64, target int64, reminder Reminder, label string) tgbotapi.MessageConfig {
+	label = tr(chatID, label)
+	text := fmt.Sprintf("%s: %s", label, reminder.Content)
+	if todo, ok := linkedTodo(chatID, reminder); ok {
+		if todo.Text == reminder.Content {
+			text = fmt.Sprintf("%s: 📝 %s", label, reminder.Content)
+		} else {
+			text += "\n" + tr(chatID, "reminder.linked_todo", todo.Text)
+		}
+	}
+	msg := tgbotapi.NewMessage(target, text)
+	msg.Entities = mentionEntities(msg.Text)
+	if target == chatID {
+		appendMention(&msg, reminder.Mention)
+	}
+	if reminder.URL != "" {
+		if userData, exists := todoData[chatID]; exists {
+			msg.DisableWebPagePreview = userData.Settings.DisableLinkPreview
+		}
+	}
+
+	return msg
+}
+
+func deliverReminder(chatID int64, reminder Reminder, label string, bot BotClient) {
+	if reminder.ExpiresAt != nil && time.Now().After(*reminder.ExpiresAt) {
+		chatLog(chatID).Info("Reminder expired before delivery", "reminder_id", reminder.ID)
+		removeReminders(chatID, func(r Reminder) bool { return r.ID == reminder.ID })
+		if err := saveUserData(); err != nil {
+			slog.Error("Failed to save user data", "err", err)
+		}
+		return
+	}
+
+	if maintenance {
+		holdReminder(chatID, reminder, label)
+		return
+	}
+
+	if delivery, delayed := delayedDelivery(chatDelay(chatID), time.Now()); delayed {
+		deferReminder(chatID, reminder, delivery, bot)
+		return
+	}
+
+	if delivery, deferred := deferredDelivery(chatQuietHours(chatID), time.Now().In(chatLocation(chatID))); deferred {
+		deferReminder(chatID, reminder, delivery, bot)
+		return
+	}
+
+	if reminder.CountdownMessageID != 0 {
+		finishCountdown(chatID, reminder, bot)
+	}
+
+	var deliveryErr error
+	for _, target := range deliveryTargets(chatID, reminder) {
+		msg := reminderMessage(chatID, target, reminder, label)
+		if target == chatID {
+			keyboard := withDoneButton(chatID, snoozeKeyboard(reminder.ID, chatSnoozeOptions(chatID)), reminder)
+			if keyboard != nil {
+				msg.ReplyMarkup = keyboard
+			}
+		}
+		sent, err := sendReminderMessage(bot, reminder, msg)
+		recordDelivery(chatID, err)
+		if err != nil {
+			chatLog(chatID).Error("Failed to deliver reminder", "reminder_id", reminder.ID, "target", target, "err", err)
+			if target == chatID {
+				deliveryErr = err
 			}
+			continue
+		}
+		if reminder.Pin {
+			pinReminderMessage(target, sent.MessageID, bot)
 		}
 	}
+	if reminder.DeliveryAttempts == 0 && reminder.Nags == 0 {
+		notifyChannels(chatID, reminder, bot)
+	}
+
+	switch {
+	case deliveryErr != nil && retryDelivery(chatID, reminder, label, deliveryErr, bot):
+		// Kept pending until a retry succeeds or the retries run out.
+	case isNagging(reminder):
+		renag(chatID, reminder, time.Now(), bot)
+	default:
+		recordFiredReminder(chatID, reminder, time.Now())
+	}
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
 }
 
 func main() {
-	apiToken := os.Getenv("API_TOKEN")
-	if apiToken == "" {
-		log.Panic("API_TOKEN environment variable is not set")
+	cfg, err := loadConfig()
+	if err != nil {
+		fatal("Invalid configuration", "err", err)
 	}
+	setupLogging(cfg.LogLevel)
 
-	bot, err := tgbotapi.NewBotAPI(apiToken)
+	bot, err := tgbotapi.NewBotAPI(cfg.BotToken)
 	if err != nil {
-		log.Panicf("Failed to initialize bot: %v", err)
+		fatal("Failed to initialize bot", "err", err)
 	}
 
-	bot.Debug = true
-	log.Printf("Authorized on account %s", bot.Self.UserName)
+	bot.Debug = cfg.LogLevel == "debug"
+	slog.Info("Authorized", "account", bot.Self.UserName)
+	botUsername = bot.Self.UserName
+	registerCommands(bot)
 
+	defaultLanguage = cfg.DefaultLanguage
+	defaultLocation = cfg.DefaultLocation
+	userQuota = cfg.UserQuota
+	auditLogSize = cfg.AuditLogSize
+	if cfg.TranscriberURL != "" {
+		transcriber = NewHTTPTranscriber(cfg.TranscriberURL)
+	}
+	if cfg.SMTPAddr != "" {
+		notifiers["email"] = NewSMTPNotifier(cfg.SMTPAddr, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+	}
+	if cfg.NotifyWebhooks {
+		notifiers["webhook"] = NewWebhookNotifier()
+	}
+	adminIDs = cfg.AdminIDs
+	adminChatID = cfg.AdminChatID
+	maintenancePath = cfg.MaintenancePath
+	maintenance = loadMaintenance(maintenancePath)
+	if maintenance {
+		slog.Warn("Starting in maintenance mode")
+	}
+
+	backend, err := NewStore(cfg)
+	if err != nil {
+		fatal("Failed to open storage", "err", err)
+	}
+	store = NewCachedStore(backend, saveDelay)
+
+	dataMu.Lock()
 	err = loadUserData()
 	if err != nil {
-		log.Printf("Failed to load user data: %v", err)
+		slog.Error("Failed to load user data", "err", err)
 	}
 
 	setupReminders(bot)
+	setupDigests(bot)
+	dataMu.Unlock()
+
+	addSystemJob(inactivityCheckSpec, func() {
+		checkInactivity(time.Now(), bot)
+	})
+	addSystemJob(purgeDeletedSpec, func() {
+		purgeDeleted(time.Now())
+	})
+	addSystemJob(purgeCompletedSpec, func() {
+		purgeCompleted(time.Now())
+	})
+	reminderScheduler.Start()
 
-	u := tgbotapi.NewUpdate(0)
-	u.Timeout = 60
-	updates := bot.GetUpdatesChan(u)
+	stopMetrics := func() {}
+	if cfg.MetricsListen != "" {
+		stopMetrics, err = startMetricsServer(cfg.MetricsListen)
+		if err != nil {
+			fatal("Failed to start metrics server", "err", err)
+		}
+	}
 
-	for update := range updates {
-		if update.Message != nil {
-			handleMessage(update.Message, bot)
+	stopCalendar := func() {}
+	if cfg.CalendarURL != "" {
+		stopCalendar, err = startCalendarServer(cfg)
+		if err != nil {
+			fatal("Failed to start calendar server", "err", err)
 		}
+		calendarURL = cfg.CalendarURL
+	}
+
+	var updates tgbotapi.UpdatesChannel
+	var stopUpdates func()
+	if cfg.WebhookURL != "" {
+		updates, stopUpdates, err = startWebhook(bot, cfg)
+		if err != nil {
+			fatal("Failed to start webhook", "err", err)
+		}
+	} else {
+		updates, stopUpdates = startPolling(bot, cfg.PollTimeout)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		slog.Info("Shutting down", "signal", sig)
+		stopUpdates()
+		stopCalendar()
+		stopMetrics()
+	}()
+
+	workers := newChatWorkers(func(update tgbotapi.Update) {
+		done := watchUpdate(update)
+		start := time.Now()
+		dataMu.Lock()
+		handleUpdate(update, bot)
+		dataMu.Unlock()
+		done()
+		elapsed := time.Since(start)
+		updatesProcessed.WithLabelValues(updateKind(update)).Inc()
+		updateDuration.Observe(elapsed.Seconds())
+		chatLog(updateChatID(update)).Debug("Handled update", "update_id", update.UpdateID, "kind", updateKind(update), "took", elapsed)
+	})
+	for update := range updates {
+		workers.Dispatch(update)
+	}
+	workers.Wait()
+
+	shutdown()
+}
+
+// shutdown waits for running reminder jobs, then saves and flushes the user
+// data one last time.
+func shutdown() {
+	slog.Info("Stopping reminder scheduler")
+	<-reminderScheduler.Stop().Done()
+
+	dataMu.Lock()
+	defer dataMu.Unlock()
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+	if err := store.Close(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+	slog.Info("User data saved, bye")
+}
+
+func handleUpdate(update tgbotapi.Update, bot BotClient) {
+	if blockedByMaintenance(update, bot) {
+		return
+	}
+
+	if update.Message != nil {
+		handleMessage(update.Message, bot)
+	} else if update.EditedMessage != nil {
+		handleEditedMessage(update.EditedMessage, bot)
+	} else if update.CallbackQuery != nil {
+		handleCallback(update.CallbackQuery, bot)
+	} else if update.MyChatMember != nil {
+		handleMyChatMember(update.MyChatMember, bot)
+	} else if update.InlineQuery != nil {
+		handleInlineQuery(update.InlineQuery, bot)
+	} else if update.ChosenInlineResult != nil {
+		handleChosenInlineResult(update.ChosenInlineResult, bot)
 	}
 }
 
-func handleMessage(message *tgbotapi.Message, bot *tgbotapi.BotAPI) {
+func handleMessage(message *tgbotapi.Message, bot BotClient) {
 	chatID := message.Chat.ID
+	if userData, exists := todoData[chatID]; exists && len(userData.Aliases) > 0 {
+		if !expandAlias(userData.Aliases, message) {
+			msg := tgbotapi.NewMessage(chatID, tr(chatID, "alias.loop"))
+			send(bot, msg)
+			return
+		}
+	}
 	text := message.Text
 
-	if strings.HasPrefix(text, "/remind") {
-		parts := strings.SplitN(text, " ", 3)
+	key := conversationKey{ChatID: chatID, UserID: senderID(message)}
+	if conversation, exists := conversations[key]; exists {
+		if !message.IsCommand() {
+			handleConversation(key, conversation, text, bot)
+			return
+		}
+		delete(conversations, key)
+	}
+
+	if message.Document != nil {
+		switch captionCommand(message) {
+		case "importtodos":
+			handleImportTodos(chatID, message.Document, bot)
+			return
+		case "import":
+			handleImport(chatID, senderID(message), message.Document, bot)
+			return
+		}
+	}
+	if message.Voice != nil {
+		handleVoice(chatID, message, bot)
+		return
+	}
+	if !message.IsCommand() && isDoneReply(text) && handleDoneReply(chatID, bot) {
+		return
+	}
+
+	args := message.CommandArguments()
+	if message.IsCommand() {
+		countCommand(message.Command())
+	}
+	if _, known := findCommand(message.Command()); known && message.Command() != "log" && message.Command() != "mylog" {
+		recordAction(chatID, message.Command(), args, time.Now())
+	}
+
+	switch message.Command() {
+	case "remind":
+		mention, args := remindArgs(message, args)
+		args, before, err := cutBeforeFlag(args)
+		if err != nil {
+			send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "headsup.invalid", maxHeadsUps)))
+			break
+		}
+		args, channels, err := cutAlsoFlag(args)
+		if err != nil {
+			sendUsage(chatID, "remind", bot)
+			break
+		}
+		if !checkChannels(chatID, channels, bot) {
+			break
+		}
+		if strings.TrimSpace(args) == "" && before == nil {
+			startRemindFlow(key, mention, bot)
+			break
+		}
+		parts := strings.SplitN(args, " ", 2)
+		if len(parts) == 2 && (parts[0] == "every" || parts[0] == "cron") {
+			if before != nil || channels != nil {
+				send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "remind.one_shot_only")))
+				break
+			}
+			handleRemindRepeating(chatID, parts[0], parts[1], bot)
+			break
+		}
+
+		timeStr, content := cutReminderTime(args)
+		reminder := Reminder{Content: content, SourceMessageID: message.MessageID, Mention: mention, Before: before, Channels: channels}
+		attachReplyMedia(message, &reminder)
+		reminder.Content = reminderContent(chatID, reminder.Content)
+		if timeStr != "" && reminder.Content != "" {
+			handleReminder(chatID, senderID(message), timeStr, reminder, bot)
+		} else {
+			sendUsage(chatID, "remind", bot)
+		}
+	case "r":
+		if text := strings.TrimSpace(args); text != "" {
+			handleNaturalReminder(chatID, senderID(message), text, Reminder{SourceMessageID: message.MessageID, Mention: senderMention(message)}, bot)
+		} else {
+			sendUsage(chatID, "r", bot)
+		}
+	case "preview":
+		if timeStr, content := cutReminderTime(args); content != "" {
+			handlePreview(chatID, timeStr, content, bot)
+		} else {
+			sendUsage(chatID, "preview", bot)
+		}
+	case "remindat":
+		parts := strings.SplitN(args, " ", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[1]) != "" {
+			handleReminder(chatID, senderID(message), parts[0], Reminder{Content: strings.TrimSpace(parts[1]), SourceMessageID: message.MessageID, Mention: senderMention(message)}, bot)
+		} else {
+			sendUsage(chatID, "remindat", bot)
+		}
+	case "nag":
+		fields, content := cutFields(args, 2)
+		if len(fields) == 2 && content != "" {
+			handleNagReminder(chatID, senderID(message), fields[0], fields[1], Reminder{Content: content, SourceMessageID: message.MessageID, Mention: senderMention(message)}, bot)
+		} else {
+			sendUsage(chatID, "nag", bot)
+		}
+	case "remindpin":
+		if timeStr, content := cutReminderTime(args); content != "" {
+			handleReminder(chatID, senderID(message), timeStr, Reminder{Content: content, Pin: true, SourceMessageID: message.MessageID, Mention: senderMention(message)}, bot)
+		} else {
+			sendUsage(chatID, "remindpin", bot)
+		}
+	case "remindto":
+		parts := strings.SplitN(args, " ", 3)
+		if len(parts) == 3 && message.From != nil {
+			handleRemindTo(chatID, message.From.ID, parts[0], parts[1], Reminder{Content: parts[2], SourceMessageID: message.MessageID}, bot)
+		} else {
+			sendUsage(chatID, "remindto", bot)
+		}
+	case "remindexpire":
+		parts := strings.SplitN(args, " ", 3)
 		if len(parts) == 3 {
-			timeStr := parts[1]
-			content := parts[2]
-			handleReminder(chatID, timeStr, content, bot)
-		} else {
-			msg := tgbotapi.NewMessage(chatID, "Usage: /remind <time> <message>")
-			bot.Send(msg)
-		}
-	} else if strings.HasPrefix(text, "/todo") {
-		handleTodoList(chatID, bot)
-	} else if strings.HasPrefix(text, "/set") {
-		task := strings.TrimPrefix(text, "/set ")
-		handleSetTodo(chatID, task, bot)
-	} else if strings.HasPrefix(text, "/done") {
-		indexStr := strings.TrimPrefix(text, "/done ")
-		handleMarkDone(chatID, indexStr, bot)
-	} else {
-		msg := tgbotapi.NewMessage(chatID, "Невідома команда!")
-		bot.Send(msg)
+			handleRemindExpire(chatID, senderID(message), parts[0], parts[1], Reminder{Content: parts[2], SourceMessageID: message.MessageID}, bot)
+		} else {
+			sendUsage(chatID, "remindexpire", bot)
+		}
+	case "remindevery":
+		handleRemindEvery(chatID, args, bot)
+	case "countdown":
+		parts := strings.SplitN(args, " ", 2)
+		if len(parts) == 2 {
+			handleCountdown(chatID, parts[0], parts[1], bot)
+		} else {
+			sendUsage(chatID, "countdown", bot)
+		}
+	case "recurring":
+		if args == "" {
+			handleRecurringList(chatID, bot)
+		} else {
+			handleRecurringReminder(chatID, args, bot)
+		}
+	case "clonerecurring":
+		parts := strings.SplitN(args, " ", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[1]) != "" {
+			handleCloneRecurring(chatID, parts[0], strings.TrimSpace(parts[1]), bot)
+		} else {
+			sendUsage(chatID, "clonerecurring", bot)
+		}
+	case "clearrecurring":
+		handleClearRecurring(chatID, bot)
+	case "rrule":
+		handleRRuleReminder(chatID, args, bot)
+	case "list", "reminders":
+		if strings.TrimSpace(args) == "" || message.Command() == "reminders" {
+			handleReminderList(chatID, bot)
+		} else {
+			handleListCommand(chatID, args, bot)
+		}
+	case "lists":
+		handleListsOverview(chatID, bot)
+	case "listjson":
+		handleListJSON(chatID, bot)
+	case "listmd":
+		handleListMarkdown(chatID, bot)
+	case "recat":
+		parts := strings.Fields(args)
+		if len(parts) == 2 {
+			handleRecategorize(chatID, parts[0], parts[1], bot)
+		} else {
+			sendUsage(chatID, "recat", bot)
+		}
+	case "priority":
+		parts := strings.Fields(args)
+		if len(parts) == 2 {
+			handlePriority(chatID, parts[0], parts[1], bot)
+		} else {
+			sendUsage(chatID, "priority", bot)
+		}
+	case "undo":
+		handleUndo(chatID, bot)
+	case "dedupe":
+		handleDedupe(chatID, bot)
+	case "nextfires":
+		handleNextFires(chatID, strings.TrimSpace(args), bot)
+	case "cancel":
+		parts := strings.Fields(args)
+		if len(parts) == 1 {
+			handleCancel(chatID, parts[0], bot)
+		} else if len(parts) == 2 {
+			handleCancelHeadsUp(chatID, parts[0], parts[1], bot)
+		} else {
+			sendUsage(chatID, "cancel", bot)
+		}
+	case "editreminder":
+		parts := strings.SplitN(strings.TrimSpace(args), " ", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[1]) != "" {
+			handleEditReminder(chatID, parts[0], strings.TrimSpace(parts[1]), bot)
+		} else {
+			sendUsage(chatID, "editreminder", bot)
+		}
+	case "cancelbetween":
+		parts := strings.Fields(args)
+		if len(parts) == 2 {
+			handleCancelBetween(chatID, parts[0], parts[1], bot)
+		} else {
+			sendUsage(chatID, "cancelbetween", bot)
+		}
+	case "remindbulkcancel":
+		handleBulkCancel(chatID, bot)
+	case "cancellabel":
+		parts := strings.Fields(args)
+		if len(parts) == 1 {
+			handleCancelLabel(chatID, parts[0], bot)
+		} else {
+			sendUsage(chatID, "cancellabel", bot)
+		}
+	case "cancelmatch":
+		if glob := strings.TrimSpace(args); glob != "" {
+			handleCancelMatch(chatID, glob, bot)
+		} else {
+			sendUsage(chatID, "cancelmatch", bot)
+		}
+	case "cancelbefore":
+		parts := strings.Fields(args)
+		if len(parts) == 1 {
+			handleCancelBefore(chatID, parts[0], bot)
+		} else {
+			sendUsage(chatID, "cancelbefore", bot)
+		}
+	case "share":
+		parts := strings.Fields(args)
+		if len(parts) == 1 {
+			handleShare(chatID, parts[0], bot)
+		} else {
+			sendUsage(chatID, "share", bot)
+		}
+	case "start":
+		if payload := strings.TrimSpace(args); payload != "" && payload != "inline" {
+			handleSharedReminder(chatID, payload, bot)
+		} else {
+			handleHelp(chatID, bot)
+		}
+	case "help":
+		handleHelp(chatID, bot)
+	case "maintenance":
+		handleMaintenance(chatID, senderID(message), strings.TrimSpace(args), bot)
+	case "remindcleanup":
+		handleRemindCleanup(chatID, senderID(message), bot)
+	case "remindsync":
+		handleRemindSync(chatID, senderID(message), bot)
+	case "stats":
+		handleAdminStats(chatID, senderID(message), bot)
+	case "broadcast":
+		handleBroadcast(chatID, senderID(message), strings.TrimSpace(args), bot)
+	case "backup":
+		handleBackup(chatID, senderID(message), bot)
+	case "again":
+		parts := strings.Fields(args)
+		if len(parts) == 2 {
+			handleAgain(chatID, senderID(message), parts[0], parts[1], bot)
+		} else {
+			sendUsage(chatID, "again", bot)
+		}
+	case "snooze":
+		parts := strings.Fields(args)
+		if len(parts) == 2 {
+			handleSnooze(chatID, parts[0], parts[1], bot)
+		} else {
+			sendUsage(chatID, "snooze", bot)
+		}
+	case "snoozeuntil":
+		parts := strings.Fields(args)
+		if len(parts) == 2 {
+			handleSnoozeUntil(chatID, parts[0], parts[1], bot)
+		} else {
+			sendUsage(chatID, "snoozeuntil", bot)
+		}
+	case "snoozebuttons":
+		handleSnoozeButtonsSetting(chatID, args, bot)
+	case "linkpreview":
+		handleLinkPreviewSetting(chatID, strings.TrimSpace(args), bot)
+	case "defaultcontent":
+		handleDefaultContentSetting(chatID, strings.TrimSpace(args), bot)
+	case "autoremind":
+		handleAutoRemindSetting(chatID, strings.TrimSpace(args), bot)
+	case "confirmafter":
+		handleConfirmAfterSetting(chatID, strings.TrimSpace(args), bot)
+	case "language":
+		handleLanguageSetting(chatID, strings.TrimSpace(args), bot)
+	case "quietdone":
+		handleQuietDoneSetting(chatID, strings.TrimSpace(args), bot)
+	case "log":
+		handleAuditLog(chatID, bot)
+	case "mylog":
+		if strings.TrimSpace(args) == "export" {
+			handleLogExport(chatID, bot)
+		} else {
+			sendUsage(chatID, "mylog", bot)
+		}
+	case "deliverystats":
+		handleDeliveryStats(chatID, bot)
+	case "notify":
+		handleNotify(chatID, args, bot)
+	case "search":
+		handleSearch(chatID, strings.TrimSpace(args), bot)
+	case "history":
+		handleHistory(chatID, args, bot)
+	case "undone":
+		handleUndone(chatID, args, bot)
+	case "clearhistory":
+		handleClearHistory(chatID, bot)
+	case "alias":
+		handleAlias(chatID, args, bot)
+	case "bulkremind":
+		handleBulkRemind(chatID, args, bot)
+	case "savetemplate":
+		handleSaveTemplate(chatID, args, bot)
+	case "applytemplate":
+		handleApplyTemplate(chatID, strings.TrimSpace(args), bot)
+	case "templates":
+		handleTemplateList(chatID, bot)
+	case "tz", "timezone":
+		handleTimezoneSetting(chatID, strings.TrimSpace(args), bot)
+	case "clock":
+		handleClockSetting(chatID, strings.TrimSpace(args), bot)
+	case "weekstart":
+		handleWeekStartSetting(chatID, strings.TrimSpace(args), bot)
+	case "digest":
+		handleDigestSetting(chatID, strings.TrimSpace(args), bot)
+	case "agenda":
+		handleAgenda(chatID, bot)
+	case "frequent":
+		handleFrequent(chatID, bot)
+	case "pattern":
+		handlePattern(chatID, strings.TrimSpace(args), bot)
+	case "summary":
+		handleSummary(chatID, bot)
+	case "anchor":
+		handleAnchor(chatID, args, bot)
+	case "businesshours":
+		handleBusinessHoursSetting(chatID, strings.TrimSpace(args), bot)
+	case "delay":
+		handleDelay(chatID, strings.TrimSpace(args), bot)
+	case "quiet":
+		handleQuietHoursSetting(chatID, strings.TrimSpace(args), bot)
+	case "deferred":
+		handleDeferredList(chatID, bot)
+	case "quiettest":
+		parts := strings.Fields(args)
+		if len(parts) == 1 {
+			handleQuietTest(chatID, parts[0], bot)
+		} else {
+			sendUsage(chatID, "quiettest", bot)
+		}
+	case "importtodos":
+		handleImportTodos(chatID, message.Document, bot)
+	case "calendar":
+		handleCalendar(chatID, strings.TrimSpace(args), bot)
+	case "export":
+		handleExport(chatID, bot)
+	case "import":
+		handleImport(chatID, senderID(message), message.Document, bot)
+	case "todo":
+		switch filter := strings.TrimSpace(args); filter {
+		case "":
+			handleTodoList(chatID, bot)
+		case "overdue", "today":
+			handleTodoFilter(chatID, filter, bot)
+		default:
+			if name, rest, ok := cutListName(chatID, filter); ok && rest == "" {
+				handleNamedTodoList(chatID, name, bot)
+			} else {
+				sendUsage(chatID, "todo", bot)
+			}
+		}
+	case "set":
+		if name, text, ok := cutListName(chatID, strings.TrimSpace(args)); ok && text != "" {
+			handleSetListTodo(chatID, name, Todo{Text: text}, bot)
+		} else if text := strings.TrimSpace(args); text != "" {
+			handleSetTodo(chatID, Todo{Text: text, SourceMessageID: message.MessageID}, bot)
+		} else {
+			sendUsage(chatID, "set", bot)
+		}
+	case "setmy":
+		if text := strings.TrimSpace(args); text != "" {
+			handleSetTodo(chatID, Todo{Text: text, SourceMessageID: message.MessageID, Owner: senderMention(message)}, bot)
+		} else {
+			sendUsage(chatID, "setmy", bot)
+		}
+	case "mytodos":
+		if message.From != nil {
+			handleMyTodos(chatID, message.From.ID, bot)
+		}
+	case "done":
+		if name, indexes, ok := cutListName(chatID, strings.TrimSpace(args)); ok && indexes != "" {
+			handleMarkListDone(chatID, name, indexes, bot)
+		} else if strings.TrimSpace(args) != "" {
+			handleMarkDone(chatID, args, bot)
+		} else {
+			sendUsage(chatID, "done", bot)
+		}
+	case "edit":
+		parts := strings.SplitN(strings.TrimSpace(args), " ", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[1]) != "" {
+			handleEditTodo(chatID, parts[0], strings.TrimSpace(parts[1]), bot)
+		} else {
+			sendUsage(chatID, "edit", bot)
+		}
+	case "move":
+		parts := strings.Fields(args)
+		if len(parts) == 2 {
+			handleMoveTodo(chatID, parts[0], parts[1], bot)
+		} else {
+			sendUsage(chatID, "move", bot)
+		}
+	case "top", "bottom":
+		parts := strings.Fields(args)
+		if len(parts) == 1 {
+			handleMoveTodo(chatID, parts[0], message.Command(), bot)
+		} else {
+			sendUsage(chatID, message.Command(), bot)
+		}
+	case "progress":
+		parts := strings.Fields(args)
+		if len(parts) == 2 {
+			handleProgress(chatID, parts[0], parts[1], bot)
+		} else {
+			sendUsage(chatID, "progress", bot)
+		}
+	case "due":
+		parts := strings.Fields(args)
+		if len(parts) == 2 {
+			handleDueTodo(chatID, parts[0], parts[1], bot)
+		} else {
+			sendUsage(chatID, "due", bot)
+		}
+	case "remindtodo":
+		fields, timeStr := cutFields(args, 1)
+		if len(fields) == 1 && timeStr != "" {
+			handleRemindTodo(chatID, fields[0], timeStr, bot)
+		} else {
+			sendUsage(chatID, "remindtodo", bot)
+		}
+	case "link":
+		parts := strings.Fields(args)
+		if len(parts) == 2 {
+			handleLink(chatID, parts[0], parts[1], bot)
+		} else {
+			sendUsage(chatID, "link", bot)
+		}
+	case "arm":
+		handleArm(chatID, bot)
+	case "nudge":
+		parts := strings.Fields(args)
+		if len(parts) == 2 {
+			handleNudgeTodo(chatID, parts[0], parts[1], bot)
+		} else {
+			sendUsage(chatID, "nudge", bot)
+		}
+	case "repeat":
+		parts := strings.Fields(args)
+		if len(parts) == 2 {
+			handleRepeatTodo(chatID, parts[0], parts[1], bot)
+		} else {
+			sendUsage(chatID, "repeat", bot)
+		}
+	default:
+		handleUnknownCommand(chatID, message.Command(), bot)
 	}
 }
 
-func handleReminder(chatID int64, timeStr string, content string, bot *tgbotapi.BotAPI) {
-	duration, err := parseDuration(timeStr)
+func handleReminder(chatID int64, userID int64, timeStr string, reminder Reminder, bot BotClient) {
+	now := time.Now()
+	reminderTime, err := parseReminderTime(chatID, timeStr, now)
 	if err != nil {
-		msg := tgbotapi.NewMessage(chatID, "Неправильний формат часу!")
-		bot.Send(msg)
+		text := tr(chatID, "error.time_format")
+		var unitErr *UnknownUnitError
+		if errors.As(err, &unitErr) {
+			text = tr(chatID, "error.unknown_unit", unitErr.Unit)
+		}
+		send(bot, tgbotapi.NewMessage(chatID, text))
+		return
+	}
+	if !reminderTime.After(now) {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.time_passed"))
+		send(bot, msg)
 		return
 	}
 
-	reminderTime := time.Now().Add(duration)
+	reminder.Time = reminderTime
+	duration := reminderTime.Sub(now)
 
-	if _, exists := todoData[chatID]; !exists {
-		todoData[chatID] = &UserData{Todos: []string{}, Reminders: []Reminder{}}
+	if !checkReminderLimit(chatID, bot) || !checkQuota(chatID, len(reminder.Content), bot) {
+		return
 	}
-	todoData[chatID].Reminders = append(todoData[chatID].Reminders, Reminder{
-		Content: content,
-		Time:    reminderTime,
-	})
 
-	time.AfterFunc(duration, func() {
-		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Нагадування: %s", content))
-		bot.Send(msg)
-	})
+	if threshold := confirmThreshold(chatID); threshold > 0 && duration > threshold {
+		requestReminderConfirmation(chatID, userID, timeStr, reminder, bot)
+		return
+	}
+
+	createReminder(chatID, timeStr, reminder, bot)
+}
+
+func createReminder(chatID int64, timeStr string, reminder Reminder, bot BotClient) {
+	content := reminder.Content
+	reminder, err := addReminder(chatID, reminder)
+	if err != nil {
+		sendReminderLimit(chatID, bot)
+		return
+	}
+	countPhrase(todoData[chatID], content)
+	scheduleReminder(chatID, reminder, bot)
 
-	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Ви встановили нагадування на %s від зараз!", timeStr))
-	bot.Send(msg)
+	text := tr(chatID, "reminder.set_in", timeStr)
+	if _, err := parseDuration(timeStr); err != nil {
+		text = tr(chatID, "reminder.set_at", formatTime(chatID, reminder.Time))
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
 
 	if err := saveUserData(); err != nil {
-		log.Printf("Failed to save user data: %v", err)
+		slog.Error("Failed to save user data", "err", err)
 	}
 }
 
-func handleTodoList(chatID int64, bot *tgbotapi.BotAPI) {
+func handleTodoList(chatID int64, bot BotClient) {
 	userData, exists := todoData[chatID]
 	if !exists || len(userData.Todos) == 0 {
-		msg := tgbotapi.NewMessage(chatID, "Ваш список справ порожній.")
-		bot.Send(msg)
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "todos.empty"))
+		send(bot, msg)
 		return
 	}
 
+	text, keyboard := todoPage(chatID, userData, 0)
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = keyboard
+	send(bot, msg)
+}
+
+// Ten todos with their buttons fit comfortably on a phone screen.
+const todoPageSize = 10
+
+// todoPage renders one page of the todo list. The keyboard has done and
+// edit buttons for the todos shown and arrows to the neighbouring pages;
+// out of range pages are clamped, so a page that just lost its last todo
+// shows the one before.
+func todoPage(chatID int64, userData *UserData, page int) (string, tgbotapi.InlineKeyboardMarkup) {
+	indexes := sortedTodoIndexes(userData.Todos, nil)
+	pages := (len(indexes) + todoPageSize - 1) / todoPageSize
+	page = max(0, min(page, pages-1))
+	shown := indexes[page*todoPageSize : min((page+1)*todoPageSize, len(indexes))]
+
+	text := tr(chatID, "todos.title")
+	if pages > 1 {
+		text = tr(chatID, "todos.title_page", page+1, pages)
+	}
+	text += "\n" + renderTodos(chatID, userData.Todos, shown)
+
+	var done, edit []tgbotapi.InlineKeyboardButton
+	for _, i := range shown {
+		done = append(done, tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf("✅ %d", i+1), fmt.Sprintf("done:%d:%d", userData.Todos[i].ID, page)))
+		edit = append(edit, tgbotapi.NewInlineKeyboardButtonData(
+			fmt.Sprintf("✏️ %d", i+1), fmt.Sprintf("edit:%d:%d", userData.Todos[i].ID, page)))
+	}
+	rows := append(buttonRows(done), buttonRows(edit)...)
+
+	if pages > 1 {
+		var nav []tgbotapi.InlineKeyboardButton
+		if page > 0 {
+			nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("⬅️", fmt.Sprintf("page:%d", page-1)))
+		}
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d/%d", page+1, pages), fmt.Sprintf("page:%d", page)))
+		if page < pages-1 {
+			nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("➡️", fmt.Sprintf("page:%d", page+1)))
+		}
+		rows = append(rows, nav)
+	}
+
+	return text, tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// renderTodos lists the todos at the given positions, numbered by their
+// position so /done and friends keep working on a sorted or filtered list.
+func renderTodos(chatID int64, todos []Todo, indexes []int) string {
 	var todoList string
-	for i, task := range userData.Todos {
-		todoList += fmt.Sprintf("%d. %s\n", i+1, task)
+	now := time.Now()
+	for _, i := range indexes {
+		task := todos[i]
+		todoList += fmt.Sprintf("%d. %s%s (%s)", i+1, priorityMarks[task.Priority], task.Text, formatAge(chatID, task.CreatedAt, now))
+		if task.Due != nil {
+			if task.Due.Before(now) {
+				todoList += " " + tr(chatID, "todos.overdue_since", formatTime(chatID, *task.Due))
+			} else {
+				todoList += " " + tr(chatID, "todos.due_by", formatTime(chatID, *task.Due))
+			}
+		}
+		if task.Owner != nil {
+			todoList += " 👤" + task.Owner.String()
+		}
+		if len(task.ReminderIDs) > 0 {
+			todoList += fmt.Sprintf(" 🔔%d", len(task.ReminderIDs))
+		}
+		if task.Progress > 0 {
+			todoList += " " + progressBar(task.Progress)
+		}
+		todoList += "\n"
 	}
 
-	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Список задач: \n%s", todoList))
-	bot.Send(msg)
+	return todoList
 }
 
-func handleSetTodo(chatID int64, task string, bot *tgbotapi.BotAPI) {
-	if _, exists := todoData[chatID]; !exists {
-		todoData[chatID] = &UserData{Todos: []string{}, Reminders: []Reminder{}}
+// buttonRows lays buttons out five to a row.
+func buttonRows(buttons []tgbotapi.InlineKeyboardButton) [][]tgbotapi.InlineKeyboardButton {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for len(buttons) > 5 {
+		rows = append(rows, buttons[:5])
+		buttons = buttons[5:]
+	}
+	if len(buttons) > 0 {
+		rows = append(rows, buttons)
 	}
-	todoData[chatID].Todos = append(todoData[chatID].Todos, task)
 
-	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("Задачу '%s' додано!", task))
-	bot.Send(msg)
+	return rows
+}
+
+func handleSetTodo(chatID int64, todo Todo, bot BotClient) {
+	if !checkTodoLimit(chatID, bot) || !checkQuota(chatID, len(todo.Text), bot) {
+		return
+	}
+
+	todo.CreatedAt = time.Now()
+	todo = parseTodoAttributes(todo, todo.CreatedAt.In(chatLocation(chatID)))
+	todo, err := addTodo(chatID, todo)
+	if err != nil {
+		sendTodoLimit(chatID, bot)
+		return
+	}
+
+	text := tr(chatID, "todo.added", todo.Text)
+	if delay := todoData[chatID].Settings.AutoRemind; delay > 0 {
+		if reminder, err := remindTodoAt(chatID, todo, todo.CreatedAt.Add(delay), bot); err == nil {
+			text += tr(chatID, "todo.auto_remind", formatTime(chatID, reminder.Time))
+		}
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
 
 	if err := saveUserData(); err != nil {
-		log.Printf("Failed to save user data: %v", err)
+		slog.Error("Failed to save user data", "err", err)
 	}
 }
 
-func handleMarkDone(chatID int64, indexStr string, bot *tgbotapi.BotAPI) {
+func handleMarkDone(chatID int64, indexStr string, bot BotClient) {
 	userData, exists := todoData[chatID]
 	if !exists || len(userData.Todos) == 0 {
-		msg := tgbotapi.NewMessage(chatID, "Ваш список справ порожній.")
-		bot.Send(msg)
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "todos.empty"))
+		send(bot, msg)
 		return
 	}
 
-	index, err := strconv.Atoi(indexStr)
-	if err != nil || index < 1 || index > len(userData.Todos) {
-		msg := tgbotapi.NewMessage(chatID, "Invalid index.")
-		bot.Send(msg)
+	indexes := make(map[int]bool)
+	for _, field := range strings.Fields(indexStr) {
+		index, err := strconv.Atoi(field)
+		if err != nil || index < 1 || index > len(userData.Todos) {
+			msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+			send(bot, msg)
+			return
+		}
+		indexes[index] = true
+	}
+	if len(indexes) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
 		return
 	}
 
-	userData.Todos = append(userData.Todos[:index-1], userData.Todos[index:]...)
-	msg := tgbotapi.NewMessage(chatID, "Виконано!")
-	bot.Send(msg)
+	kept := userData.Todos[:0]
+	now := time.Now()
+	var linked []Reminder
+	for i, todo := range userData.Todos {
+		if !indexes[i+1] {
+			kept = append(kept, todo)
+			continue
+		}
+		linked = append(linked, linkedReminders(chatID, todo)...)
+		archiveTodo(userData, todo, "", now)
+
+		if todo.RepeatEvery != nil {
+			todo.LastDoneAt = now
+			todo.Progress = 0
+			scheduled := ScheduledTodo{Todo: todo, ReaddAt: now.Add(*todo.RepeatEvery)}
+			userData.ScheduledTodos = append(userData.ScheduledTodos, scheduled)
+			scheduleTodoReadd(chatID, scheduled, bot)
+		}
+	}
+	userData.Todos = kept
+
+	if !userData.Settings.QuietDone {
+		text := tr(chatID, "todo.done")
+		if len(indexes) > 1 {
+			text = tr(chatID, "todo.done_many", len(indexes))
+		}
+		send(bot, tgbotapi.NewMessage(chatID, text))
+	}
+	if len(linked) > 0 {
+		cancelLinkedReminders(chatID, linked, bot)
+	}
 
 	if err := saveUserData(); err != nil {
-		log.Printf("Failed to save user data: %v", err)
+		slog.Error("Failed to save user data", "err", err)
 	}
 }
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+func TestTodoFlow(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 1001
+
+	dispatch(command(chatID, "/set buy milk"), bot)
+	dispatch(command(chatID, "/set call the bank"), bot)
+	if got := len(todoData[chatID].Todos); got != 2 {
+		t.Fatalf("after /set: %d todos, want 2", got)
+	}
+
+	dispatch(command(chatID, "/done 1"), bot)
+	userData := todoData[chatID]
+	if len(userData.Todos) != 1 || userData.Todos[0].Text != "call the bank" {
+		t.Errorf("after /done 1: todos %+v", userData.Todos)
+	}
+	if len(userData.Completed) != 1 || userData.Completed[0].Todo.Text != "buy milk" {
+		t.Errorf("after /done 1: completed %+v", userData.Completed)
+	}
+
+	dispatch(command(chatID, "/todo"), bot)
+	sent := fake.sent(chatID)
+	if len(sent) == 0 || !strings.Contains(sent[len(sent)-1], "call the bank") {
+		t.Errorf("/todo answered %q", sent)
+	}
+}
+
+func TestRemindFlow(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	backend := useTestData(t)
+	const chatID = 1002
+
+	before := time.Now()
+	dispatch(command(chatID, "/remind 1h call mom"), bot)
+
+	reminders := pendingReminders(chatID)
+	if len(reminders) != 1 {
+		t.Fatalf("after /remind: %d pending reminders, want 1", len(reminders))
+	}
+	reminder := reminders[0]
+	if reminder.Content != "call mom" {
+		t.Errorf("content %q, want %q", reminder.Content, "call mom")
+	}
+	if at := reminder.Time.Sub(before); at < time.Hour || at > time.Hour+time.Minute {
+		t.Errorf("reminder set %v ahead, want an hour", at)
+	}
+	if _, ok := reminderTimers[reminderKey{ChatID: chatID, ReminderID: reminder.ID}]; !ok {
+		t.Error("reminder has no timer")
+	}
+	if len(fake.sent(chatID)) == 0 {
+		t.Error("/remind sent no confirmation")
+	}
+
+	// What the handlers saved reads back the same after a restart.
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	saved, err := backend.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	assertSameData(t, saved, todoData)
+}
+
+// In a group, the /remind wizard only takes answers from whoever started
+// it.
+func TestRemindFlowInGroup(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const groupID, alice, bob = -3001, 11, 12
+
+	dispatch(groupMessage(groupID, alice, "/remind"), bot)
+	dispatch(groupMessage(groupID, bob, "lunch anyone?"), bot)
+	dispatch(groupMessage(groupID, alice, "water the plants"), bot)
+	dispatch(groupMessage(groupID, bob, "no thanks"), bot)
+	dispatch(groupMessage(groupID, alice, "2h"), bot)
+
+	reminders := pendingReminders(groupID)
+	if len(reminders) != 1 || reminders[0].Content != "water the plants" {
+		t.Errorf("pending reminders %+v, want one for alice's text", reminders)
+	}
+	if _, exists := conversations[conversationKey{ChatID: groupID, UserID: alice}]; exists {
+		t.Error("alice's flow still open after it finished")
+	}
+}
+
+// Months and years follow the calendar the way AddDate does; shorter units
+// are fixed durations.
+func TestAddDuration(t *testing.T) {
+	date := func(year int, month time.Month, day, hour int) time.Time {
+		return time.Date(year, month, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		from     time.Time
+		duration string
+		want     time.Time
+	}{
+		{date(2024, time.March, 15, 9), "1M", date(2024, time.April, 15, 9)},
+		{date(2024, time.January, 31, 9), "1M", date(2024, time.March, 2, 9)},
+		{date(2023, time.January, 31, 9), "1M", date(2023, time.March, 3, 9)},
+		{date(2024, time.February, 29, 9), "1y", date(2025, time.March, 1, 9)},
+		{date(2023, time.March, 1, 9), "1y", date(2024, time.March, 1, 9)},
+		{date(2024, time.November, 30, 9), "2M", date(2025, time.January, 30, 9)},
+		{date(2024, time.January, 31, 9), "1y1M", date(2025, time.March, 3, 9)},
+		{date(2024, time.March, 15, 9), "1M2d3h", date(2024, time.April, 17, 12)},
+		{date(2024, time.March, 15, 9), "90m", date(2024, time.March, 15, 10).Add(30 * time.Minute)},
+	}
+	for _, test := range tests {
+		got, err := addDuration(test.from, test.duration)
+		if err != nil {
+			t.Errorf("addDuration(%v, %q): %v", test.from, test.duration, err)
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("addDuration(%v, %q) = %v, want %v", test.from, test.duration, got, test.want)
+		}
+	}
+
+	for _, duration := range []string{"", "M", "1q", "1.5M"} {
+		if got, err := addDuration(date(2024, time.March, 15, 9), duration); err == nil {
+			t.Errorf("addDuration(%q) = %v, want an error", duration, got)
+		}
+	}
+}
+
+// setupReminders schedules every saved reminder with its own chat and
+// content. Each timer must fire for the reminder it was made for, not the
+// last one the loops saw.
+func TestSetupRemindersFiresEachReminder(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+
+	due := time.Now().Add(100 * time.Millisecond)
+	want := map[int64][]string{
+		7001: {"water the plants", "feed the cat"},
+		7002: {"call mom"},
+		7003: {"stretch", "drink water", "go to bed"},
+	}
+	for chatID, contents := range want {
+		userData := &UserData{Todos: []Todo{}}
+		for i, content := range contents {
+			userData.Reminders = append(userData.Reminders, Reminder{ID: i + 1, Content: content, Time: due.Add(time.Duration(i) * 10 * time.Millisecond)})
+		}
+		userData.NextReminderID = len(contents)
+		todoData[chatID] = userData
+	}
+
+	dataMu.Lock()
+	setupReminders(bot)
+	dataMu.Unlock()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for chatID, contents := range want {
+		for _, content := range contents {
+			for !sentContaining(fake.sent(chatID), content) {
+				if time.Now().After(deadline) {
+					t.Fatalf("chat %d never got %q; got %q", chatID, content, fake.sent(chatID))
+				}
+				time.Sleep(20 * time.Millisecond)
+			}
+		}
+		if got := len(fake.sent(chatID)); got != len(contents) {
+			t.Errorf("chat %d got %d messages, want %d: %q", chatID, got, len(contents), fake.sent(chatID))
+		}
+	}
+
+	// Let the last deliveries finish saving before the test data goes away.
+	for !allFired(want) {
+		if time.Now().After(deadline) {
+			t.Fatal("reminders still pending after they were sent")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func allFired(chats map[int64][]string) bool {
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	for chatID := range chats {
+		if len(todoData[chatID].Reminders) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sentContaining(texts []string, content string) bool {
+	for _, text := range texts {
+		if strings.Contains(text, content) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Updates of several chats and the timers of their reminders run at once,
+// each taking dataMu and send releasing it. Run with -race to catch chat
+// state touched outside the lock.
+func TestConcurrentUpdatesAndTimers(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+
+	chats := make(map[int64][]string)
+	updates := make(map[int64][]tgbotapi.Update)
+	for chatID := int64(7201); chatID <= 7208; chatID++ {
+		chats[chatID] = []string{"stretch"}
+		for _, text := range []string{"/remind 1s stretch", "/set water the plants", "/todo", "/reminders", "/set feed the cat"} {
+			updates[chatID] = append(updates[chatID], command(chatID, text))
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, chatUpdates := range updates {
+		wg.Add(1)
+		go func(chatUpdates []tgbotapi.Update) {
+			defer wg.Done()
+			for _, update := range chatUpdates {
+				dispatch(update, bot)
+			}
+		}(chatUpdates)
+	}
+	wg.Wait()
+
+	for deadline := time.Now().Add(10 * time.Second); !allFired(chats); time.Sleep(20 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("reminders still pending")
+		}
+	}
+
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	for chatID := range chats {
+		if got := len(todoData[chatID].Todos); got != 2 {
+			t.Errorf("chat %d has %d todos, want 2", chatID, got)
+		}
+	}
+}
+
+// A fired reminder leaves no heads-up timers behind.
+func TestFiredReminderDropsHeadsUps(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 7101
+
+	reminder := Reminder{ID: 1, Content: "dentist", Time: time.Now().Add(150 * time.Millisecond), Before: []time.Duration{100 * time.Millisecond}}
+	todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{reminder}, NextReminderID: 1}
+	dataMu.Lock()
+	scheduleReminder(chatID, reminder, bot)
+	dataMu.Unlock()
+
+	chats := map[int64][]string{chatID: {reminder.Content}}
+	for deadline := time.Now().Add(5 * time.Second); !allFired(chats); time.Sleep(20 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("reminder never fired; sent %q", fake.sent(chatID))
+		}
+	}
+
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	if timers, exists := headsUpTimers[reminderKey{ChatID: chatID, ReminderID: reminder.ID}]; exists {
+		t.Errorf("%d heads-up timers left after the reminder fired", len(timers))
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// While maintenance is on, only admins can use the bot and reminders that
+// come due are held until it is switched off. The flag is persisted as the
+// presence of the file at maintenancePath.
+var (
+	maintenance     bool
+	maintenancePath string
+	adminIDs        = make(map[int64]bool)
+	heldReminders   []HeldReminder
+)
+
+type HeldReminder struct {
+	ChatID   int64
+	Reminder Reminder
+	Label    string
+}
+
+func parseAdminIDs(s string) (map[int64]bool, error) {
+	ids := make(map[int64]bool)
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid admin id %q", field)
+		}
+		ids[id] = true
+	}
+
+	return ids, nil
+}
+
+// senderID returns the user who sent the message, or 0 for channel posts.
+func senderID(message *tgbotapi.Message) int64 {
+	if message.From == nil {
+		return 0
+	}
+
+	return message.From.ID
+}
+
+func isAdmin(userID int64) bool {
+	return adminIDs[userID]
+}
+
+func loadMaintenance(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func setMaintenance(on bool) error {
+	if on {
+		if err := os.WriteFile(maintenancePath, nil, 0o644); err != nil {
+			return err
+		}
+	} else if err := os.Remove(maintenancePath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	maintenance = on
+	return nil
+}
+
+// holdReminder queues a due reminder for delivery once maintenance ends.
+// A recurring reminder that fires several times meanwhile is held once.
+func holdReminder(chatID int64, reminder Reminder, label string) {
+	for _, held := range heldReminders {
+		if held.ChatID == chatID && held.Reminder.ID == reminder.ID {
+			return
+		}
+	}
+
+	heldReminders = append(heldReminders, HeldReminder{ChatID: chatID, Reminder: reminder, Label: label})
+}
+
+// releaseHeldReminders delivers everything held during maintenance that
+// hasn't been cancelled in the meantime.
+func releaseHeldReminders(bot BotClient) {
+	held := heldReminders
+	heldReminders = nil
+
+	for _, h := range held {
+		reminder, exists := findReminder(h.ChatID, h.Reminder.ID)
+		if !exists {
+			continue
+		}
+		deliverReminder(h.ChatID, reminder, h.Label, bot)
+	}
+}
+
+// blockedByMaintenance answers updates from non-admins with the maintenance
+// notice and reports whether the update should be dropped.
+func blockedByMaintenance(update tgbotapi.Update, bot BotClient) bool {
+	if !maintenance {
+		return false
+	}
+
+	switch {
+	case update.Message != nil:
+		if isAdmin(senderID(update.Message)) || (adminChatID != 0 && update.Message.Chat.ID == adminChatID) {
+			return false
+		}
+		send(bot, tgbotapi.NewMessage(update.Message.Chat.ID, tr(update.Message.Chat.ID, "maintenance.notice")))
+	case update.CallbackQuery != nil:
+		if isAdmin(update.CallbackQuery.From.ID) {
+			return false
+		}
+		// Inline keyboards have no message; chat 0 gets the default language.
+		var chatID int64
+		if update.CallbackQuery.Message != nil {
+			chatID = update.CallbackQuery.Message.Chat.ID
+		}
+		request(bot, tgbotapi.NewCallback(update.CallbackQuery.ID, tr(chatID, "maintenance.notice")))
+	}
+
+	return true
+}
+
+func handleMaintenance(chatID int64, userID int64, value string, bot BotClient) {
+	if !checkAdmin(chatID, userID, bot) {
+		return
+	}
+	if value != "on" && value != "off" {
+		sendUsage(chatID, "maintenance", bot)
+		return
+	}
+
+	if err := setMaintenance(value == "on"); err != nil {
+		slog.Error("Failed to switch maintenance mode", "err", err)
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "maintenance.failed"))
+		send(bot, msg)
+		return
+	}
+
+	if !maintenance {
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "maintenance.off", len(heldReminders))))
+		releaseHeldReminders(bot)
+		return
+	}
+
+	send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "maintenance.on")))
+}
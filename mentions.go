@@ -0,0 +1,55 @@
+package main
+
+import (
+	"unicode/utf16"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Telegram usernames are 5-32 characters of letters, digits and underscores.
+const (
+	minUsernameLength = 5
+	maxUsernameLength = 32
+)
+
+// mentionEntities finds @username mentions in text and returns matching
+// "mention" entities. Offsets and lengths are in UTF-16 code units, as the
+// Bot API expects.
+func mentionEntities(text string) []tgbotapi.MessageEntity {
+	runes := []rune(text)
+	var entities []tgbotapi.MessageEntity
+
+	offset := 0
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && isUsernameRune(runes[i-1])) {
+			offset += utf16.RuneLen(runes[i])
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isUsernameRune(runes[end]) {
+			end++
+		}
+
+		length := end - i - 1
+		if length < minUsernameLength || length > maxUsernameLength {
+			offset += utf16.RuneLen(runes[i])
+			continue
+		}
+
+		// Usernames are ASCII, so every rune of the mention is one UTF-16 unit.
+		entities = append(entities, tgbotapi.MessageEntity{
+			Type:   "mention",
+			Offset: offset,
+			Length: end - i,
+		})
+		offset += end - i
+		i = end - 1
+	}
+
+	return entities
+}
+
+func isUsernameRune(r rune) bool {
+	return r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9')
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	updatesProcessed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "remindeer_updates_total",
+		Help: "Updates processed, by kind.",
+	}, []string{"kind"})
+	updateDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "remindeer_update_duration_seconds",
+		Help:    "Time spent handling an update, including waiting for the data lock.",
+		Buckets: prometheus.DefBuckets,
+	})
+	commandsHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "remindeer_commands_total",
+		Help: "Commands received, by command. Unregistered commands count as \"unknown\".",
+	}, []string{"command"})
+	remindersScheduled = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "remindeer_reminders_scheduled_total",
+		Help: "Reminders added to the scheduler.",
+	})
+	remindersFired = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "remindeer_reminders_fired_total",
+		Help: "Reminders that came due while the bot was running.",
+	})
+	remindersMissed = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "remindeer_reminders_missed_total",
+		Help: "Reminders that came due while the bot was down and were caught up on start.",
+	})
+	sendErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "remindeer_send_errors_total",
+		Help: "Failed Telegram API calls, by whether they were rate limited.",
+	}, []string{"reason"})
+	storageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "remindeer_storage_duration_seconds",
+		Help:    "Latency of storage backend operations.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"op"})
+)
+
+func updateKind(update tgbotapi.Update) string {
+	switch {
+	case update.Message != nil:
+		return "message"
+	case update.EditedMessage != nil:
+		return "edited_message"
+	case update.CallbackQuery != nil:
+		return "callback_query"
+	case update.MyChatMember != nil:
+		return "my_chat_member"
+	case update.InlineQuery != nil:
+		return "inline_query"
+	case update.ChosenInlineResult != nil:
+		return "chosen_inline_result"
+	default:
+		return "other"
+	}
+}
+
+// countCommand counts a command under its registered name, so aliases and
+// typos don't grow the label set.
+func countCommand(name string) {
+	if _, ok := findCommand(name); !ok {
+		name = "unknown"
+	}
+	commandsHandled.WithLabelValues(name).Inc()
+}
+
+// observeStorage records how long a storage operation started at start took.
+func observeStorage(op string, start time.Time) {
+	storageDuration.WithLabelValues(op).Observe(time.Since(start).Seconds())
+}
+
+// startMetricsServer serves Prometheus metrics at /metrics on listen.
+func startMetricsServer(listen string) (func(), error) {
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	server := &http.Server{Handler: mux}
+
+	go func() {
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Metrics server stopped", "err", err)
+		}
+	}()
+	slog.Info("Serving metrics", "addr", listen, "path", "/metrics")
+
+	stop := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			slog.Error("Failed to stop metrics server", "err", err)
+		}
+	}
+
+	return stop, nil
+}
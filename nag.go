@@ -0,0 +1,96 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// A nagging reminder is sent again every NagEvery until the user replies
+// "done", at most maxNags extra times.
+const maxNags = 10
+
+func isNagging(reminder Reminder) bool {
+	return !reminder.Recurring && reminder.NagEvery > 0
+}
+
+// isDoneReply reports whether a plain text message acknowledges nagging
+// reminders.
+func isDoneReply(text string) bool {
+	switch strings.ToLower(strings.TrimSpace(text)) {
+	case "done", "готово", "зроблено":
+		return true
+	}
+
+	return false
+}
+
+// renag keeps a nagging reminder around after it fires and schedules the
+// next repeat, or retires it once the cap is reached.
+func renag(chatID int64, reminder Reminder, now time.Time, bot BotClient) {
+	if reminder.Nags >= maxNags {
+		recordFiredReminder(chatID, reminder, now)
+		return
+	}
+
+	userData := todoData[chatID]
+	for i := range userData.Reminders {
+		if userData.Reminders[i].ID == reminder.ID {
+			userData.Reminders[i].Time = now.Add(reminder.NagEvery)
+			userData.Reminders[i].Nags++
+			scheduleReminder(chatID, userData.Reminders[i], bot)
+			return
+		}
+	}
+}
+
+// acknowledgeNags stops every nagging reminder of the chat that has already
+// fired and returns how many there were.
+func acknowledgeNags(chatID int64, now time.Time) int {
+	userData, exists := todoData[chatID]
+	if !exists {
+		return 0
+	}
+
+	var active []Reminder
+	for _, reminder := range userData.Reminders {
+		if isNagging(reminder) && reminder.Nags > 0 {
+			active = append(active, reminder)
+		}
+	}
+	for _, reminder := range active {
+		unscheduleReminder(chatID, reminder.ID)
+		recordFiredReminder(chatID, reminder, now)
+	}
+
+	return len(active)
+}
+
+func handleNagReminder(chatID int64, userID int64, timeStr string, intervalStr string, reminder Reminder, bot BotClient) {
+	interval, err := parseDuration(intervalStr)
+	if err != nil || interval < time.Minute {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.time_format"))
+		send(bot, msg)
+		return
+	}
+
+	reminder.NagEvery = interval
+	handleReminder(chatID, userID, timeStr, reminder, bot)
+}
+
+func handleDoneReply(chatID int64, bot BotClient) bool {
+	stopped := acknowledgeNags(chatID, time.Now())
+	if stopped == 0 {
+		return false
+	}
+
+	send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "nag.stopped", stopped)))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+
+	return true
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// After each fire a nagging reminder comes back NagEvery later, until it
+// has nagged maxNags times.
+func TestRenag(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	now := time.Now()
+
+	tests := []struct {
+		nags    int
+		pending bool
+	}{
+		{0, true},
+		{maxNags - 1, true},
+		{maxNags, false},
+	}
+	for i, test := range tests {
+		chatID := int64(10801 + i)
+		reminder := Reminder{ID: 1, Content: "take pills", Time: now, NagEvery: 5 * time.Minute, Nags: test.nags}
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{reminder}, NextReminderID: 1}
+
+		dataMu.Lock()
+		renag(chatID, reminder, now, bot)
+		current, pending := findReminder(chatID, 1)
+		dataMu.Unlock()
+		if pending != test.pending {
+			t.Errorf("after nag %d: pending %v, want %v", test.nags, pending, test.pending)
+			continue
+		}
+		if pending && (!current.Time.Equal(now.Add(5*time.Minute)) || current.Nags != test.nags+1) {
+			t.Errorf("after nag %d: next at %v with %d nags, want in 5m with %d", test.nags, current.Time.Sub(now), current.Nags, test.nags+1)
+		}
+		if !pending && len(todoData[chatID].History) != 1 {
+			t.Errorf("retired nag not in history: %+v", todoData[chatID].History)
+		}
+	}
+}
+
+// Replying "done" stops the reminders that are nagging, and only those.
+func TestDoneReplyStopsNags(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 10811
+
+	later := time.Now().Add(time.Hour)
+	todoData[chatID] = &UserData{
+		Todos: []Todo{},
+		Reminders: []Reminder{
+			{ID: 1, Content: "take pills", Time: later, NagEvery: 5 * time.Minute, Nags: 2},
+			{ID: 2, Content: "water", Time: later, NagEvery: 5 * time.Minute},
+			{ID: 3, Content: "call mom", Time: later},
+		},
+		NextReminderID: 3,
+	}
+
+	dispatch(command(chatID, "Готово"), bot)
+	var left []int
+	for _, reminder := range todoData[chatID].Reminders {
+		left = append(left, reminder.ID)
+	}
+	if len(left) != 2 || left[0] != 2 || left[1] != 3 {
+		t.Errorf("reminders %v left, want [2 3]", left)
+	}
+}
+
+func TestIsDoneReply(t *testing.T) {
+	for text, want := range map[string]bool{
+		"done":       true,
+		" Done ":     true,
+		"ГОТОВО":     true,
+		"зроблено":   true,
+		"done!":      false,
+		"not done":   false,
+		"":           false,
+		"/done 1":    false,
+		"все готово": false,
+	} {
+		if got := isDoneReply(text); got != want {
+			t.Errorf("isDoneReply(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
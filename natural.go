@@ -0,0 +1,466 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var (
+	ordinalPattern  = regexp.MustCompile(`^(\d{1,2})(st|nd|rd|th)$`)
+	naturalPrefixes = []string{"remind me to ", "remind me ", "нагадай мені ", "нагадай "}
+	numberWords     = map[string]int{
+		"a": 1, "an": 1, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6,
+		"seven": 7, "eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12,
+		"fifteen": 15, "twenty": 20, "thirty": 30, "forty": 40, "fifty": 50,
+	}
+)
+
+// naturalTime collects the pieces of a time expression found in a /r
+// message. Unset pieces are left zero, with clock -1.
+type naturalTime struct {
+	offset   time.Duration
+	months   int
+	days     int
+	date     time.Time
+	weekday  *time.Weekday
+	nextWeek bool
+	clock    int
+	every    string
+	interval time.Duration
+	monthDay int
+}
+
+// NaturalReminder is what /r understood: a one-off Time or a cron Schedule
+// in the chat's local time, and the message.
+type NaturalReminder struct {
+	Time     time.Time
+	Schedule string
+	Content  string
+}
+
+func cleanWord(word string) string {
+	return strings.Trim(strings.ToLower(word), ".,!?;")
+}
+
+// parseWeekday accepts full weekday names only, so that words like "sun"
+// or "sat" stay part of the message.
+func parseWeekday(word string) (time.Weekday, bool) {
+	word = strings.TrimSuffix(word, "s")
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		if word == strings.ToLower(wd.String()) {
+			return wd, true
+		}
+	}
+
+	return 0, false
+}
+
+func parseMonthName(word string) (time.Month, bool) {
+	for m := time.January; m <= time.December; m++ {
+		name := strings.ToLower(m.String())
+		if word == name || word == name[:3] {
+			return m, true
+		}
+	}
+
+	return 0, false
+}
+
+func parseOrdinal(word string) (int, bool) {
+	match := ordinalPattern.FindStringSubmatch(word)
+	if match == nil {
+		return 0, false
+	}
+	day, _ := strconv.Atoi(match[1])
+
+	return day, day >= 1 && day <= 31
+}
+
+func parseAmount(word string) (int, bool) {
+	if n, ok := numberWords[word]; ok {
+		return n, true
+	}
+	n, err := strconv.Atoi(word)
+
+	return n, err == nil && n > 0
+}
+
+// parseNaturalClock reads "noon", "9am", "18:30" or "5 pm" at words[i] and
+// returns the minutes after midnight and how many words it used.
+func parseNaturalClock(words []string, i int) (int, int, bool) {
+	if i >= len(words) {
+		return 0, 0, false
+	}
+	switch words[i] {
+	case "noon":
+		return 12 * 60, 1, true
+	case "midnight":
+		return 0, 1, true
+	}
+	if i+1 < len(words) && (words[i+1] == "am" || words[i+1] == "pm") {
+		if minutes, err := parseClock12(words[i] + words[i+1]); err == nil {
+			return minutes, 2, true
+		}
+	}
+	if minutes, err := parseAnyClock(words[i]); err == nil {
+		return minutes, 1, true
+	}
+
+	return 0, 0, false
+}
+
+// addUnit adds n of the named unit to t and reports how it was understood.
+func (t *naturalTime) addUnit(n int, unit string) bool {
+	switch strings.TrimSuffix(unit, "s") {
+	case "minute", "min":
+		t.offset += time.Duration(n) * time.Minute
+	case "hour", "hr":
+		t.offset += time.Duration(n) * time.Hour
+	case "day":
+		t.days += n
+	case "week":
+		t.days += 7 * n
+	case "month":
+		t.months += n
+	default:
+		return false
+	}
+
+	return true
+}
+
+// unitDuration converts an amount and unit to a fixed interval for "every".
+func unitDuration(n int, unit string) (time.Duration, bool) {
+	switch strings.TrimSuffix(unit, "s") {
+	case "minute", "min":
+		return time.Duration(n) * time.Minute, true
+	case "hour", "hr":
+		return time.Duration(n) * time.Hour, true
+	}
+
+	return 0, false
+}
+
+// match tries to read a time expression at words[i] and returns how many
+// words it used, or 0 if there is none there.
+func (t *naturalTime) match(words []string, i int, now time.Time) int {
+	w := words[i]
+	next := func(k int) string {
+		if i+k < len(words) {
+			return words[i+k]
+		}
+		return ""
+	}
+
+	switch w {
+	case "today":
+		t.date = now
+		return 1
+	case "tonight":
+		t.date = now
+		if t.clock < 0 {
+			t.clock = 20 * 60
+		}
+		return 1
+	case "tomorrow":
+		t.date = now.AddDate(0, 0, 1)
+		return 1
+	case "daily":
+		t.every = "day"
+		return 1
+	case "weekly":
+		t.every = "week"
+		return 1
+	case "monthly":
+		t.every = "month"
+		return 1
+	case "in":
+		if n, ok := parseAmount(next(1)); ok && t.addUnit(n, next(2)) {
+			return 3
+		}
+		if d, err := parseDuration(next(1)); err == nil && d > 0 {
+			t.offset += d
+			return 2
+		}
+	case "at":
+		if minutes, used, ok := parseNaturalClock(words, i+1); ok {
+			t.clock = minutes
+			return 1 + used
+		}
+		if hour, err := strconv.Atoi(next(1)); err == nil && hour >= 0 && hour <= 23 {
+			t.clock = hour * 60
+			return 2
+		}
+	case "next":
+		if wd, ok := parseWeekday(next(1)); ok {
+			t.weekday, t.nextWeek = &wd, true
+			return 2
+		}
+		if next(1) == "week" {
+			t.days += 7
+			return 2
+		}
+		if next(1) == "month" {
+			t.months++
+			return 2
+		}
+	case "every":
+		if wd, ok := parseWeekday(next(1)); ok {
+			t.every, t.weekday = "week", &wd
+			return 2
+		}
+		switch next(1) {
+		case "day", "morning":
+			t.every = "day"
+			return 2
+		case "week":
+			t.every = "week"
+			return 2
+		case "month":
+			t.every = "month"
+			return 2
+		}
+		if n, ok := parseAmount(next(1)); ok {
+			if d, ok := unitDuration(n, next(2)); ok {
+				t.every, t.interval = "interval", d
+				return 3
+			}
+		}
+	case "on":
+		if i+1 >= len(words) {
+			return 0
+		}
+		if used := t.match(words, i+1, now); used > 0 {
+			return 1 + used
+		}
+		return 0
+	case "the":
+		if i+1 >= len(words) {
+			return 0
+		}
+		if _, ok := parseOrdinal(next(1)); ok {
+			return 1 + t.match(words, i+1, now)
+		}
+		return 0
+	}
+
+	if wd, ok := parseWeekday(w); ok {
+		t.weekday = &wd
+		return 1
+	}
+	if minutes, used, ok := parseNaturalClock(words, i); ok {
+		t.clock = minutes
+		return used
+	}
+
+	if day, ok := parseOrdinal(w); ok {
+		// "1st of every month", "3rd of june", "3rd june" or a bare "1st".
+		used := 1
+		if next(used) == "of" {
+			used++
+		}
+		if next(used) == "every" && next(used+1) == "month" {
+			t.every, t.monthDay = "month", day
+			return used + 2
+		}
+		if month, ok := parseMonthName(next(used)); ok {
+			t.date = calendarDate(month, day, now)
+			return used + 1
+		}
+		t.monthDay = day
+		return 1
+	}
+	if month, ok := parseMonthName(w); ok {
+		// "june 3rd" or "june 3".
+		if day, ok := parseOrdinal(next(1)); ok {
+			t.date = calendarDate(month, day, now)
+			return 2
+		}
+		if day, err := strconv.Atoi(next(1)); err == nil && day >= 1 && day <= 31 {
+			t.date = calendarDate(month, day, now)
+			return 2
+		}
+	}
+
+	return 0
+}
+
+// calendarDate returns the next month and day on or after today.
+func calendarDate(month time.Month, day int, now time.Time) time.Time {
+	date := time.Date(now.Year(), month, day, 0, 0, 0, 0, now.Location())
+	if date.Before(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())) {
+		date = date.AddDate(1, 0, 0)
+	}
+
+	return date
+}
+
+// parseNatural reads messages like "remind me to pay rent on the 1st of
+// every month at noon" or "call mom in 20 minutes". Words that are part of
+// a time expression are removed and the rest becomes the message.
+func parseNatural(text string, now time.Time) (NaturalReminder, error) {
+	for _, prefix := range naturalPrefixes {
+		if strings.HasPrefix(strings.ToLower(text), prefix) {
+			text = text[len(prefix):]
+			break
+		}
+	}
+
+	original := strings.Fields(text)
+	words := make([]string, len(original))
+	for i, word := range original {
+		words[i] = cleanWord(word)
+	}
+
+	t := naturalTime{clock: -1}
+	var content []string
+	found := false
+	for i := 0; i < len(words); {
+		if used := t.match(words, i, now); used > 0 {
+			found = true
+			i += used
+			continue
+		}
+		content = append(content, original[i])
+		i++
+	}
+
+	if len(content) > 0 && strings.EqualFold(content[0], "to") {
+		content = content[1:]
+	}
+	message := strings.Trim(strings.Join(content, " "), " ,.")
+	if !found {
+		return NaturalReminder{}, errors.New("no time found")
+	}
+	if message == "" {
+		return NaturalReminder{}, errors.New("no message found")
+	}
+
+	if t.every != "" {
+		spec, err := t.schedule(now)
+		return NaturalReminder{Schedule: spec, Content: message}, err
+	}
+
+	at, err := t.resolve(now)
+	return NaturalReminder{Time: at, Content: message}, err
+}
+
+func (t naturalTime) clockOr(hour int) (int, int) {
+	if t.clock < 0 {
+		return hour, 0
+	}
+
+	return t.clock / 60, t.clock % 60
+}
+
+func (t naturalTime) schedule(now time.Time) (string, error) {
+	hour, minute := t.clockOr(defaultDayHour)
+	switch t.every {
+	case "interval":
+		if t.interval < time.Minute {
+			return "", errors.New("interval too short")
+		}
+		return everySpec(t.interval, now), nil
+	case "day":
+		return fmt.Sprintf("%d %d * * *", minute, hour), nil
+	case "week":
+		weekday := now.Weekday()
+		if t.weekday != nil {
+			weekday = *t.weekday
+		}
+		return fmt.Sprintf("%d %d * * %d", minute, hour, weekday), nil
+	default:
+		day := now.Day()
+		if t.monthDay > 0 {
+			day = t.monthDay
+		}
+		return fmt.Sprintf("%d %d %d * *", minute, hour, day), nil
+	}
+}
+
+func (t naturalTime) resolve(now time.Time) (time.Time, error) {
+	if t.offset > 0 && t.date.IsZero() && t.weekday == nil && t.days == 0 && t.months == 0 && t.clock < 0 {
+		return now.Add(t.offset), nil
+	}
+
+	day := now
+	dayGiven := false
+	switch {
+	case !t.date.IsZero():
+		day, dayGiven = t.date, true
+	case t.weekday != nil:
+		offset := (int(*t.weekday) - int(now.Weekday()) + 7) % 7
+		if offset == 0 && t.nextWeek {
+			offset = 7
+		}
+		day, dayGiven = now.AddDate(0, 0, offset), true
+	case t.monthDay > 0:
+		day = time.Date(now.Year(), now.Month(), t.monthDay, 0, 0, 0, 0, now.Location())
+		if day.Day() != t.monthDay || day.Before(time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())) {
+			day = time.Date(now.Year(), now.Month()+1, t.monthDay, 0, 0, 0, 0, now.Location())
+		}
+		dayGiven = true
+	case t.days > 0 || t.months > 0:
+		day, dayGiven = now.AddDate(0, t.months, t.days), true
+	}
+
+	if !dayGiven {
+		if t.clock < 0 {
+			return now.Add(t.offset), nil
+		}
+		return nextClockTime(now, t.clock).Add(t.offset), nil
+	}
+
+	hour, minute := t.clockOr(defaultDayHour)
+	at := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, now.Location()).Add(t.offset)
+	if t.weekday != nil && !at.After(now) {
+		at = at.AddDate(0, 0, 7)
+	}
+
+	return at, nil
+}
+
+func handleNaturalReminder(chatID int64, userID int64, text string, reminder Reminder, bot BotClient) {
+	now := time.Now().In(chatLocation(chatID))
+	parsed, err := parseNatural(text, now)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "natural.not_understood")+" "+tr(chatID, "natural.examples"))
+		send(bot, msg)
+		return
+	}
+	reminder.Content = parsed.Content
+	if !checkReminderLimit(chatID, bot) || !checkQuota(chatID, len(reminder.Content), bot) {
+		return
+	}
+
+	var when string
+	if parsed.Schedule != "" {
+		reminder.Schedule = withCronTZ(parsed.Schedule, chatLocation(chatID))
+		next, err := nextFireTime(reminder, now)
+		if err != nil {
+			msg := tgbotapi.NewMessage(chatID, tr(chatID, "natural.not_understood"))
+			send(bot, msg)
+			return
+		}
+		reminder.Schedule = parsed.Schedule
+		when = tr(chatID, "natural.scheduled", parsed.Schedule, formatTime(chatID, next))
+	} else {
+		if !parsed.Time.After(now) {
+			msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.time_passed"))
+			send(bot, msg)
+			return
+		}
+		reminder.Time = parsed.Time
+		when = formatTime(chatID, parsed.Time)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "natural.confirm", reminder.Content, when))
+	msg.ReplyMarkup = addPendingConfirmation(chatID, userID, reminder.Time.Format(time.RFC3339), reminder)
+	send(bot, msg)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseNatural(t *testing.T) {
+	now := time.Date(2024, time.March, 14, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		text     string
+		time     time.Time
+		schedule string
+		content  string
+	}{
+		{"call mom in 20 minutes", now.Add(20 * time.Minute), "", "call mom"},
+		{"нагадай мені call mom in 1 hour", now.Add(time.Hour), "", "call mom"},
+		{"dentist tomorrow at 9am", time.Date(2024, time.March, 15, 9, 0, 0, 0, time.UTC), "", "dentist"},
+		{"buy milk at 6pm", time.Date(2024, time.March, 14, 18, 0, 0, 0, time.UTC), "", "buy milk"},
+		{"buy milk at 9am", time.Date(2024, time.March, 15, 9, 0, 0, 0, time.UTC), "", "buy milk"},
+		{"party on friday at 8pm", time.Date(2024, time.March, 15, 20, 0, 0, 0, time.UTC), "", "party"},
+		{"call mom next week", time.Date(2024, time.March, 21, 9, 0, 0, 0, time.UTC), "", "call mom"},
+		{"taxes on the 15th", time.Date(2024, time.March, 15, 9, 0, 0, 0, time.UTC), "", "taxes"},
+		{"remind me to pay rent on the 1st of every month at noon", time.Time{}, "0 12 1 * *", "pay rent"},
+		{"gym every monday at 7pm", time.Time{}, "0 19 * * 1", "gym"},
+		{"water plants every day at 8am", time.Time{}, "0 8 * * *", "water plants"},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			got, err := parseNatural(test.text, now)
+			if err != nil {
+				t.Fatalf("error: %v", err)
+			}
+			if !got.Time.Equal(test.time) || got.Schedule != test.schedule || got.Content != test.content {
+				t.Errorf("got %v %q %q, want %v %q %q", got.Time, got.Schedule, got.Content, test.time, test.schedule, test.content)
+			}
+		})
+	}
+}
+
+func TestParseNaturalRejects(t *testing.T) {
+	now := time.Date(2024, time.March, 14, 10, 0, 0, 0, time.UTC)
+
+	for _, text := range []string{"", "nothing here", "in 2 hours"} {
+		if got, err := parseNatural(text, now); err == nil {
+			t.Errorf("parseNatural(%q) = %+v, want an error", text, got)
+		}
+	}
+}
+
+func TestParseNaturalTrailingKeyword(t *testing.T) {
+	now := time.Date(2024, time.March, 14, 10, 0, 0, 0, time.UTC)
+
+	for _, keyword := range []string{"on", "the", "at", "in", "next", "every"} {
+		t.Run(keyword, func(t *testing.T) {
+			if _, err := parseNatural("call mom "+keyword, now); err == nil {
+				t.Errorf("parseNatural(%q) found a time", "call mom "+keyword)
+			}
+		})
+	}
+}
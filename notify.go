@@ -0,0 +1,439 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math/big"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const notifyTimeout = 10 * time.Second
+
+// An email address only becomes a channel once the code sent to it is
+// entered with /notify confirm, so the bot can't be used to mail strangers.
+const (
+	emailCodeDigits   = 6
+	emailCodeTTL      = time.Hour
+	emailCodeCooldown = 5 * time.Minute
+	maxEmailAttempts  = 5
+)
+
+// EmailConfirmation is an address waiting for its code.
+type EmailConfirmation struct {
+	Address  string    `json:"address"`
+	Code     string    `json:"code"`
+	SentAt   time.Time `json:"sent_at"`
+	Attempts int       `json:"attempts,omitempty"`
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, which IsPrivate
+// doesn't cover.
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// Notification is a reminder as delivered outside Telegram. Address is the
+// chat's destination for the channel, e.g. an email address or a URL.
+type Notification struct {
+	ChatID     int64     `json:"chat_id"`
+	ReminderID int       `json:"reminder_id"`
+	Title      string    `json:"title"`
+	Content    string    `json:"content"`
+	URL        string    `json:"url,omitempty"`
+	Labels     []string  `json:"labels,omitempty"`
+	Time       time.Time `json:"time"`
+	Address    string    `json:"-"`
+}
+
+// Notifier delivers reminders over a channel other than Telegram. Reminders
+// are always sent to the chat; --also adds channels on top.
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// notifiers holds the channels the operator configured, by the name used
+// after --also. Empty unless SMTP_ADDR or NOTIFY_WEBHOOKS is set.
+var notifiers = make(map[string]Notifier)
+
+// SMTPNotifier sends reminders as plain-text email.
+type SMTPNotifier struct {
+	Addr string
+	From string
+	Auth smtp.Auth
+}
+
+func NewSMTPNotifier(addr, username, password, from string) *SMTPNotifier {
+	notifier := &SMTPNotifier{Addr: addr, From: from}
+	if username != "" {
+		host, _, _ := strings.Cut(addr, ":")
+		notifier.Auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return notifier
+}
+
+func (s *SMTPNotifier) Notify(n Notification) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", n.Address)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", n.Title))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(n.Content, "\n", "\r\n"))
+
+	return smtp.SendMail(s.Addr, s.Auth, s.From, []string{n.Address}, msg.Bytes())
+}
+
+// WebhookNotifier posts the notification as JSON to the chat's URL and
+// expects a 2xx answer.
+type WebhookNotifier struct {
+	Client *http.Client
+}
+
+// NewWebhookNotifier returns a notifier that only connects to public
+// addresses. The address is checked when connecting, after DNS, so a host
+// that resolved to a public address for /notify can't later point the bot
+// at localhost or the internal network. Redirects are dialed the same way.
+func NewWebhookNotifier() *WebhookNotifier {
+	dialer := &net.Dialer{Timeout: notifyTimeout, Control: dialPublicOnly}
+	transport := &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: notifyTimeout}
+
+	return &WebhookNotifier{Client: &http.Client{Timeout: notifyTimeout, Transport: transport}}
+}
+
+// isPublicIP reports whether ip is an internet address rather than one of
+// the bot's own host or network.
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() &&
+		!sharedAddressSpace.Contains(ip)
+}
+
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("refusing to connect to non-public address %s", host)
+	}
+
+	return nil
+}
+
+// checkWebhookHost rejects hosts that resolve to any non-public address.
+func checkWebhookHost(host string) error {
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if !isPublicIP(ip) {
+			return fmt.Errorf("%s resolves to non-public address %s", host, ip)
+		}
+	}
+
+	return nil
+}
+
+func newEmailCode() (string, error) {
+	max := big.NewInt(1)
+	for range emailCodeDigits {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%0*d", emailCodeDigits, n), nil
+}
+
+func (w *WebhookNotifier) Notify(n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.Client.Post(n.Address, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+
+	return nil
+}
+
+// channelAddress returns the chat's destination for a channel, or an empty
+// string if the chat hasn't set one.
+func channelAddress(chatID int64, channel string) string {
+	userData, exists := todoData[chatID]
+	if !exists {
+		return ""
+	}
+
+	switch channel {
+	case "email":
+		return userData.Settings.Email
+	case "webhook":
+		return userData.Settings.NotifyWebhook
+	}
+
+	return ""
+}
+
+// cutAlsoFlag takes an "--also email,webhook" option out of /remind
+// arguments and returns the channels it lists.
+func cutAlsoFlag(args string) (string, []string, error) {
+	args, value, found := cutFlag(args, "--also")
+	if !found {
+		return args, nil, nil
+	}
+	if value == "" {
+		return "", nil, fmt.Errorf("missing channels")
+	}
+
+	var channels []string
+	for _, channel := range strings.Split(strings.ToLower(value), ",") {
+		if !slices.Contains(channels, channel) {
+			channels = append(channels, channel)
+		}
+	}
+
+	return args, channels, nil
+}
+
+// checkChannels tells the chat off unless every channel is configured on
+// the server and has a destination set with /notify.
+func checkChannels(chatID int64, channels []string, bot BotClient) bool {
+	for _, channel := range channels {
+		if _, ok := notifiers[channel]; !ok {
+			send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "notify.unknown_channel", channel, strings.Join(slices.Sorted(maps.Keys(notifiers)), ", "))))
+			return false
+		}
+		if channelAddress(chatID, channel) == "" {
+			send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "notify.no_address", channel)))
+			return false
+		}
+	}
+
+	return true
+}
+
+// notifyChannels fans the reminder out to its extra channels. Sending runs
+// in the background without holding dataMu; a channel that fails is
+// reported to the chat by name.
+func notifyChannels(chatID int64, reminder Reminder, bot BotClient) {
+	if len(reminder.Channels) == 0 {
+		return
+	}
+
+	base := Notification{
+		ChatID:     chatID,
+		ReminderID: reminder.ID,
+		Title:      tr(chatID, "reminder.label") + ": " + reminder.DisplayTitle(),
+		Content:    reminder.Content,
+		URL:        reminder.URL,
+		Labels:     reminder.Labels,
+		Time:       reminder.Time,
+	}
+	failure := tr(chatID, "notify.failed")
+	for _, channel := range reminder.Channels {
+		notifier, ok := notifiers[channel]
+		address := channelAddress(chatID, channel)
+		if !ok || address == "" {
+			send(bot, tgbotapi.NewMessage(chatID, fmt.Sprintf(failure, channel, reminder.DisplayTitle(), tr(chatID, "notify.not_configured"))))
+			continue
+		}
+
+		n := base
+		n.Address = address
+		go func() {
+			if err := notifier.Notify(n); err != nil {
+				chatLog(chatID).Error("Failed to notify", "channel", channel, "reminder_id", reminder.ID, "err", err)
+				sendDirect(bot, tgbotapi.NewMessage(chatID, fmt.Sprintf(failure, channel, reminder.DisplayTitle(), err)))
+			}
+		}()
+	}
+}
+
+// handleNotify shows the chat's channel destinations, or sets or clears
+// one: "email <address>|off", "confirm <code>" or "webhook <url>|off".
+func handleNotify(chatID int64, args string, bot BotClient) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		text := tr(chatID, "notify.title")
+		for _, channel := range []string{"email", "webhook"} {
+			address := channelAddress(chatID, channel)
+			if address == "" {
+				address = "—"
+			}
+			text += fmt.Sprintf("\n• %s: %s", channel, address)
+		}
+		if userData, exists := todoData[chatID]; exists && userData.Settings.PendingEmail != nil {
+			text += "\n" + tr(chatID, "notify.email_pending", userData.Settings.PendingEmail.Address)
+		}
+		send(bot, tgbotapi.NewMessage(chatID, text))
+		return
+	}
+	if len(fields) == 2 && fields[0] == "confirm" {
+		handleConfirmEmail(chatID, fields[1], bot)
+		return
+	}
+	if len(fields) != 2 || fields[0] != "email" && fields[0] != "webhook" {
+		sendUsage(chatID, "notify", bot)
+		return
+	}
+
+	channel, address := fields[0], fields[1]
+	if address != "off" {
+		if _, ok := notifiers[channel]; !ok {
+			send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "notify.disabled", channel)))
+			return
+		}
+	}
+	if channel == "email" && address != "off" {
+		requestEmailConfirmation(chatID, address, bot)
+		return
+	}
+	if channel == "webhook" && address != "off" {
+		u, err := url.Parse(address)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "notify.invalid_webhook")))
+			return
+		}
+		unlocked(func() {
+			err = checkWebhookHost(u.Hostname())
+		})
+		if err != nil {
+			chatLog(chatID).Warn("Rejected webhook", "host", u.Hostname(), "err", err)
+			send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "notify.private_webhook")))
+			return
+		}
+	}
+	if address == "off" {
+		address = ""
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	settings := &todoData[chatID].Settings
+	if channel == "email" {
+		settings.Email = address
+		settings.PendingEmail = nil
+	} else {
+		settings.NotifyWebhook = address
+	}
+
+	text := tr(chatID, "notify.set", channel, channel)
+	if address == "" {
+		text = tr(chatID, "notify.cleared", channel)
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+// requestEmailConfirmation mails a code to the address. The address is only
+// used once /notify confirm gets the code, and a new code can be asked for
+// only every emailCodeCooldown.
+func requestEmailConfirmation(chatID int64, address string, bot BotClient) {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "notify.invalid_email")))
+		return
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	settings := &todoData[chatID].Settings
+	if pending := settings.PendingEmail; pending != nil && time.Since(pending.SentAt) < emailCodeCooldown {
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "notify.email_wait", pending.Address)))
+		return
+	}
+
+	code, err := newEmailCode()
+	if err != nil {
+		chatLog(chatID).Error("Failed to generate email code", "err", err)
+		return
+	}
+	settings.PendingEmail = &EmailConfirmation{Address: parsed.Address, Code: code, SentAt: time.Now()}
+
+	n := Notification{
+		ChatID:  chatID,
+		Title:   tr(chatID, "notify.email_code_subject"),
+		Content: tr(chatID, "notify.email_code_body", code),
+		Time:    time.Now(),
+		Address: parsed.Address,
+	}
+	notifier := notifiers["email"]
+	failure := tr(chatID, "notify.email_code_failed", parsed.Address)
+	go func() {
+		if err := notifier.Notify(n); err != nil {
+			chatLog(chatID).Error("Failed to send email code", "err", err)
+			sendDirect(bot, tgbotapi.NewMessage(chatID, failure))
+		}
+	}()
+	send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "notify.email_code_sent", parsed.Address)))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+// handleConfirmEmail turns the pending address into the email channel if
+// the code matches. Too many wrong codes or an old code drop the request.
+func handleConfirmEmail(chatID int64, code string, bot BotClient) {
+	userData, exists := todoData[chatID]
+	if !exists || userData.Settings.PendingEmail == nil {
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "notify.email_no_pending")))
+		return
+	}
+	settings := &userData.Settings
+	pending := settings.PendingEmail
+
+	switch {
+	case time.Since(pending.SentAt) > emailCodeTTL:
+		settings.PendingEmail = nil
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "notify.email_code_expired")))
+	case subtle.ConstantTimeCompare([]byte(code), []byte(pending.Code)) != 1:
+		pending.Attempts++
+		if pending.Attempts >= maxEmailAttempts {
+			settings.PendingEmail = nil
+			send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "notify.email_code_expired")))
+		} else {
+			send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "notify.email_code_wrong")))
+		}
+	default:
+		settings.Email = pending.Address
+		settings.PendingEmail = nil
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "notify.set", "email", "email")))
+	}
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
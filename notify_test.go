@@ -0,0 +1,162 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"224.0.0.1", false},
+	}
+	for _, test := range tests {
+		if got := isPublicIP(net.ParseIP(test.ip)); got != test.want {
+			t.Errorf("isPublicIP(%s) = %v, want %v", test.ip, got, test.want)
+		}
+	}
+}
+
+func TestWebhookNotifierRefusesLocalhost(t *testing.T) {
+	hit := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit = true
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier().Notify(Notification{Address: server.URL, Time: time.Now()})
+	if err == nil || hit {
+		t.Errorf("Notify to %s: err %v, server hit %v; want refused", server.URL, err, hit)
+	}
+}
+
+func TestNotifyRejectsPrivateWebhook(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	notifiers["webhook"] = NewWebhookNotifier()
+	t.Cleanup(func() { delete(notifiers, "webhook") })
+	const chatID = 5001
+
+	dispatch(command(chatID, "/notify webhook https://127.0.0.1/hook"), bot)
+	if address := channelAddress(chatID, "webhook"); address != "" {
+		t.Errorf("webhook set to %q", address)
+	}
+}
+
+// captureNotifier keeps the notifications instead of delivering them.
+type captureNotifier struct {
+	mu   sync.Mutex
+	sent []Notification
+}
+
+func (c *captureNotifier) Notify(n Notification) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sent = append(c.sent, n)
+
+	return nil
+}
+
+// code waits for the confirmation mail to go out and returns its code.
+func (c *captureNotifier) code(t *testing.T) string {
+	t.Helper()
+	for range 100 {
+		c.mu.Lock()
+		if len(c.sent) > 0 {
+			content := c.sent[len(c.sent)-1].Content
+			c.mu.Unlock()
+			for _, word := range strings.Fields(content) {
+				if len(word) == emailCodeDigits && strings.Trim(word, "0123456789") == "" {
+					return word
+				}
+			}
+			t.Fatalf("no code in %q", content)
+		}
+		c.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("no confirmation mail sent")
+
+	return ""
+}
+
+func TestNotifyEmailNeedsConfirmation(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	mailer := &captureNotifier{}
+	notifiers["email"] = mailer
+	t.Cleanup(func() { delete(notifiers, "email") })
+	const chatID = 5002
+
+	dispatch(command(chatID, "/notify email me@example.com"), bot)
+	if address := channelAddress(chatID, "email"); address != "" {
+		t.Fatalf("email set to %q before confirming", address)
+	}
+	code := mailer.code(t)
+	if to := mailer.sent[0].Address; to != "me@example.com" {
+		t.Errorf("code mailed to %q", to)
+	}
+
+	// Asking again right away doesn't mail a second code.
+	dispatch(command(chatID, "/notify email other@example.com"), bot)
+	if len(mailer.sent) != 1 {
+		t.Errorf("%d mails sent, want 1", len(mailer.sent))
+	}
+
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	dispatch(command(chatID, "/notify confirm "+wrong), bot)
+	if address := channelAddress(chatID, "email"); address != "" {
+		t.Fatalf("email set to %q by a wrong code", address)
+	}
+
+	dispatch(command(chatID, "/notify confirm "+code), bot)
+	if address := channelAddress(chatID, "email"); address != "me@example.com" {
+		t.Errorf("email %q after confirming, want me@example.com", address)
+	}
+	if todoData[chatID].Settings.PendingEmail != nil {
+		t.Error("pending email kept after confirming")
+	}
+}
+
+func TestNotifyEmailGivesUpAfterWrongCodes(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	notifiers["email"] = &captureNotifier{}
+	t.Cleanup(func() { delete(notifiers, "email") })
+	const chatID = 5003
+
+	dispatch(command(chatID, "/notify email me@example.com"), bot)
+	code := todoData[chatID].Settings.PendingEmail.Code
+	for range maxEmailAttempts {
+		dispatch(command(chatID, "/notify confirm x"), bot)
+	}
+	dispatch(command(chatID, "/notify confirm "+code), bot)
+	if address := channelAddress(chatID, "email"); address != "" {
+		t.Errorf("email set to %q after too many wrong codes", address)
+	}
+}
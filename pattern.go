@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const patternBarWidth = 20
+
+// reminderTimes collects the times of the chat's fired and pending one-shot
+// reminders in the chat's timezone.
+func reminderTimes(chatID int64) []time.Time {
+	userData, exists := todoData[chatID]
+	if !exists {
+		return nil
+	}
+
+	loc := chatLocation(chatID)
+	var times []time.Time
+	for _, fired := range userData.History {
+		times = append(times, fired.FiredAt.In(loc))
+	}
+	for _, reminder := range userData.Reminders {
+		if !reminder.Recurring {
+			times = append(times, reminder.Time.In(loc))
+		}
+	}
+
+	return times
+}
+
+// countByWeekday buckets times by day of the week, starting at weekStart.
+func countByWeekday(times []time.Time, weekStart time.Weekday) [7]int {
+	var counts [7]int
+	for _, t := range times {
+		counts[(int(t.Weekday())-int(weekStart)+7)%7]++
+	}
+
+	return counts
+}
+
+func countByHour(times []time.Time) [24]int {
+	var counts [24]int
+	for _, t := range times {
+		counts[t.Hour()]++
+	}
+
+	return counts
+}
+
+// renderHistogram draws one bar per bucket, scaled so the biggest bucket is
+// patternBarWidth wide.
+func renderHistogram(labels []string, counts []int) string {
+	highest := 0
+	for _, count := range counts {
+		highest = max(highest, count)
+	}
+
+	var b strings.Builder
+	for i, count := range counts {
+		width := 0
+		if highest > 0 {
+			width = (count*patternBarWidth + highest - 1) / highest
+		}
+		fmt.Fprintf(&b, "%s %s %d\n", labels[i], strings.Repeat("█", width), count)
+	}
+
+	return b.String()
+}
+
+func handlePattern(chatID int64, mode string, bot BotClient) {
+	times := reminderTimes(chatID)
+	if len(times) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "pattern.empty"))
+		send(bot, msg)
+		return
+	}
+
+	var labels []string
+	var counts []int
+	switch mode {
+	case "", "days":
+		weekStart := chatWeekStart(chatID)
+		weekdayNames := strings.Fields(tr(chatID, "format.weekdays"))
+		byDay := countByWeekday(times, weekStart)
+		for i := range byDay {
+			labels = append(labels, weekdayNames[(int(weekStart)+i)%7])
+		}
+		counts = byDay[:]
+	case "hours":
+		byHour := countByHour(times)
+		for hour := range byHour {
+			labels = append(labels, fmt.Sprintf("%02d", hour))
+		}
+		counts = byHour[:]
+	default:
+		sendUsage(chatID, "pattern", bot)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "pattern.title", len(times), renderHistogram(labels, counts)))
+	msg.ParseMode = tgbotapi.ModeHTML
+	send(bot, msg)
+}
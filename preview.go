@@ -0,0 +1,26 @@
+package main
+
+import (
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handlePreview shows how a reminder would look when it fires without
+// creating or scheduling it.
+func handlePreview(chatID int64, timeStr string, content string, bot BotClient) {
+	now := time.Now()
+	reminderTime, err := parseReminderTime(chatID, timeStr, now)
+	if err != nil || !reminderTime.After(now) {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.time_format"))
+		send(bot, msg)
+		return
+	}
+
+	reminder := Reminder{Time: reminderTime}
+	reminder.Title, reminder.Content = splitTitle(content)
+	reminder.URL = urlPattern.FindString(reminder.Content)
+
+	send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "preview.header", formatTime(chatID, reminderTime))))
+	send(bot, reminderMessage(chatID, chatID, reminder, "reminder.label"))
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// QuietHours is a daily window, in minutes after midnight, during which
+// reminders are held back until the window ends. The window may wrap past
+// midnight.
+type QuietHours struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// deferredFires holds the timers of recurring reminders whose fire was held
+// back by quiet hours or /delay, so they can be stopped along with the
+// reminder's cron entry.
+var deferredFires = make(map[reminderKey]*time.Timer)
+
+func parseClock(s string) (int, error) {
+	hoursStr, minutesStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+
+	hours, err := strconv.Atoi(hoursStr)
+	if err != nil || hours < 0 || hours > 23 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	minutes, err := strconv.Atoi(minutesStr)
+	if err != nil || minutes < 0 || minutes > 59 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+
+	return hours*60 + minutes, nil
+}
+
+// parseClock12 parses a 12-hour clock time such as "9am", "9:30pm" or
+// "12am" (midnight) into minutes after midnight.
+func parseClock12(s string) (int, error) {
+	clock := strings.ToLower(s)
+	var offset int
+	switch {
+	case strings.HasSuffix(clock, "am"):
+	case strings.HasSuffix(clock, "pm"):
+		offset = 12 * 60
+	default:
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	clock = clock[:len(clock)-2]
+
+	hoursStr, minutesStr, hasMinutes := strings.Cut(clock, ":")
+	hours, err := strconv.Atoi(hoursStr)
+	if err != nil || hours < 1 || hours > 12 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	minutes := 0
+	if hasMinutes {
+		minutes, err = strconv.Atoi(minutesStr)
+		if err != nil || len(minutesStr) != 2 || minutes > 59 {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+	}
+
+	return (hours%12)*60 + offset + minutes, nil
+}
+
+func parseQuietHours(s string) (QuietHours, error) {
+	startStr, endStr, ok := strings.Cut(s, "-")
+	if !ok {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours %q", s)
+	}
+
+	start, err := parseClock(startStr)
+	if err != nil {
+		return QuietHours{}, err
+	}
+	end, err := parseClock(endStr)
+	if err != nil {
+		return QuietHours{}, err
+	}
+	if start == end {
+		return QuietHours{}, fmt.Errorf("quiet hours must not be empty")
+	}
+
+	return QuietHours{Start: start, End: end}, nil
+}
+
+func (q QuietHours) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", q.Start/60, q.Start%60, q.End/60, q.End%60)
+}
+
+func (q QuietHours) Contains(t time.Time) bool {
+	minutes := t.Hour()*60 + t.Minute()
+	if q.Start < q.End {
+		return minutes >= q.Start && minutes < q.End
+	}
+
+	return minutes >= q.Start || minutes < q.End
+}
+
+// deferredDelivery reports when a reminder due at t is actually delivered
+// under the quiet hours q, and whether that differs from t.
+func deferredDelivery(q *QuietHours, t time.Time) (time.Time, bool) {
+	if q == nil || !q.Contains(t) {
+		return t, false
+	}
+
+	delivery := time.Date(t.Year(), t.Month(), t.Day(), q.End/60, q.End%60, 0, 0, t.Location())
+	if !delivery.After(t) {
+		delivery = delivery.AddDate(0, 0, 1)
+	}
+
+	return delivery, true
+}
+
+func chatQuietHours(chatID int64) *QuietHours {
+	if userData, exists := todoData[chatID]; exists {
+		return userData.Settings.QuietHours
+	}
+
+	return nil
+}
+
+func deferReminder(chatID int64, reminder Reminder, delivery time.Time, bot BotClient) {
+	key := reminderKey{ChatID: chatID, ReminderID: reminder.ID}
+	if !reminder.Recurring {
+		unscheduleReminder(chatID, reminder.ID)
+		reminderTimers[key] = time.AfterFunc(time.Until(delivery), locked(func() {
+			fireReminder(chatID, reminder, bot)
+		}))
+		return
+	}
+
+	// A recurring reminder keeps its cron entry; the held back fire gets a
+	// timer of its own.
+	if timer, exists := deferredFires[key]; exists {
+		timer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(delivery), locked(func() {
+		if deferredFires[key] == timer {
+			delete(deferredFires, key)
+		}
+		fireReminder(chatID, reminder, bot)
+	}))
+	deferredFires[key] = timer
+}
+
+func handleQuietHoursSetting(chatID int64, value string, bot BotClient) {
+	var quietHours *QuietHours
+	if value != "off" {
+		parsed, err := parseQuietHours(value)
+		if err != nil {
+			sendUsage(chatID, "quiet", bot)
+			return
+		}
+		quietHours = &parsed
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	todoData[chatID].Settings.QuietHours = quietHours
+
+	text := tr(chatID, "quiet.off")
+	if quietHours != nil {
+		text = tr(chatID, "quiet.on", quietHours)
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func handleDeferredList(chatID int64, bot BotClient) {
+	quietHours := chatQuietHours(chatID)
+	if quietHours == nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "quiet.not_set"))
+		send(bot, msg)
+		return
+	}
+
+	now := time.Now()
+	var list string
+	for _, reminder := range todoData[chatID].Reminders {
+		fireTime := reminder.Time
+		if reminder.Recurring {
+			next, err := nextFireTime(reminder, now)
+			if err != nil {
+				continue
+			}
+			fireTime = next
+		} else if !fireTime.After(now) {
+			continue
+		}
+
+		if delivery, deferred := deferredDelivery(quietHours, fireTime.In(chatLocation(chatID))); deferred {
+			list += fmt.Sprintf("• %s — %s → %s\n", reminder.DisplayTitle(), formatTime(chatID, fireTime), formatTime(chatID, delivery))
+		}
+	}
+
+	if list == "" {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "quiet.none_deferred"))
+		send(bot, msg)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "quiet.deferred", quietHours, list))
+	send(bot, msg)
+}
+
+// parseTestTime accepts either a full date/time or a bare HH:MM for today.
+func parseTestTime(s string, now time.Time) (time.Time, error) {
+	if minutes, err := parseClock(s); err == nil {
+		return time.Date(now.Year(), now.Month(), now.Day(), minutes/60, minutes%60, 0, 0, now.Location()), nil
+	}
+
+	return parseDateTime(s, now.Location())
+}
+
+func handleQuietTest(chatID int64, timeStr string, bot BotClient) {
+	quietHours := chatQuietHours(chatID)
+	if quietHours == nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "quiet.not_set"))
+		send(bot, msg)
+		return
+	}
+
+	fireTime, err := parseTestTime(timeStr, time.Now().In(chatLocation(chatID)))
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.time_format"))
+		send(bot, msg)
+		return
+	}
+
+	text := tr(chatID, "quiet.test_outside", formatTime(chatID, fireTime), quietHours)
+	if delivery, deferred := deferredDelivery(quietHours, fireTime); deferred {
+		text = tr(chatID, "quiet.test_inside", formatTime(chatID, fireTime), quietHours, formatTime(chatID, delivery))
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// A recurring reminder held back by quiet hours doesn't fire after it was
+// cancelled, whether through a command or found orphaned by /remindsync.
+func TestDeferredRecurringFireIsStopped(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+
+	tests := []struct {
+		name   string
+		cancel func(chatID int64)
+	}{
+		{"cancelled", func(chatID int64) {
+			removeReminders(chatID, func(Reminder) bool { return true })
+		}},
+		{"orphaned", func(chatID int64) {
+			todoData[chatID].Reminders = nil
+			reconcileReminders(time.Now(), bot)
+		}},
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chatID := int64(9401 + i)
+			reminder := Reminder{ID: 1, Content: "stand up", Recurring: true, Schedule: "0 10 * * *"}
+			todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{reminder}, NextReminderID: 1}
+
+			dataMu.Lock()
+			deferReminder(chatID, reminder, time.Now().Add(100*time.Millisecond), bot)
+			test.cancel(chatID)
+			_, tracked := deferredFires[reminderKey{ChatID: chatID, ReminderID: reminder.ID}]
+			dataMu.Unlock()
+			if tracked {
+				t.Error("deferred fire still tracked")
+			}
+
+			time.Sleep(300 * time.Millisecond)
+			if sent := fake.sent(chatID); len(sent) != 0 {
+				t.Errorf("sent %q after the reminder was cancelled", sent)
+			}
+		})
+	}
+}
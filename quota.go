@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const defaultUserQuota = 64 * 1024
+
+// Each pending reminder holds a timer, so the number per chat is capped on
+// top of the storage quota.
+const (
+	maxRemindersPerChat = 50
+	maxTodosPerChat     = 100
+)
+
+var userQuota = defaultUserQuota
+
+// errReminderLimit is returned by addReminder when the chat already has
+// maxRemindersPerChat reminders.
+var errReminderLimit = errors.New("reminder limit reached")
+
+// errTodoLimit is returned by addTodo when the chat already has
+// maxTodosPerChat todos.
+var errTodoLimit = errors.New("todo limit reached")
+
+// storageUsage approximates how many bytes of user-provided text a chat
+// keeps stored.
+func storageUsage(userData *UserData) int {
+	usage := 0
+	for _, todo := range userData.Todos {
+		usage += len(todo.Text)
+	}
+	for _, todos := range userData.Lists {
+		for _, todo := range todos {
+			usage += len(todo.Text)
+		}
+	}
+	for _, reminder := range userData.Reminders {
+		usage += len(reminder.Content)
+	}
+
+	return usage
+}
+
+// checkQuota reports whether the chat can store extra more bytes, telling
+// the user when it can't.
+func checkQuota(chatID int64, extra int, bot BotClient) bool {
+	usage := 0
+	if userData, exists := todoData[chatID]; exists {
+		usage = storageUsage(userData)
+	}
+	if usage+extra <= userQuota {
+		return true
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "quota.storage", userQuota/1024))
+	send(bot, msg)
+
+	return false
+}
+
+// checkReminderLimit tells the chat off if it can't add another reminder.
+// addReminder enforces the limit anyway; commands that ask for
+// confirmation or post something first check early as well.
+func checkReminderLimit(chatID int64, bot BotClient) bool {
+	if userData, exists := todoData[chatID]; !exists || len(userData.Reminders) < maxRemindersPerChat {
+		return true
+	}
+
+	sendReminderLimit(chatID, bot)
+
+	return false
+}
+
+func sendReminderLimit(chatID int64, bot BotClient) {
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "quota.reminders", maxRemindersPerChat))
+	send(bot, msg)
+}
+
+// todoCount counts the todos of the default list and all named lists.
+func todoCount(userData *UserData) int {
+	count := len(userData.Todos)
+	for _, todos := range userData.Lists {
+		count += len(todos)
+	}
+
+	return count
+}
+
+// checkTodoLimit tells the chat off if it can't add another todo. addTodo
+// enforces the limit anyway.
+func checkTodoLimit(chatID int64, bot BotClient) bool {
+	if userData, exists := todoData[chatID]; !exists || todoCount(userData) < maxTodosPerChat {
+		return true
+	}
+
+	sendTodoLimit(chatID, bot)
+
+	return false
+}
+
+func sendTodoLimit(chatID int64, bot BotClient) {
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "quota.todos", maxTodosPerChat))
+	send(bot, msg)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// fullChat gives chatID as many pending reminders as it may have.
+func fullChat(chatID int64) *UserData {
+	userData := &UserData{Todos: []Todo{}}
+	at := time.Now().Add(24 * time.Hour)
+	for i := 1; i <= maxRemindersPerChat; i++ {
+		userData.Reminders = append(userData.Reminders, Reminder{ID: i, Content: "busy", Time: at})
+	}
+	userData.NextReminderID = maxRemindersPerChat
+	todoData[chatID] = userData
+
+	return userData
+}
+
+func TestReminderLimitAppliesToEveryCommand(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	due := time.Now().Add(48 * time.Hour)
+
+	tests := []struct {
+		name    string
+		text    string
+		prepare func(userData *UserData)
+	}{
+		{"remind", "/remind 1h stretch", nil},
+		{"bulkremind", "/bulkremind\n1h water\n2h stretch", nil},
+		{"applytemplate", "/applytemplate morning", func(userData *UserData) {
+			userData.Templates = map[string][]TemplateItem{"morning": {{TimeStr: "1h", Content: "water"}}}
+		}},
+		{"countdown", "/countdown 2099-01-01T10:00 party", nil},
+		{"arm", "/arm", func(userData *UserData) {
+			userData.Todos = []Todo{{ID: 1, Text: "pay rent", Due: &due}}
+		}},
+		{"autoremind", "/set pay rent", func(userData *UserData) {
+			userData.Settings.AutoRemind = time.Hour
+		}},
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chatID := int64(4000 + i)
+			userData := fullChat(chatID)
+			if test.prepare != nil {
+				test.prepare(userData)
+			}
+
+			dispatch(command(chatID, test.text), bot)
+			if got := len(todoData[chatID].Reminders); got != maxRemindersPerChat {
+				t.Errorf("%d reminders after %q, want the limit of %d", got, test.text, maxRemindersPerChat)
+			}
+		})
+	}
+}
+
+// fileBot serves every file download from url.
+type fileBot struct {
+	*tgbotapi.BotAPI
+	url string
+}
+
+func (b fileBot) GetFileDirectURL(fileID string) (string, error) {
+	return b.url, nil
+}
+
+// Todos that come back on their own, not just typed ones, stop at the
+// limit.
+func TestTodoLimitAppliesToImportsAndRepeats(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	useTestData(t)
+	export := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"content": "water"}, {"content": "stretch"}, {"content": "read"}]`)
+	}))
+	t.Cleanup(export.Close)
+	bot := fileBot{BotAPI: fake.bot(), url: export.URL}
+
+	tests := []struct {
+		name string
+		room int
+		add  func(chatID int64)
+	}{
+		{"import", 2, func(chatID int64) {
+			handleImportTodos(chatID, &tgbotapi.Document{FileID: "export"}, bot)
+		}},
+		{"import into a full chat", 0, func(chatID int64) {
+			handleImportTodos(chatID, &tgbotapi.Document{FileID: "export"}, bot)
+		}},
+		{"repeat", 1, func(chatID int64) {
+			readdTodo(chatID, ScheduledTodo{Todo: Todo{Text: "water"}, ReaddAt: time.Now()}, bot)
+		}},
+		{"repeat into a full chat", 0, func(chatID int64) {
+			readdTodo(chatID, ScheduledTodo{Todo: Todo{Text: "water"}, ReaddAt: time.Now()}, bot)
+		}},
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chatID := int64(4100 + i)
+			userData := &UserData{Todos: []Todo{}}
+			for len(userData.Todos) < maxTodosPerChat-test.room {
+				userData.NextTodoID++
+				userData.Todos = append(userData.Todos, Todo{ID: userData.NextTodoID, Text: "busy"})
+			}
+			todoData[chatID] = userData
+
+			dataMu.Lock()
+			test.add(chatID)
+			dataMu.Unlock()
+			if got := todoCount(todoData[chatID]); got != maxTodosPerChat {
+				t.Errorf("%d todos, want the limit of %d", got, maxTodosPerChat)
+			}
+		})
+	}
+}
+
+// Snoozing a delivered one-off reminder from its button adds it back, so a
+// full chat can't take it.
+func TestSnoozeButtonRespectsReminderLimit(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 4200
+
+	userData := fullChat(chatID)
+	userData.History = []FiredReminder{{Reminder: Reminder{ID: maxRemindersPerChat + 1, Content: "stretch"}, FiredAt: time.Now()}}
+
+	dispatch(button(chatID, chatID, fmt.Sprintf("snooze:%d:10m", maxRemindersPerChat+1)), bot)
+	if got := len(todoData[chatID].Reminders); got != maxRemindersPerChat {
+		t.Errorf("%d reminders after snoozing, want the limit of %d", got, maxRemindersPerChat)
+	}
+}
@@ -0,0 +1,551 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/robfig/cron/v3"
+)
+
+// parseSchedule splits a recurring reminder into its cron spec and content.
+// The spec is either a descriptor ("@daily", "@every 2h") or the five
+// standard cron fields.
+func parseSchedule(args string) (string, string, error) {
+	var tz []string
+	if strings.HasPrefix(args, "CRON_TZ=") || strings.HasPrefix(args, "TZ=") {
+		tz, args = cutFields(args, 1)
+	}
+
+	if strings.HasPrefix(args, onStartSchedule+" ") && tz == nil {
+		_, content := cutFields(args, 1)
+		return onStartSchedule, content, nil
+	}
+
+	fieldCount := 5
+	if strings.HasPrefix(args, "@every") {
+		fieldCount = 2
+	} else if strings.HasPrefix(args, daysSchedulePrefix) {
+		fieldCount = 3
+	} else if strings.HasPrefix(args, "@") {
+		fieldCount = 1
+	}
+
+	fields, content := cutFields(args, fieldCount)
+	if len(fields) < fieldCount || content == "" {
+		return "", "", fmt.Errorf("missing schedule or content")
+	}
+
+	spec := strings.Join(append(tz, fields...), " ")
+	if _, err := parseCron(spec); err != nil {
+		return "", "", err
+	}
+
+	return spec, content, nil
+}
+
+// cronParser reads standard cron specs, with CRON_TZ= or TZ= zones loaded by
+// parseTimezone so that the fixed UTC offsets it makes work too.
+type cronParser struct{}
+
+func (cronParser) Parse(spec string) (cron.Schedule, error) {
+	return parseCron(spec)
+}
+
+func parseCron(spec string) (cron.Schedule, error) {
+	var loc *time.Location
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if rest, ok := strings.CutPrefix(spec, prefix); ok {
+			name, fields, _ := strings.Cut(rest, " ")
+			var err error
+			if loc, err = parseTimezone(name); err != nil {
+				return nil, fmt.Errorf("bad location %s: %v", name, err)
+			}
+			spec = strings.TrimSpace(fields)
+		}
+	}
+
+	if loc == nil {
+		loc = time.UTC
+	}
+	if args, ok := strings.CutPrefix(spec, daysSchedulePrefix+" "); ok {
+		return parseDaysSchedule(args, loc)
+	}
+
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, err
+	}
+	if spec, ok := schedule.(*cron.SpecSchedule); ok {
+		spec.Location = loc
+	}
+
+	return schedule, nil
+}
+
+// daysSchedulePrefix starts a "@days 2 2024-03-14T10:30" spec: every two
+// days at 10:30 local time, counted from March 14th. Plain cron can't say
+// "every other day", and "@every 48h" restarts its count whenever the bot
+// does and slides by an hour over DST changes.
+const daysSchedulePrefix = "@days"
+
+const daysAnchorLayout = "2006-01-02T15:04"
+
+type daysSchedule struct {
+	Days   int
+	Anchor time.Time
+}
+
+// daysSpec makes a spec that fires every days days from now, at now's time
+// of day.
+func daysSpec(days int, now time.Time) string {
+	return fmt.Sprintf("%s %d %s", daysSchedulePrefix, days, now.Format(daysAnchorLayout))
+}
+
+func parseDaysSchedule(args string, loc *time.Location) (cron.Schedule, error) {
+	fields := strings.Fields(args)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("expected %s <days> <start>", daysSchedulePrefix)
+	}
+	days, err := strconv.Atoi(fields[0])
+	if err != nil || days < 1 {
+		return nil, fmt.Errorf("invalid day count %q", fields[0])
+	}
+	anchor, err := time.ParseInLocation(daysAnchorLayout, fields[1], loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start %q", fields[1])
+	}
+
+	return daysSchedule{Days: days, Anchor: anchor}, nil
+}
+
+func (s daysSchedule) Next(t time.Time) time.Time {
+	t = t.In(s.Anchor.Location())
+	if t.Before(s.Anchor) {
+		return s.Anchor
+	}
+
+	// Whole periods since the anchor, give or take a DST hour.
+	n := int(t.Sub(s.Anchor)/(24*time.Hour)) / s.Days
+	next := s.Anchor.AddDate(0, 0, n*s.Days)
+	for !next.After(t) {
+		n++
+		next = s.Anchor.AddDate(0, 0, n*s.Days)
+	}
+
+	return next
+}
+
+// onStartSchedule marks a recurring reminder that fires each time the bot
+// starts instead of on a cron schedule.
+const onStartSchedule = "@onstart"
+
+func isOnStart(reminder Reminder) bool {
+	return reminder.Recurring && reminder.Schedule == onStartSchedule
+}
+
+// parseRecurringSpec accepts the friendly forms "every <duration>" and
+// "daily <HH:MM>" in addition to everything parseSchedule understands.
+func parseRecurringSpec(args string, now time.Time) (string, string, error) {
+	fields, content := cutFields(args, 2)
+	if len(fields) < 2 || (fields[0] != "every" && fields[0] != "daily") {
+		return parseSchedule(args)
+	}
+	if content == "" {
+		return "", "", fmt.Errorf("missing content")
+	}
+
+	if fields[0] == "every" {
+		interval, err := parseDuration(fields[1])
+		if err != nil || interval < time.Minute {
+			return "", "", fmt.Errorf("invalid interval %q", fields[1])
+		}
+		return everySpec(interval, now), content, nil
+	}
+
+	minutes, err := parseClock(fields[1])
+	if err != nil {
+		if minutes, err = parseClock12(fields[1]); err != nil {
+			return "", "", err
+		}
+	}
+
+	return fmt.Sprintf("%d %d * * *", minutes%60, minutes/60), content, nil
+}
+
+func cutFields(s string, n int) ([]string, string) {
+	var fields []string
+	for i := 0; i < n; i++ {
+		s = strings.TrimLeft(s, " ")
+		field, rest, _ := strings.Cut(s, " ")
+		if field == "" {
+			break
+		}
+		fields = append(fields, field)
+		s = rest
+	}
+
+	return fields, strings.TrimSpace(s)
+}
+
+// cutFlag takes an option like "--before 1h" out of args wherever it is
+// and returns the remaining args and the option's value, which is empty if
+// the option ends args.
+func cutFlag(args string, name string) (string, string, bool) {
+	fields := strings.Fields(args)
+	index := slices.Index(fields, name)
+	if index < 0 {
+		return args, "", false
+	}
+
+	var value string
+	if index+1 < len(fields) {
+		value = fields[index+1]
+	}
+	fields = slices.Delete(fields, index, min(index+2, len(fields)))
+
+	return strings.Join(fields, " "), value, true
+}
+
+// parseInterval turns a friendly interval such as "day", "hour" or
+// "2 weeks" followed by the reminder content into a cron spec. Days, weeks
+// and months stay aligned to the current local time of day, several days or
+// weeks counted from today; minutes and hours repeat every fixed duration.
+func parseInterval(args string, now time.Time) (string, string, error) {
+	fields, content := cutFields(args, 2)
+	if len(fields) == 0 {
+		return "", "", fmt.Errorf("missing interval")
+	}
+
+	count := 1
+	unit := fields[0]
+	if n, err := strconv.Atoi(fields[0]); err == nil {
+		if len(fields) < 2 || n < 1 {
+			return "", "", fmt.Errorf("invalid interval")
+		}
+		count = n
+		unit = fields[1]
+	} else {
+		_, content = cutFields(args, 1)
+	}
+	if content == "" {
+		return "", "", fmt.Errorf("missing content")
+	}
+
+	switch strings.TrimSuffix(unit, "s") {
+	case "minute", "min":
+		return fmt.Sprintf("@every %dm", count), content, nil
+	case "hour":
+		return fmt.Sprintf("@every %dh", count), content, nil
+	case "day":
+		if count == 1 {
+			return fmt.Sprintf("%d %d * * *", now.Minute(), now.Hour()), content, nil
+		}
+		return daysSpec(count, now), content, nil
+	case "week":
+		if count == 1 {
+			return fmt.Sprintf("%d %d * * %d", now.Minute(), now.Hour(), now.Weekday()), content, nil
+		}
+		return daysSpec(count*7, now), content, nil
+	case "month":
+		if count == 1 {
+			return fmt.Sprintf("%d %d %d * *", now.Minute(), now.Hour(), now.Day()), content, nil
+		}
+		if 12%count != 0 {
+			return "", "", fmt.Errorf("month interval must divide a year")
+		}
+		firstMonth := (int(now.Month())-1)%count + 1
+		return fmt.Sprintf("%d %d %d %d-12/%d *", now.Minute(), now.Hour(), now.Day(), firstMonth, count), content, nil
+	default:
+		return "", "", fmt.Errorf("unknown interval unit %q", unit)
+	}
+}
+
+// withCronTZ pins a cron spec to loc so that e.g. "0 9 * * *" means 9:00 in
+// the user's timezone rather than UTC. Specs that already name a timezone
+// and @every intervals are left alone.
+func withCronTZ(spec string, loc *time.Location) string {
+	if loc == time.UTC || strings.HasPrefix(spec, "@every") || spec == onStartSchedule ||
+		strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		return spec
+	}
+
+	return fmt.Sprintf("CRON_TZ=%s %s", loc, spec)
+}
+
+// cronTZ returns the zone a spec's CRON_TZ or TZ prefix names, or "" if it
+// has none.
+func cronTZ(spec string) string {
+	for _, prefix := range []string{"CRON_TZ=", "TZ="} {
+		if strings.HasPrefix(spec, prefix) {
+			zone, _, _ := strings.Cut(strings.TrimPrefix(spec, prefix), " ")
+			return zone
+		}
+	}
+
+	return ""
+}
+
+func stripCronTZ(spec string) string {
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		_, spec, _ = strings.Cut(spec, " ")
+	}
+
+	return spec
+}
+
+func nextFireTime(reminder Reminder, now time.Time) (time.Time, error) {
+	schedule, err := parseCron(reminder.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return schedule.Next(now.UTC()), nil
+}
+
+// everySpec turns a repeat interval into a cron spec. Whole days keep firing
+// at the current time of day; anything else repeats from now.
+func everySpec(interval time.Duration, now time.Time) string {
+	switch interval {
+	case 24 * time.Hour:
+		return fmt.Sprintf("%d %d * * *", now.Minute(), now.Hour())
+	case 7 * 24 * time.Hour:
+		return fmt.Sprintf("%d %d * * %d", now.Minute(), now.Hour(), now.Weekday())
+	}
+	if interval%(24*time.Hour) == 0 {
+		return daysSpec(int(interval/(24*time.Hour)), now)
+	}
+
+	return "@every " + interval.String()
+}
+
+// handleRemindRepeating handles "/remind every <duration> <message>" and
+// "/remind cron <spec> <message>".
+func handleRemindRepeating(chatID int64, kind string, args string, bot BotClient) {
+	var spec, content string
+	if kind == "cron" {
+		var err error
+		spec, content, err = parseSchedule(args)
+		if err != nil {
+			sendUsage(chatID, "remind", bot)
+			return
+		}
+	} else {
+		fields, rest := cutFields(args, 1)
+		if len(fields) == 0 || rest == "" {
+			sendUsage(chatID, "remind", bot)
+			return
+		}
+		interval, err := parseDuration(fields[0])
+		if err != nil || interval < time.Minute {
+			msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.time_format"))
+			send(bot, msg)
+			return
+		}
+		spec, content = everySpec(interval, time.Now().In(chatLocation(chatID))), rest
+	}
+
+	addRecurringReminder(chatID, Reminder{Content: content, Schedule: spec}, bot)
+}
+
+func handleRecurringReminder(chatID int64, args string, bot BotClient) {
+	spec, content, err := parseRecurringSpec(args, time.Now().In(chatLocation(chatID)))
+	if err != nil {
+		sendUsage(chatID, "recurring", bot)
+		return
+	}
+
+	addRecurringReminder(chatID, Reminder{Content: content, Schedule: spec}, bot)
+}
+
+func handleRemindEvery(chatID int64, args string, bot BotClient) {
+	spec, content, err := parseInterval(args, time.Now().In(chatLocation(chatID)))
+	if err != nil {
+		sendUsage(chatID, "remindevery", bot)
+		return
+	}
+
+	addRecurringReminder(chatID, Reminder{Content: content, Schedule: spec}, bot)
+}
+
+func addRecurringReminder(chatID int64, reminder Reminder, bot BotClient) {
+	if !checkQuota(chatID, len(reminder.Content), bot) {
+		return
+	}
+
+	reminder.Recurring = true
+	reminder.Schedule = withCronTZ(reminder.Schedule, chatLocation(chatID))
+	reminder, err := addReminder(chatID, reminder)
+	if err != nil {
+		sendReminderLimit(chatID, bot)
+		return
+	}
+	scheduleReminder(chatID, reminder, bot)
+
+	text := tr(chatID, "recurring.on_start")
+	if !isOnStart(reminder) {
+		next, _ := nextFireTime(reminder, time.Now())
+		text = tr(chatID, "recurring.set", formatTime(chatID, next))
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func handleRecurringList(chatID int64, bot BotClient) {
+	var list string
+	if userData, exists := todoData[chatID]; exists {
+		n := 0
+		for _, reminder := range userData.Reminders {
+			if !reminder.Recurring {
+				continue
+			}
+			n++
+
+			next, err := nextFireTime(reminder, time.Now())
+			if err != nil {
+				list += tr(chatID, "recurring.item_no_next", n, reminder.DisplayTitle(), reminder.Schedule) + "\n"
+				continue
+			}
+			list += tr(chatID, "recurring.item", n, reminder.DisplayTitle(), reminder.Schedule, formatTime(chatID, next)) + "\n"
+		}
+	}
+
+	if list == "" {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "recurring.empty"))
+		send(bot, msg)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "recurring.title", list))
+	send(bot, msg)
+}
+
+const (
+	defaultNextFires = 5
+	maxNextFires     = 50
+)
+
+type UpcomingFire struct {
+	Reminder Reminder
+	Time     time.Time
+}
+
+// upcomingFires merges the next fire times of all recurring reminders and
+// returns the first n of them in chronological order.
+func upcomingFires(reminders []Reminder, now time.Time, n int) []UpcomingFire {
+	var fires []UpcomingFire
+	for _, reminder := range reminders {
+		if !reminder.Recurring {
+			continue
+		}
+
+		schedule, err := parseCron(reminder.Schedule)
+		if err != nil {
+			continue
+		}
+
+		next := now.UTC()
+		for i := 0; i < n; i++ {
+			next = schedule.Next(next)
+			if next.IsZero() {
+				break
+			}
+			fires = append(fires, UpcomingFire{Reminder: reminder, Time: next})
+		}
+	}
+
+	sort.SliceStable(fires, func(i, j int) bool {
+		return fires[i].Time.Before(fires[j].Time)
+	})
+	if len(fires) > n {
+		fires = fires[:n]
+	}
+
+	return fires
+}
+
+func handleNextFires(chatID int64, countStr string, bot BotClient) {
+	count := defaultNextFires
+	if countStr != "" {
+		n, err := strconv.Atoi(countStr)
+		if err != nil || n < 1 || n > maxNextFires {
+			sendUsage(chatID, "nextfires", bot)
+			return
+		}
+		count = n
+	}
+
+	var fires []UpcomingFire
+	if userData, exists := todoData[chatID]; exists {
+		fires = upcomingFires(userData.Reminders, time.Now(), count)
+	}
+	if len(fires) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "recurring.empty"))
+		send(bot, msg)
+		return
+	}
+
+	var list string
+	for i, fire := range fires {
+		list += fmt.Sprintf("%d. %s — %s\n", i+1, formatTime(chatID, fire.Time), fire.Reminder.DisplayTitle())
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "recurring.next_fires", list))
+	send(bot, msg)
+}
+
+func handleClearRecurring(chatID int64, bot BotClient) {
+	removed := cancelReminders(chatID, func(reminder Reminder) bool {
+		return reminder.Recurring
+	})
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "recurring.cleared", removed))
+	send(bot, msg)
+
+	if removed > 0 {
+		if err := saveUserData(); err != nil {
+			slog.Error("Failed to save user data", "err", err)
+		}
+	}
+}
+
+// recurringReminderByIndex resolves the 1-based index shown by /recurring.
+func recurringReminderByIndex(chatID int64, indexStr string) (Reminder, bool) {
+	userData, exists := todoData[chatID]
+	index, err := strconv.Atoi(indexStr)
+	if !exists || err != nil || index < 1 {
+		return Reminder{}, false
+	}
+
+	n := 0
+	for _, reminder := range userData.Reminders {
+		if !reminder.Recurring {
+			continue
+		}
+		n++
+		if n == index {
+			return reminder, true
+		}
+	}
+
+	return Reminder{}, false
+}
+
+func handleCloneRecurring(chatID int64, indexStr string, content string, bot BotClient) {
+	original, ok := recurringReminderByIndex(chatID, indexStr)
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	addRecurringReminder(chatID, Reminder{Content: content, Schedule: original.Schedule}, bot)
+}
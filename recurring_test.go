@@ -0,0 +1,109 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseInterval(t *testing.T) {
+	now := time.Date(2024, time.March, 14, 10, 30, 0, 0, time.UTC) // a Thursday
+
+	tests := []struct {
+		args    string
+		spec    string
+		content string
+	}{
+		{"day water the plants", "30 10 * * *", "water the plants"},
+		{"days water", "30 10 * * *", "water"},
+		{"hour stretch", "@every 1h", "stretch"},
+		{"3 hours stretch", "@every 3h", "stretch"},
+		{"15 minutes blink", "@every 15m", "blink"},
+		{"15 min blink", "@every 15m", "blink"},
+		{"week report", "30 10 * * 4", "report"},
+		{"month rent", "30 10 14 * *", "rent"},
+		{"3 months review", "30 10 14 3-12/3 *", "review"},
+		{"6 months dentist", "30 10 14 3-12/6 *", "dentist"},
+		{"2 days water", "@days 2 2024-03-14T10:30", "water"},
+		{"3 weeks haircut", "@days 21 2024-03-14T10:30", "haircut"},
+	}
+	for _, test := range tests {
+		spec, content, err := parseInterval(test.args, now)
+		if err != nil {
+			t.Errorf("parseInterval(%q): %v", test.args, err)
+			continue
+		}
+		if spec != test.spec || content != test.content {
+			t.Errorf("parseInterval(%q) = %q, %q; want %q, %q", test.args, spec, content, test.spec, test.content)
+		}
+	}
+}
+
+func TestParseIntervalRejects(t *testing.T) {
+	now := time.Date(2024, time.March, 14, 10, 30, 0, 0, time.UTC)
+
+	for _, args := range []string{"", "days", "2", "0 days water", "-1 days water", "5 months rent", "fortnight water"} {
+		if spec, _, err := parseInterval(args, now); err == nil {
+			t.Errorf("parseInterval(%q) = %q, want an error", args, spec)
+		}
+	}
+}
+
+// "Every N days" counts from the day it was set, at the same local time,
+// whenever the bot happens to start and across DST changes.
+func TestDaysSchedule(t *testing.T) {
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	if err != nil {
+		t.Skip(err)
+	}
+	set := time.Date(2024, time.March, 28, 9, 0, 0, 0, kyiv) // DST starts on the 31st
+	schedule, err := parseCron(withCronTZ(daysSpec(2, set), kyiv))
+	if err != nil {
+		t.Fatalf("parseCron: %v", err)
+	}
+
+	tests := []struct {
+		from time.Time
+		want time.Time
+	}{
+		{set, time.Date(2024, time.March, 30, 9, 0, 0, 0, kyiv)},
+		{set.Add(-time.Hour), set},
+		{time.Date(2024, time.March, 30, 9, 0, 0, 0, kyiv), time.Date(2024, time.April, 1, 9, 0, 0, 0, kyiv)},
+		{time.Date(2024, time.March, 31, 12, 0, 0, 0, time.UTC), time.Date(2024, time.April, 1, 9, 0, 0, 0, kyiv)},
+		{time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, time.June, 2, 9, 0, 0, 0, kyiv)},
+	}
+	for _, test := range tests {
+		if got := schedule.Next(test.from); !got.Equal(test.want) {
+			t.Errorf("Next(%v) = %v, want %v", test.from, got, test.want)
+		}
+	}
+}
+
+func TestUpcomingFires(t *testing.T) {
+	now := time.Date(2024, time.March, 14, 8, 0, 0, 0, time.UTC) // a Thursday
+	reminders := []Reminder{
+		{ID: 1, Content: "stretch", Recurring: true, Schedule: "0 9 * * *"},
+		{ID: 2, Content: "report", Recurring: true, Schedule: "30 10 * * 4"},
+		{ID: 3, Content: "dentist", Time: now.Add(time.Hour)},
+		{ID: 4, Content: "broken", Recurring: true, Schedule: "every now and then"},
+	}
+
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{1, []string{"03-14 09:00 stretch"}},
+		{4, []string{"03-14 09:00 stretch", "03-14 10:30 report", "03-15 09:00 stretch", "03-16 09:00 stretch"}},
+		{9, []string{"03-14 09:00 stretch", "03-14 10:30 report", "03-15 09:00 stretch", "03-16 09:00 stretch",
+			"03-17 09:00 stretch", "03-18 09:00 stretch", "03-19 09:00 stretch", "03-20 09:00 stretch", "03-21 09:00 stretch"}},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, fire := range upcomingFires(reminders, now, test.n) {
+			got = append(got, fire.Time.Format("01-02 15:04")+" "+fire.Reminder.Content)
+		}
+		if strings.Join(got, ", ") != strings.Join(test.want, ", ") {
+			t.Errorf("upcomingFires(%d) = %q, want %q", test.n, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,663 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/robfig/cron/v3"
+)
+
+type reminderKey struct {
+	ChatID     int64
+	ReminderID int
+}
+
+const snoozeWarningThreshold = 5
+
+var urlPattern = regexp.MustCompile(`https?://\S+`)
+
+// Hashtags in a reminder's text become its labels.
+var labelPattern = regexp.MustCompile(`#([\p{L}\p{N}_]+)`)
+
+// splitTitle separates a short title from the reminder text written as
+// "title | body". Text without a separator has no title.
+func splitTitle(content string) (string, string) {
+	title, body, found := strings.Cut(content, "|")
+	title, body = strings.TrimSpace(title), strings.TrimSpace(body)
+	if !found || title == "" || body == "" {
+		return "", content
+	}
+
+	return title, body
+}
+
+// setReminderText replaces the reminder's text along with everything derived
+// from it.
+func setReminderText(reminder *Reminder, text string) {
+	reminder.Title, reminder.Content = splitTitle(text)
+	reminder.URL = urlPattern.FindString(text)
+	reminder.Labels = parseLabels(text)
+}
+
+// DisplayTitle is what listings show for the reminder: its title if it has
+// one, otherwise the full text.
+func (r Reminder) DisplayTitle() string {
+	if r.Title != "" {
+		return r.Title
+	}
+
+	return r.Content
+}
+
+var reminderTimers = make(map[reminderKey]*time.Timer)
+var reminderEntries = make(map[reminderKey]cron.EntryID)
+
+// addReminder stores a new reminder under the chat's next ID. Every way of
+// creating a reminder goes through here, so this is where the per-chat
+// limit is enforced.
+func addReminder(chatID int64, reminder Reminder) (Reminder, error) {
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	userData := todoData[chatID]
+	if len(userData.Reminders) >= maxRemindersPerChat {
+		return Reminder{}, errReminderLimit
+	}
+
+	userData.NextReminderID++
+	reminder.ID = userData.NextReminderID
+	if reminder.Title == "" {
+		reminder.Title, reminder.Content = splitTitle(reminder.Content)
+	}
+	reminder.URL = urlPattern.FindString(reminder.Content)
+	reminder.Labels = parseLabels(reminder.Content)
+	userData.Reminders = append(userData.Reminders, reminder)
+
+	return reminder, nil
+}
+
+func findReminder(chatID int64, id int) (Reminder, bool) {
+	userData, exists := todoData[chatID]
+	if !exists {
+		return Reminder{}, false
+	}
+
+	for _, reminder := range userData.Reminders {
+		if reminder.ID == id {
+			return reminder, true
+		}
+	}
+
+	return Reminder{}, false
+}
+
+func unscheduleReminder(chatID int64, id int) {
+	key := reminderKey{ChatID: chatID, ReminderID: id}
+
+	if timer, exists := reminderTimers[key]; exists {
+		timer.Stop()
+		delete(reminderTimers, key)
+	}
+	if entryID, exists := reminderEntries[key]; exists {
+		reminderScheduler.Remove(entryID)
+		delete(reminderEntries, key)
+	}
+	if timer, exists := deferredFires[key]; exists {
+		timer.Stop()
+		delete(deferredFires, key)
+	}
+	unscheduleHeadsUps(key)
+}
+
+// pendingReminders returns the chat's one-shot reminders that have not fired
+// yet, in chronological order. User-facing reminder indexes refer to it.
+func pendingReminders(chatID int64) []Reminder {
+	userData, exists := todoData[chatID]
+	if !exists {
+		return nil
+	}
+
+	now := time.Now()
+	var pending []Reminder
+	for _, reminder := range userData.Reminders {
+		if !reminder.Recurring && reminder.Time.After(now) {
+			pending = append(pending, reminder)
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].Time.Before(pending[j].Time)
+	})
+
+	return pending
+}
+
+func handleReminderList(chatID int64, bot BotClient) {
+	pending := pendingReminders(chatID)
+	if len(pending) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "reminders.empty"))
+		send(bot, msg)
+		return
+	}
+
+	var list string
+	for i, reminder := range pending {
+		list += fmt.Sprintf("%d. %s — %s", i+1, reminder.DisplayTitle(), formatTime(chatID, reminder.Time))
+		if reminder.Category != "" {
+			list += fmt.Sprintf(" [%s]", reminder.Category)
+		}
+		if len(reminder.Channels) > 0 {
+			list += " " + tr(chatID, "notify.listed", strings.Join(reminder.Channels, ", "))
+		}
+		if len(reminder.Before) > 0 {
+			list += "\n   " + tr(chatID, "headsup.listed", formatLeads(reminder.Before))
+		}
+		list += "\n"
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "reminders.title", list))
+	send(bot, msg)
+}
+
+func pendingReminderByIndex(chatID int64, indexStr string) (Reminder, bool) {
+	pending := pendingReminders(chatID)
+	index, err := strconv.Atoi(indexStr)
+	if err != nil || index < 1 || index > len(pending) {
+		return Reminder{}, false
+	}
+
+	return pending[index-1], true
+}
+
+func rescheduleReminder(chatID int64, id int, newTime time.Time, bot BotClient) {
+	userData := todoData[chatID]
+	for i := range userData.Reminders {
+		if userData.Reminders[i].ID == id {
+			unscheduleReminder(chatID, id)
+			userData.Reminders[i].Time = newTime
+			scheduleReminder(chatID, userData.Reminders[i], bot)
+			return
+		}
+	}
+}
+
+// removeReminders deletes every reminder of the chat matching the predicate,
+// stops its timer and returns how many were removed.
+func removeReminders(chatID int64, match func(Reminder) bool) int {
+	userData, exists := todoData[chatID]
+	if !exists {
+		return 0
+	}
+
+	kept := userData.Reminders[:0]
+	removed := 0
+	for _, reminder := range userData.Reminders {
+		if match(reminder) {
+			unscheduleReminder(chatID, reminder.ID)
+			unlinkReminder(userData, reminder)
+			removed++
+			continue
+		}
+		kept = append(kept, reminder)
+	}
+	userData.Reminders = kept
+
+	return removed
+}
+
+// parseDateBound parses the boundary of a date range. A bare date used as
+// the upper bound covers the whole day.
+func parseDateBound(s string, upper bool, loc *time.Location) (time.Time, error) {
+	t, err := parseDateTime(s, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if upper && len(s) == len("2006-01-02") {
+		t = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	return t, nil
+}
+
+func handleCancelBetween(chatID int64, fromStr string, toStr string, bot BotClient) {
+	from, err := parseDateBound(fromStr, false, chatLocation(chatID))
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.date_format"))
+		send(bot, msg)
+		return
+	}
+	to, err := parseDateBound(toStr, true, chatLocation(chatID))
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.date_format"))
+		send(bot, msg)
+		return
+	}
+	if to.Before(from) {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "reminders.bad_range"))
+		send(bot, msg)
+		return
+	}
+
+	now := time.Now()
+	removed := cancelReminders(chatID, func(reminder Reminder) bool {
+		return !reminder.Recurring && reminder.Time.After(now) &&
+			!reminder.Time.Before(from) && !reminder.Time.After(to)
+	})
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "reminders.cancelled", removed))
+	send(bot, msg)
+
+	if removed > 0 {
+		if err := saveUserData(); err != nil {
+			slog.Error("Failed to save user data", "err", err)
+		}
+	}
+}
+
+func handleSnoozeUntil(chatID int64, indexStr string, dateTimeStr string, bot BotClient) {
+	reminder, ok := pendingReminderByIndex(chatID, indexStr)
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	newTime, err := parseDateTime(dateTimeStr, chatLocation(chatID))
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.date_format"))
+		send(bot, msg)
+		return
+	}
+	if !newTime.After(time.Now()) {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.date_passed"))
+		send(bot, msg)
+		return
+	}
+
+	snoozeReminder(chatID, reminder, newTime, bot)
+}
+
+func handleSnooze(chatID int64, indexStr string, timeStr string, bot BotClient) {
+	reminder, ok := pendingReminderByIndex(chatID, indexStr)
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	newTime, err := addDuration(reminder.Time, timeStr)
+	if err != nil || !newTime.After(reminder.Time) {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.time_format"))
+		send(bot, msg)
+		return
+	}
+
+	snoozeReminder(chatID, reminder, newTime, bot)
+}
+
+// snoozeReminder moves a reminder to newTime and counts the snooze, nudging
+// the user to reschedule properly once it has been put off too many times.
+func snoozeReminder(chatID int64, reminder Reminder, newTime time.Time, bot BotClient) {
+	rescheduleReminder(chatID, reminder.ID, newTime, bot)
+
+	snoozeCount := 0
+	userData := todoData[chatID]
+	for i := range userData.Reminders {
+		if userData.Reminders[i].ID == reminder.ID {
+			userData.Reminders[i].SnoozeCount++
+			snoozeCount = userData.Reminders[i].SnoozeCount
+			break
+		}
+	}
+
+	text := tr(chatID, "reminder.snoozed", reminder.DisplayTitle(), formatTime(chatID, newTime))
+	if snoozeCount >= snoozeWarningThreshold {
+		text += "\n\n" + tr(chatID, "reminder.snoozed_often", snoozeCount)
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func handleCancelBefore(chatID int64, cutoffStr string, bot BotClient) {
+	cutoff, err := parseDateBound(cutoffStr, false, chatLocation(chatID))
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.date_format"))
+		send(bot, msg)
+		return
+	}
+
+	now := time.Now()
+	removed := cancelReminders(chatID, func(reminder Reminder) bool {
+		return !reminder.Recurring && reminder.Time.After(now) && reminder.Time.Before(cutoff)
+	})
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "reminders.cancelled", removed))
+	send(bot, msg)
+
+	if removed > 0 {
+		if err := saveUserData(); err != nil {
+			slog.Error("Failed to save user data", "err", err)
+		}
+	}
+}
+
+func handleAgain(chatID int64, userID int64, indexStr string, timeStr string, bot BotClient) {
+	original, ok := pendingReminderByIndex(chatID, indexStr)
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	copied := original
+	copied.ID = 0
+	copied.SnoozeCount = 0
+	copied.CountdownMessageID = 0
+
+	handleReminder(chatID, userID, timeStr, copied, bot)
+}
+
+// Reminders with the same content firing within this window of each other
+// count as duplicates.
+const duplicateWindow = time.Minute
+
+func isDuplicateReminder(a Reminder, b Reminder) bool {
+	if a.Content != b.Content || a.Recurring != b.Recurring {
+		return false
+	}
+	if a.Recurring {
+		return a.Schedule == b.Schedule
+	}
+
+	diff := a.Time.Sub(b.Time)
+	return diff > -duplicateWindow && diff < duplicateWindow
+}
+
+func handleDedupe(chatID int64, bot BotClient) {
+	var kept []Reminder
+	duplicates := make(map[int]bool)
+	for _, reminder := range pendingAndRecurringReminders(chatID) {
+		duplicate := false
+		for _, other := range kept {
+			if isDuplicateReminder(reminder, other) {
+				duplicate = true
+				break
+			}
+		}
+
+		if duplicate {
+			duplicates[reminder.ID] = true
+		} else {
+			kept = append(kept, reminder)
+		}
+	}
+
+	removed := cancelReminders(chatID, func(reminder Reminder) bool {
+		return duplicates[reminder.ID]
+	})
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "reminders.deduped", removed))
+	send(bot, msg)
+
+	if removed > 0 {
+		if err := saveUserData(); err != nil {
+			slog.Error("Failed to save user data", "err", err)
+		}
+	}
+}
+
+// pendingAndRecurringReminders returns the chat's pending one-shot reminders
+// in chronological order followed by its recurring ones.
+func pendingAndRecurringReminders(chatID int64) []Reminder {
+	reminders := pendingReminders(chatID)
+	if userData, exists := todoData[chatID]; exists {
+		for _, reminder := range userData.Reminders {
+			if reminder.Recurring {
+				reminders = append(reminders, reminder)
+			}
+		}
+	}
+
+	return reminders
+}
+
+// handleRemindExpire sets a reminder that is dropped if it can't be
+// delivered within the window after its time, e.g. because of quiet hours
+// or downtime. "today" keeps it valid until the end of that day.
+func handleRemindExpire(chatID int64, userID int64, timeStr string, window string, reminder Reminder, bot BotClient) {
+	fireTime, err := parseReminderTime(chatID, timeStr, time.Now())
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.time_format"))
+		send(bot, msg)
+		return
+	}
+
+	var expiresAt time.Time
+	if window == "today" {
+		expiresAt = time.Date(fireTime.Year(), fireTime.Month(), fireTime.Day(), 0, 0, 0, 0, fireTime.Location()).AddDate(0, 0, 1)
+	} else {
+		duration, err := parseDuration(window)
+		if err != nil || duration <= 0 {
+			sendUsage(chatID, "remindexpire", bot)
+			return
+		}
+		expiresAt = fireTime.Add(duration)
+	}
+	reminder.ExpiresAt = &expiresAt
+
+	handleReminder(chatID, userID, timeStr, reminder, bot)
+}
+
+var priorities = map[string]int{
+	"high":   1,
+	"normal": 0,
+	"low":    -1,
+}
+
+// sortForDelivery orders reminders that are due at the same time by
+// priority, highest first, and then by their scheduled time.
+func sortForDelivery(reminders []Reminder) {
+	sort.SliceStable(reminders, func(i, j int) bool {
+		if reminders[i].Priority != reminders[j].Priority {
+			return reminders[i].Priority > reminders[j].Priority
+		}
+		return reminders[i].Time.Before(reminders[j].Time)
+	})
+}
+
+func handlePriority(chatID int64, indexStr string, priorityStr string, bot BotClient) {
+	priority, ok := priorities[priorityStr]
+	if !ok {
+		sendUsage(chatID, "priority", bot)
+		return
+	}
+
+	reminder, ok := pendingReminderByIndex(chatID, indexStr)
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	userData := todoData[chatID]
+	for i := range userData.Reminders {
+		if userData.Reminders[i].ID == reminder.ID {
+			userData.Reminders[i].Priority = priority
+		}
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "reminder.priority", reminder.Content, priorityStr))
+	send(bot, msg)
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func parseLabels(content string) []string {
+	var labels []string
+	for _, match := range labelPattern.FindAllStringSubmatch(content, -1) {
+		labels = append(labels, strings.ToLower(match[1]))
+	}
+
+	return labels
+}
+
+func hasLabel(reminder Reminder, label string) bool {
+	for _, l := range reminder.Labels {
+		if l == label {
+			return true
+		}
+	}
+
+	return false
+}
+
+func handleCancelLabel(chatID int64, label string, bot BotClient) {
+	label = strings.ToLower(strings.TrimPrefix(label, "#"))
+	removed := cancelReminders(chatID, func(reminder Reminder) bool {
+		return hasLabel(reminder, label)
+	})
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "reminders.cancelled_label", label, removed))
+	send(bot, msg)
+
+	if removed > 0 {
+		if err := saveUserData(); err != nil {
+			slog.Error("Failed to save user data", "err", err)
+		}
+	}
+}
+
+// globPattern turns a glob where * matches any run of characters and ? a
+// single one into a case-insensitive regexp matching the whole text.
+func globPattern(glob string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("(?is)^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+
+	return regexp.MustCompile(b.String())
+}
+
+func handleCancelMatch(chatID int64, glob string, bot BotClient) {
+	pattern := globPattern(glob)
+	removed := cancelReminders(chatID, func(reminder Reminder) bool {
+		return pattern.MatchString(reminder.Content) || (reminder.Title != "" && pattern.MatchString(reminder.Title))
+	})
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "reminders.cancelled_match", glob, removed))
+	send(bot, msg)
+
+	if removed > 0 {
+		if err := saveUserData(); err != nil {
+			slog.Error("Failed to save user data", "err", err)
+		}
+	}
+}
+
+func handleRecategorize(chatID int64, indexStr string, category string, bot BotClient) {
+	reminder, ok := pendingReminderByIndex(chatID, indexStr)
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	if category == "none" {
+		category = ""
+	}
+	userData := todoData[chatID]
+	for i := range userData.Reminders {
+		if userData.Reminders[i].ID == reminder.ID {
+			userData.Reminders[i].Category = category
+		}
+	}
+
+	text := tr(chatID, "reminder.category", reminder.Content, category)
+	if category == "" {
+		text = tr(chatID, "reminder.category_cleared", reminder.Content)
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func handleCancel(chatID int64, indexStr string, bot BotClient) {
+	reminder, ok := pendingReminderByIndex(chatID, indexStr)
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	cancelReminders(chatID, func(r Reminder) bool { return r.ID == reminder.ID })
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "reminder.cancelled", reminder.DisplayTitle()))
+	send(bot, msg)
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+// handleEditReminder changes a pending reminder's time if value parses as
+// one, and its text otherwise.
+func handleEditReminder(chatID int64, indexStr string, value string, bot BotClient) {
+	reminder, ok := pendingReminderByIndex(chatID, indexStr)
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	now := time.Now()
+	if newTime, err := parseReminderTime(chatID, value, now); err == nil {
+		if !newTime.After(now) {
+			msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.time_passed"))
+			send(bot, msg)
+			return
+		}
+		rescheduleReminder(chatID, reminder.ID, newTime, bot)
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "reminder.snoozed", reminder.DisplayTitle(), formatTime(chatID, newTime))))
+	} else {
+		if !checkQuota(chatID, len(value)-len(reminder.Content), bot) {
+			return
+		}
+		userData := todoData[chatID]
+		for i := range userData.Reminders {
+			if userData.Reminders[i].ID == reminder.ID {
+				setReminderText(&userData.Reminders[i], value)
+				break
+			}
+		}
+		// The timer holds a copy of the reminder, so it has to be
+		// rescheduled to deliver the new text.
+		rescheduleReminder(chatID, reminder.ID, reminder.Time, bot)
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "reminder.text_changed", value)))
+	}
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
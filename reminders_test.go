@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Editing a reminder's text changes what is delivered, not just what is
+// listed.
+func TestEditReminderTextIsDelivered(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 1101
+
+	dataMu.Lock()
+	reminder, err := addReminder(chatID, Reminder{Content: "buy milk", Time: time.Now().Add(300 * time.Millisecond)})
+	if err == nil {
+		scheduleReminder(chatID, reminder, bot)
+	}
+	dataMu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dispatch(command(chatID, "/editreminder 1 buy oat milk"), bot)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !allFired(map[int64][]string{chatID: nil}) {
+		if time.Now().After(deadline) {
+			t.Fatal("reminder never fired")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	sent := fake.sent(chatID)
+	delivered := sent[len(sent)-1]
+	if !strings.Contains(delivered, "buy oat milk") {
+		t.Errorf("delivered %q, want the edited text", delivered)
+	}
+	for _, text := range sent {
+		if strings.Contains(text, "buy milk") {
+			t.Errorf("old text was delivered: %q", text)
+		}
+	}
+}
+
+func TestCancelBetween(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+
+	day := func(d, h, m int) time.Time { return time.Date(2099, time.March, d, h, m, 0, 0, time.UTC) }
+	reminders := []Reminder{
+		{ID: 1, Content: "midnight", Time: day(1, 0, 0)},
+		{ID: 2, Content: "noon", Time: day(1, 12, 0)},
+		{ID: 3, Content: "late", Time: day(1, 23, 59)},
+		{ID: 4, Content: "next day", Time: day(2, 0, 0)},
+		{ID: 5, Content: "daily", Recurring: true, Schedule: "0 9 * * *"},
+	}
+
+	tests := []struct {
+		name     string
+		from, to string
+		left     string
+	}{
+		{"whole day", "2099-03-01", "2099-03-01", "[next day daily]"},
+		{"inclusive times", "2099-03-01T12:00", "2099-03-02T00:00", "[midnight daily]"},
+		{"single instant", "2099-03-01T23:59", "2099-03-01T23:59", "[midnight noon next day daily]"},
+		{"empty range", "2099-04-01", "2099-04-30", "[midnight noon late next day daily]"},
+		{"reversed", "2099-03-02", "2099-03-01", "[midnight noon late next day daily]"},
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chatID := int64(9801 + i)
+			todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: append([]Reminder(nil), reminders...), NextReminderID: len(reminders)}
+
+			dispatch(command(chatID, "/cancelbetween "+test.from+" "+test.to), bot)
+			var left []string
+			for _, reminder := range todoData[chatID].Reminders {
+				left = append(left, reminder.Content)
+			}
+			if got := fmt.Sprint(left); got != test.left {
+				t.Errorf("left %s, want %s", got, test.left)
+			}
+		})
+	}
+}
+
+// /snoozeuntil moves a reminder to a future date in the chat's timezone
+// and refuses anything else.
+func TestSnoozeUntil(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	if err != nil {
+		t.Skip(err)
+	}
+	due := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		args    string
+		want    time.Time
+		snoozes int
+	}{
+		{"1 2099-05-01T10:00", time.Date(2099, time.May, 1, 10, 0, 0, 0, kyiv), 1},
+		{"1 2099-05-01", time.Date(2099, time.May, 1, 0, 0, 0, 0, kyiv), 1},
+		{"1 2000-01-01T10:00", due, 0},
+		{"1 tomorrow", due, 0},
+		{"2 2099-05-01T10:00", due, 0},
+	}
+	for i, test := range tests {
+		chatID := int64(9901 + i)
+		todoData[chatID] = &UserData{
+			Todos:          []Todo{},
+			Reminders:      []Reminder{{ID: 1, Content: "call mom", Time: due}},
+			NextReminderID: 1,
+			Settings:       Settings{Timezone: "Europe/Kyiv"},
+		}
+
+		dispatch(command(chatID, "/snoozeuntil "+test.args), bot)
+		reminder := todoData[chatID].Reminders[0]
+		if !reminder.Time.Equal(test.want) || reminder.SnoozeCount != test.snoozes {
+			t.Errorf("/snoozeuntil %s: reminder at %v snoozed %d times, want %v and %d", test.args, reminder.Time, reminder.SnoozeCount, test.want, test.snoozes)
+		}
+	}
+}
+
+// From the snoozeWarningThreshold-th snooze on, the confirmation suggests
+// rescheduling instead.
+func TestSnoozeWarning(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+
+	tests := []struct {
+		snoozed int
+		warned  bool
+	}{
+		{0, false},
+		{snoozeWarningThreshold - 2, false},
+		{snoozeWarningThreshold - 1, true},
+		{snoozeWarningThreshold + 3, true},
+	}
+	for i, test := range tests {
+		chatID := int64(10201 + i)
+		todoData[chatID] = &UserData{
+			Todos:          []Todo{},
+			Reminders:      []Reminder{{ID: 1, Content: "call mom", Time: time.Now().Add(time.Hour), SnoozeCount: test.snoozed}},
+			NextReminderID: 1,
+			Settings:       Settings{Language: "en"},
+		}
+
+		dispatch(command(chatID, "/snooze 1 10m"), bot)
+		sent := fake.sent(chatID)
+		if len(sent) != 1 {
+			t.Fatalf("sent %q, want one confirmation", sent)
+		}
+		warning := fmt.Sprintf("snoozed this reminder %d times", test.snoozed+1)
+		if warned := strings.Contains(sent[0], warning); warned != test.warned {
+			t.Errorf("snooze number %d answered %q, want warning %v", test.snoozed+1, sent[0], test.warned)
+		}
+	}
+}
+
+func TestIsDuplicateReminder(t *testing.T) {
+	at := time.Date(2024, time.March, 14, 9, 0, 0, 0, time.UTC)
+	water := Reminder{Content: "water", Time: at}
+	daily := Reminder{Content: "water", Recurring: true, Schedule: "0 9 * * *"}
+
+	tests := []struct {
+		name string
+		a, b Reminder
+		want bool
+	}{
+		{"exact", Reminder{Content: "water", Time: at}, water, true},
+		{"seconds apart", Reminder{Content: "water", Time: at.Add(59 * time.Second)}, water, true},
+		{"seconds before", Reminder{Content: "water", Time: at.Add(-30 * time.Second)}, water, true},
+		{"a minute apart", Reminder{Content: "water", Time: at.Add(time.Minute)}, water, false},
+		{"other text", Reminder{Content: "Water", Time: at}, water, false},
+		{"same schedule", Reminder{Content: "water", Recurring: true, Schedule: "0 9 * * *"}, daily, true},
+		{"other schedule", Reminder{Content: "water", Recurring: true, Schedule: "0 10 * * *"}, daily, false},
+		{"recurring and one-shot", water, daily, false},
+	}
+	for _, test := range tests {
+		if got := isDuplicateReminder(test.a, test.b); got != test.want {
+			t.Errorf("%s: isDuplicateReminder = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+// /dedupe keeps the first of each group of duplicates.
+func TestDedupe(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 10401
+
+	at := time.Now().Add(time.Hour).Truncate(time.Minute)
+	todoData[chatID] = &UserData{
+		Todos: []Todo{},
+		Reminders: []Reminder{
+			{ID: 1, Content: "water", Time: at.Add(20 * time.Second)},
+			{ID: 2, Content: "water", Time: at},
+			{ID: 3, Content: "stretch", Time: at},
+			{ID: 4, Content: "water", Time: at.Add(2 * time.Minute)},
+			{ID: 5, Content: "standup", Recurring: true, Schedule: "0 10 * * 1-5"},
+			{ID: 6, Content: "standup", Recurring: true, Schedule: "0 10 * * 1-5"},
+		},
+		NextReminderID: 6,
+	}
+
+	dispatch(command(chatID, "/dedupe"), bot)
+	var ids []int
+	for _, reminder := range todoData[chatID].Reminders {
+		ids = append(ids, reminder.ID)
+	}
+	if fmt.Sprint(ids) != "[2 3 4 5]" {
+		t.Errorf("kept reminders %v, want [2 3 4 5]", ids)
+	}
+}
+
+func TestGlobPattern(t *testing.T) {
+	tests := []struct {
+		glob, text string
+		want       bool
+	}{
+		{"water*", "water the plants", true},
+		{"water*", "Water the plants", true},
+		{"water*", "drink water", false},
+		{"*call*", "call mom", true},
+		{"*call*", "recall the\nmeeting", true},
+		{"call ?om", "call mom", true},
+		{"call ?om", "call from", false},
+		{"1+1*", "1+1=2", true},
+		{"1+1*", "11=2", false},
+		{"(a)", "(a)", true},
+		{"(a)", "a", false},
+	}
+	for _, test := range tests {
+		if got := globPattern(test.glob).MatchString(test.text); got != test.want {
+			t.Errorf("glob %q on %q = %v, want %v", test.glob, test.text, got, test.want)
+		}
+	}
+}
+
+// /cancelmatch cancels the reminders whose content or title matches.
+func TestCancelMatch(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 10831
+
+	at := time.Now().Add(time.Hour)
+	todoData[chatID] = &UserData{
+		Todos: []Todo{},
+		Reminders: []Reminder{
+			{ID: 1, Content: "water the plants", Time: at},
+			{ID: 2, Content: "drink water", Time: at},
+			{ID: 3, Content: "long note about the garden", Title: "Water roses", Time: at},
+			{ID: 4, Content: "call mom", Time: at},
+		},
+		NextReminderID: 4,
+		Settings:       Settings{Language: "en"},
+	}
+
+	dispatch(command(chatID, "/cancelmatch water*"), bot)
+	var ids []int
+	for _, reminder := range todoData[chatID].Reminders {
+		ids = append(ids, reminder.ID)
+	}
+	if fmt.Sprint(ids) != "[2 4]" {
+		t.Errorf("kept reminders %v, want [2 4]", ids)
+	}
+	if len(todoData[chatID].Deleted) != 2 {
+		t.Errorf("cancelled %d reminders, want 2 kept for /undo", len(todoData[chatID].Deleted))
+	}
+}
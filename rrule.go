@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// An RRULE carries no DTSTART, so rules without BYHOUR/BYMINUTE fire at this
+// time of day.
+const (
+	defaultRRuleHour   = 9
+	defaultRRuleMinute = 0
+)
+
+var rruleDays = map[string]int{
+	"SU": 0,
+	"MO": 1,
+	"TU": 2,
+	"WE": 3,
+	"TH": 4,
+	"FR": 5,
+	"SA": 6,
+}
+
+var rruleUntilLayouts = []string{
+	"20060102T150405Z",
+	"20060102T150405",
+	"20060102",
+}
+
+// parseRRULE converts a subset of iCalendar RRULE (FREQ=DAILY/WEEKLY, BYDAY,
+// INTERVAL, COUNT, UNTIL, BYHOUR, BYMINUTE) into a recurring reminder without
+// content.
+func parseRRULE(s string) (Reminder, error) {
+	parts := make(map[string]string)
+	for _, part := range strings.Split(strings.TrimPrefix(s, "RRULE:"), ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return Reminder{}, fmt.Errorf("malformed RRULE part %q", part)
+		}
+		if _, seen := parts[key]; seen {
+			return Reminder{}, fmt.Errorf("duplicate RRULE part %q", key)
+		}
+		parts[key] = value
+	}
+
+	reminder := Reminder{Recurring: true}
+	hour, minute := defaultRRuleHour, defaultRRuleMinute
+	interval := 1
+	var days []string
+	for key, value := range parts {
+		var err error
+		switch key {
+		case "FREQ":
+			if value != "DAILY" && value != "WEEKLY" {
+				return Reminder{}, fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			interval, err = strconv.Atoi(value)
+			if err == nil && interval < 1 {
+				err = fmt.Errorf("INTERVAL must be positive")
+			}
+		case "COUNT":
+			reminder.RemainingFires, err = strconv.Atoi(value)
+			if err == nil && reminder.RemainingFires < 1 {
+				err = fmt.Errorf("COUNT must be positive")
+			}
+		case "UNTIL":
+			until, untilErr := parseRRuleUntil(value)
+			reminder.ExpiresAt, err = &until, untilErr
+		case "BYDAY":
+			days = strings.Split(value, ",")
+			for _, day := range days {
+				if _, ok := rruleDays[day]; !ok {
+					err = fmt.Errorf("unsupported BYDAY %q", day)
+				}
+			}
+		case "BYHOUR":
+			hour, err = strconv.Atoi(value)
+			if err == nil && (hour < 0 || hour > 23) {
+				err = fmt.Errorf("invalid BYHOUR %q", value)
+			}
+		case "BYMINUTE":
+			minute, err = strconv.Atoi(value)
+			if err == nil && (minute < 0 || minute > 59) {
+				err = fmt.Errorf("invalid BYMINUTE %q", value)
+			}
+		default:
+			err = fmt.Errorf("unsupported RRULE part %q", key)
+		}
+		if err != nil {
+			return Reminder{}, err
+		}
+	}
+	if parts["COUNT"] != "" && parts["UNTIL"] != "" {
+		return Reminder{}, fmt.Errorf("COUNT and UNTIL are mutually exclusive")
+	}
+
+	switch parts["FREQ"] {
+	case "DAILY":
+		if len(days) > 0 && interval > 1 {
+			return Reminder{}, fmt.Errorf("INTERVAL with BYDAY is not supported")
+		}
+		if interval > 1 {
+			reminder.Schedule = fmt.Sprintf("@every %dh", interval*24)
+		} else {
+			reminder.Schedule = fmt.Sprintf("%d %d * * %s", minute, hour, cronDays(days))
+		}
+	case "WEEKLY":
+		if len(days) == 0 {
+			return Reminder{}, fmt.Errorf("WEEKLY needs BYDAY without a DTSTART")
+		}
+		if interval > 1 {
+			return Reminder{}, fmt.Errorf("WEEKLY with INTERVAL is not supported")
+		}
+		reminder.Schedule = fmt.Sprintf("%d %d * * %s", minute, hour, cronDays(days))
+	default:
+		return Reminder{}, fmt.Errorf("missing FREQ")
+	}
+
+	return reminder, nil
+}
+
+func parseRRuleUntil(value string) (time.Time, error) {
+	for _, layout := range rruleUntilLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			if layout == "20060102" {
+				t = t.AddDate(0, 0, 1)
+			}
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+func cronDays(days []string) string {
+	if len(days) == 0 {
+		return "*"
+	}
+
+	fields := make([]string, len(days))
+	for i, day := range days {
+		fields[i] = strconv.Itoa(rruleDays[day])
+	}
+	return strings.Join(fields, ",")
+}
+
+func handleRRuleReminder(chatID int64, args string, bot BotClient) {
+	fields, content := cutFields(args, 1)
+	if len(fields) == 0 || content == "" {
+		sendUsage(chatID, "rrule", bot)
+		return
+	}
+
+	reminder, err := parseRRULE(fields[0])
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "rrule.invalid", err))
+		send(bot, msg)
+		return
+	}
+	reminder.Content = content
+
+	addRecurringReminder(chatID, reminder, bot)
+}
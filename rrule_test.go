@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRRULE(t *testing.T) {
+	tests := []struct {
+		rule      string
+		schedule  string
+		fires     int
+		expiresAt string
+	}{
+		{"FREQ=DAILY", "0 9 * * *", 0, ""},
+		{"RRULE:FREQ=DAILY;BYHOUR=7;BYMINUTE=30", "30 7 * * *", 0, ""},
+		{"FREQ=DAILY;INTERVAL=3", "@every 72h", 0, ""},
+		{"FREQ=DAILY;BYDAY=MO,WE,FR", "0 9 * * 1,3,5", 0, ""},
+		{"FREQ=WEEKLY;BYDAY=SU;BYHOUR=20", "0 20 * * 0", 0, ""},
+		{"FREQ=WEEKLY;BYDAY=TU,TH;COUNT=6", "0 9 * * 2,4", 6, ""},
+		{"FREQ=DAILY;UNTIL=20240331T120000Z", "0 9 * * *", 0, "2024-03-31T12:00:00Z"},
+		{"FREQ=DAILY;UNTIL=20240331", "0 9 * * *", 0, "2024-04-01T00:00:00Z"},
+	}
+	for _, test := range tests {
+		reminder, err := parseRRULE(test.rule)
+		if err != nil {
+			t.Errorf("parseRRULE(%q): %v", test.rule, err)
+			continue
+		}
+		expiresAt := ""
+		if reminder.ExpiresAt != nil {
+			expiresAt = reminder.ExpiresAt.Format(time.RFC3339)
+		}
+		if !reminder.Recurring || reminder.Schedule != test.schedule || reminder.RemainingFires != test.fires || expiresAt != test.expiresAt {
+			t.Errorf("parseRRULE(%q) = %q, %d fires, until %q; want %q, %d, %q",
+				test.rule, reminder.Schedule, reminder.RemainingFires, expiresAt, test.schedule, test.fires, test.expiresAt)
+		}
+	}
+}
+
+func TestParseRRULERejects(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"BYDAY=MO",
+		"FREQ=MONTHLY",
+		"FREQ=WEEKLY",
+		"FREQ=WEEKLY;BYDAY=MO;INTERVAL=2",
+		"FREQ=DAILY;BYDAY=MO;INTERVAL=2",
+		"FREQ=DAILY;BYDAY=XX",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=0",
+		"FREQ=DAILY;COUNT=3;UNTIL=20240331",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=DAILY;BYHOUR=24",
+		"FREQ=DAILY;BYMINUTE=60",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;WKST=MO",
+		"FREQ=DAILY;",
+	} {
+		if reminder, err := parseRRULE(rule); err == nil {
+			t.Errorf("parseRRULE(%q) = %q, want an error", rule, reminder.Schedule)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	maxSearchResults = 20
+	// Query words at least this long also match words one typo away.
+	minFuzzyWordLength = 4
+)
+
+// withinOneTypo reports whether a and b differ by at most one inserted,
+// deleted or replaced rune, or two neighbouring runes swapped.
+func withinOneTypo(a, b string) bool {
+	x, y := []rune(a), []rune(b)
+	if len(x) > len(y) {
+		x, y = y, x
+	}
+	if len(y)-len(x) > 1 {
+		return false
+	}
+
+	i := 0
+	for i < len(x) && x[i] == y[i] {
+		i++
+	}
+	if len(x) == len(y) {
+		if i >= len(x)-1 {
+			return true
+		}
+		swapped := x[i] == y[i+1] && x[i+1] == y[i] && string(x[i+2:]) == string(y[i+2:])
+		return swapped || string(x[i+1:]) == string(y[i+1:])
+	}
+
+	return string(x[i:]) == string(y[i+1:])
+}
+
+// matchesQuery reports whether every word of the query occurs in text,
+// ignoring case. Longer query words also match a word of text with one
+// typo, so "dentsit" still finds "dentist".
+func matchesQuery(text string, words []string) bool {
+	text = strings.ToLower(text)
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for _, word := range words {
+		if strings.Contains(text, word) {
+			continue
+		}
+		found := false
+		if utf8.RuneCountInString(word) >= minFuzzyWordLength {
+			for _, field := range fields {
+				if withinOneTypo(word, field) {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// handleSearch finds todos, reminders and completed todos matching the
+// query. Each result starts with the command that acts on it, using the
+// same index or ID the listings show.
+func handleSearch(chatID int64, query string, bot BotClient) {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		sendUsage(chatID, "search", bot)
+		return
+	}
+
+	userData, exists := todoData[chatID]
+	if !exists {
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "search.empty", query)))
+		return
+	}
+
+	found := 0
+	var sections []string
+	addSection := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		found += len(lines)
+		if len(lines) > maxSearchResults {
+			lines = append(lines[:maxSearchResults], tr(chatID, "search.more", len(lines)-maxSearchResults))
+		}
+		sections = append(sections, title+"\n"+strings.Join(lines, "\n"))
+	}
+
+	var todos []string
+	for i, todo := range userData.Todos {
+		if matchesQuery(todo.Text, words) {
+			todos = append(todos, fmt.Sprintf("/done %d — %s", i+1, todo.Text))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(userData.Lists)) {
+		for i, todo := range userData.Lists[name] {
+			if matchesQuery(todo.Text, words) {
+				todos = append(todos, fmt.Sprintf("/done %s %d — %s", name, i+1, todo.Text))
+			}
+		}
+	}
+	addSection(tr(chatID, "search.todos"), todos)
+
+	var reminders []string
+	for i, reminder := range pendingReminders(chatID) {
+		if matchesQuery(reminder.Title+" "+reminder.Content, words) {
+			reminders = append(reminders, fmt.Sprintf("/cancel %d — %s — %s", i+1, reminder.DisplayTitle(), formatTime(chatID, reminder.Time)))
+		}
+	}
+	n := 0
+	for _, reminder := range userData.Reminders {
+		if !reminder.Recurring {
+			continue
+		}
+		n++
+		if matchesQuery(reminder.Title+" "+reminder.Content, words) {
+			reminders = append(reminders, tr(chatID, "search.recurring", n, reminder.DisplayTitle(), reminder.Schedule))
+		}
+	}
+	addSection(tr(chatID, "search.reminders"), reminders)
+
+	var completed []string
+	for i := len(userData.Completed) - 1; i >= 0; i-- {
+		item := userData.Completed[i]
+		if matchesQuery(item.Todo.Text, words) {
+			completed = append(completed, fmt.Sprintf("/undone %d — %s — %s", item.Todo.ID, item.Todo.Text, formatTime(chatID, item.CompletedAt)))
+		}
+	}
+	addSection(tr(chatID, "search.completed"), completed)
+
+	if found == 0 {
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "search.empty", query)))
+		return
+	}
+
+	text := tr(chatID, "search.title", found) + "\n\n" + strings.Join(sections, "\n\n")
+	send(bot, tgbotapi.NewMessage(chatID, text))
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Telegram allows bots roughly 30 messages per second across all chats,
+// about one per second in a single chat and 20 per minute in a group.
+const (
+	sendRate  = 30
+	sendBurst = 30
+
+	chatSendRate  = 1
+	groupSendRate = 20.0 / 60
+	chatSendBurst = 3
+
+	// Chat limiters idle for this long are full again and can be dropped.
+	chatLimiterIdle = time.Minute
+
+	maxSendAttempts = 3
+)
+
+type RateLimiter struct {
+	mu          sync.Mutex
+	rate        float64
+	capacity    float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+var outbox = NewSendQueue()
+
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		rate:     rate,
+		capacity: float64(burst),
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until a token is available and takes it.
+func (l *RateLimiter) Wait() {
+	for {
+		l.mu.Lock()
+		now := time.Now()
+		if now.Before(l.pausedUntil) {
+			delay := l.pausedUntil.Sub(now)
+			l.mu.Unlock()
+			time.Sleep(delay)
+			continue
+		}
+
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.capacity {
+			l.tokens = l.capacity
+		}
+		l.last = now
+
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return
+		}
+
+		delay := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+		time.Sleep(delay)
+	}
+}
+
+// Pause stops handing out tokens for d, e.g. after Telegram answered with
+// 429 Too Many Requests.
+func (l *RateLimiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	until := time.Now().Add(d)
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+	l.tokens = 0
+}
+
+type chatLimiter struct {
+	limiter  *RateLimiter
+	lastUsed time.Time
+}
+
+// SendQueue is the one way out to Telegram. Every call waits for its chat's
+// limiter and then the global one, so a chat flooding itself doesn't use
+// up the budget of the others.
+type SendQueue struct {
+	global *RateLimiter
+
+	mu    sync.Mutex
+	chats map[int64]*chatLimiter
+}
+
+func NewSendQueue() *SendQueue {
+	return &SendQueue{
+		global: NewRateLimiter(sendRate, sendBurst),
+		chats:  make(map[int64]*chatLimiter),
+	}
+}
+
+// chat returns the limiter of chatID, creating it on first use. Group chats
+// have negative IDs and the stricter limit.
+func (q *SendQueue) chat(chatID int64) *RateLimiter {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := time.Now()
+	entry, exists := q.chats[chatID]
+	if !exists {
+		for id, idle := range q.chats {
+			if now.Sub(idle.lastUsed) > chatLimiterIdle {
+				delete(q.chats, id)
+			}
+		}
+		var rate float64 = chatSendRate
+		if chatID < 0 {
+			rate = groupSendRate
+		}
+		entry = &chatLimiter{limiter: NewRateLimiter(rate, chatSendBurst)}
+		q.chats[chatID] = entry
+	}
+	entry.lastUsed = now
+
+	return entry.limiter
+}
+
+func (q *SendQueue) Wait(c tgbotapi.Chattable) {
+	if chatID, ok := chattableChat(c); ok {
+		q.chat(chatID).Wait()
+	}
+	q.global.Wait()
+}
+
+// Pause holds back the chat c goes to for d after a 429. Calls without a
+// chat, like answering callbacks, pause everything since Telegram didn't
+// say what was flooded.
+func (q *SendQueue) Pause(c tgbotapi.Chattable, d time.Duration) {
+	if chatID, ok := chattableChat(c); ok {
+		q.chat(chatID).Pause(d)
+		return
+	}
+	q.global.Pause(d)
+}
+
+// chattableChat returns the chat a call posts to, for the calls that count
+// against the per-chat limit.
+func chattableChat(c tgbotapi.Chattable) (int64, bool) {
+	switch c := c.(type) {
+	case tgbotapi.MessageConfig:
+		return c.ChatID, true
+	case tgbotapi.DocumentConfig:
+		return c.ChatID, true
+	case tgbotapi.EditMessageTextConfig:
+		return c.ChatID, true
+	case tgbotapi.EditMessageReplyMarkupConfig:
+		return c.ChatID, true
+	default:
+		return 0, false
+	}
+}
+
+// BotClient is the part of the Telegram Bot API the handlers use.
+// *tgbotapi.BotAPI implements it; handlers take the interface so they can
+// run against a stand-in for the API.
+type BotClient interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+	GetFileDirectURL(fileID string) (string, error)
+	GetChatMember(config tgbotapi.GetChatMemberConfig) (tgbotapi.ChatMember, error)
+}
+
+// botUsername is the bot's own username, for links into the bot. It is set
+// at startup since BotClient doesn't carry the bot's profile.
+var botUsername string
+
+// send delivers c through the send queue. When Telegram answers with 429
+// the queue is paused for the advised RetryAfter and the send is retried.
+// The caller holds dataMu; it is released while waiting for the queue and
+// talking to Telegram, so a throttled chat doesn't hold up the others. As
+// with unlocked, chat state must be looked up again afterwards.
+func send(bot BotClient, c tgbotapi.Chattable) (msg tgbotapi.Message, err error) {
+	unlocked(func() {
+		msg, err = sendDirect(bot, c)
+	})
+
+	return msg, err
+}
+
+// sendDirect is send for goroutines that don't hold dataMu.
+func sendDirect(bot BotClient, c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	var msg tgbotapi.Message
+	var err error
+	for attempt := 1; attempt <= maxSendAttempts; attempt++ {
+		outbox.Wait(c)
+		msg, err = bot.Send(c)
+		if !shouldRetry(c, err) {
+			break
+		}
+	}
+	countSendError(err)
+
+	return msg, err
+}
+
+// request is send for calls whose result isn't a message.
+func request(bot BotClient, c tgbotapi.Chattable) (resp *tgbotapi.APIResponse, err error) {
+	unlocked(func() {
+		resp, err = requestDirect(bot, c)
+	})
+
+	return resp, err
+}
+
+// requestDirect is request for goroutines that don't hold dataMu.
+func requestDirect(bot BotClient, c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	var resp *tgbotapi.APIResponse
+	var err error
+	for attempt := 1; attempt <= maxSendAttempts; attempt++ {
+		outbox.Wait(c)
+		resp, err = bot.Request(c)
+		if !shouldRetry(c, err) {
+			break
+		}
+	}
+	countSendError(err)
+
+	return resp, err
+}
+
+func countSendError(err error) {
+	if err == nil {
+		return
+	}
+
+	reason := "error"
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		reason = "rate_limited"
+	}
+	sendErrors.WithLabelValues(reason).Inc()
+}
+
+func shouldRetry(c tgbotapi.Chattable, err error) bool {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) || apiErr.RetryAfter <= 0 {
+		return false
+	}
+
+	slog.Warn("Rate limited by Telegram", "retry_after", apiErr.RetryAfter)
+	outbox.Pause(c, time.Duration(apiErr.RetryAfter)*time.Second)
+
+	return true
+}
+
+// splitMessage breaks text into chunks of at most limit characters, cutting
+// at line breaks where possible. Line breaks at the edges of a chunk are
+// dropped.
+func splitMessage(text string, limit int) []string {
+	var chunks []string
+	var current []rune
+	flush := func() {
+		if chunk := strings.Trim(string(current), "\n"); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		current = nil
+	}
+	for _, line := range strings.SplitAfter(text, "\n") {
+		runes := []rune(line)
+		if len(current)+len(runes) > limit && len(current) > 0 {
+			flush()
+		}
+		for len(runes) > limit {
+			current = runes[:limit]
+			flush()
+			runes = runes[limit:]
+		}
+		current = append(current, runes...)
+	}
+	flush()
+
+	return chunks
+}
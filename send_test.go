@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// A chat that Telegram throttled must not hold dataMu while it waits, or
+// every other chat and timer waits with it.
+func TestThrottledChatDoesNotBlockOthers(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const throttled, other = 2001, 2002
+
+	outbox.Pause(tgbotapi.NewMessage(throttled, ""), time.Second)
+	slow, fast := command(throttled, "/set slow"), command(other, "/set fast")
+	done := make(chan struct{})
+	go func() {
+		dispatch(slow, bot)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	dispatch(fast, bot)
+	if took := time.Since(start); took > 500*time.Millisecond {
+		t.Errorf("other chat took %v behind the throttled one", took)
+	}
+	<-done
+}
+
+// floodBot answers the first failures sends with 429 Too Many Requests
+// and records when each send came in.
+type floodBot struct {
+	BotClient
+	failures int
+	calls    []time.Time
+}
+
+func (b *floodBot) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	b.calls = append(b.calls, time.Now())
+	if len(b.calls) <= b.failures {
+		return tgbotapi.Message{}, &tgbotapi.Error{Code: 429, Message: "Too Many Requests", ResponseParameters: tgbotapi.ResponseParameters{RetryAfter: 1}}
+	}
+
+	return tgbotapi.Message{MessageID: len(b.calls)}, nil
+}
+
+// A 429 pauses the chat for the advised RetryAfter before the send is
+// retried, up to maxSendAttempts times.
+func TestSendRetriesAfterFlood(t *testing.T) {
+	tests := []struct {
+		name     string
+		chatID   int64
+		failures int
+		calls    int
+		ok       bool
+	}{
+		{"recovers", 2101, 1, 2, true},
+		{"gives up", 2102, maxSendAttempts, maxSendAttempts, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bot := &floodBot{failures: test.failures}
+			_, err := sendDirect(bot, tgbotapi.NewMessage(test.chatID, "hi"))
+			if (err == nil) != test.ok {
+				t.Errorf("sendDirect error %v, want success %v", err, test.ok)
+			}
+			if len(bot.calls) != test.calls {
+				t.Fatalf("%d attempts, want %d", len(bot.calls), test.calls)
+			}
+			for i := 1; i < len(bot.calls); i++ {
+				if pause := bot.calls[i].Sub(bot.calls[i-1]); pause < 900*time.Millisecond {
+					t.Errorf("attempt %d came %v after the 429, want the RetryAfter of 1s", i+1, pause)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{"fits", "hello", 10, []string{"hello"}},
+		{"empty", "", 10, nil},
+		{"at line breaks", "aaa\nbbb\nccc", 8, []string{"aaa\nbbb", "ccc"}},
+		{"exact", "aaa\nbbb", 7, []string{"aaa\nbbb"}},
+		{"long line", "abcdefghij", 4, []string{"abcd", "efgh", "ij"}},
+		{"long line after short", "ab\ncdefghij", 4, []string{"ab", "cdef", "ghij"}},
+		{"runes", "привіт\nсвіт", 6, []string{"привіт", "світ"}},
+		{"blank lines", "a\n\n\nb", 2, []string{"a", "b"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := splitMessage(test.text, test.limit)
+			if fmt.Sprintf("%q", got) != fmt.Sprintf("%q", test.want) {
+				t.Errorf("splitMessage(%q, %d) = %q, want %q", test.text, test.limit, got, test.want)
+			}
+			for _, chunk := range got {
+				if n := len([]rune(chunk)); n > test.limit {
+					t.Errorf("chunk %q has %d characters, over %d", chunk, n, test.limit)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	tests := []struct {
+		name  string
+		rate  float64
+		burst int
+		calls int
+		min   time.Duration
+		max   time.Duration
+	}{
+		{"within burst", 10, 3, 3, 0, 50 * time.Millisecond},
+		{"past burst", 20, 2, 4, 80 * time.Millisecond, 400 * time.Millisecond},
+		{"no burst", 50, 1, 3, 30 * time.Millisecond, 300 * time.Millisecond},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			limiter := NewRateLimiter(test.rate, test.burst)
+			start := time.Now()
+			for range test.calls {
+				limiter.Wait()
+			}
+			if took := time.Since(start); took < test.min || took > test.max {
+				t.Errorf("%d calls took %v, want %v to %v", test.calls, took, test.min, test.max)
+			}
+		})
+	}
+}
+
+func TestRateLimiterPause(t *testing.T) {
+	limiter := NewRateLimiter(1000, 5)
+	limiter.Pause(100 * time.Millisecond)
+
+	start := time.Now()
+	limiter.Wait()
+	if took := time.Since(start); took < 90*time.Millisecond {
+		t.Errorf("Wait returned after %v during a 100ms pause", took)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Reminders set further ahead than this ask for confirmation first, to catch
+// typos like 100d instead of 10d.
+const defaultConfirmThreshold = 30 * 24 * time.Hour
+
+type Settings struct {
+	DisableLinkPreview bool               `json:"disable_link_preview,omitempty"`
+	ConfirmAfter       time.Duration      `json:"confirm_after,omitempty"`
+	QuietDone          bool               `json:"quiet_done,omitempty"`
+	Language           string             `json:"language,omitempty"`
+	QuietHours         *QuietHours        `json:"quiet_hours,omitempty"`
+	Clock              int                `json:"clock,omitempty"`
+	WeekStart          string             `json:"week_start,omitempty"`
+	Timezone           string             `json:"timezone,omitempty"`
+	BusinessHours      *BusinessHours     `json:"business_hours,omitempty"`
+	DigestAt           *int               `json:"digest_at,omitempty"`
+	DigestDay          *time.Weekday      `json:"digest_day,omitempty"`
+	SnoozeOptions      []string           `json:"snooze_options"`
+	AutoRemind         time.Duration      `json:"auto_remind,omitempty"`
+	DefaultContent     string             `json:"default_reminder_content,omitempty"`
+	CalendarToken      string             `json:"calendar_token,omitempty"`
+	KeepCompleted      time.Duration      `json:"keep_completed,omitempty"`
+	Email              string             `json:"email,omitempty"`
+	PendingEmail       *EmailConfirmation `json:"pending_email,omitempty"`
+	NotifyWebhook      string             `json:"notify_webhook,omitempty"`
+}
+
+// confirmThreshold returns the chat's confirmation threshold, or zero if
+// confirmations are turned off.
+func confirmThreshold(chatID int64) time.Duration {
+	userData, exists := todoData[chatID]
+	if !exists || userData.Settings.ConfirmAfter == 0 {
+		return defaultConfirmThreshold
+	}
+	if userData.Settings.ConfirmAfter < 0 {
+		return 0
+	}
+
+	return userData.Settings.ConfirmAfter
+}
+
+func parseOnOff(value string) (bool, bool) {
+	switch value {
+	case "on":
+		return true, true
+	case "off":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+func handleLinkPreviewSetting(chatID int64, value string, bot BotClient) {
+	enabled, ok := parseOnOff(value)
+	if !ok {
+		sendUsage(chatID, "linkpreview", bot)
+		return
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	todoData[chatID].Settings.DisableLinkPreview = !enabled
+
+	text := tr(chatID, "settings.link_preview_on")
+	if !enabled {
+		text = tr(chatID, "settings.link_preview_off")
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func handleConfirmAfterSetting(chatID int64, value string, bot BotClient) {
+	threshold := time.Duration(-1)
+	if value != "off" {
+		duration, err := parseDuration(value)
+		if err != nil || duration <= 0 {
+			sendUsage(chatID, "confirmafter", bot)
+			return
+		}
+		threshold = duration
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	todoData[chatID].Settings.ConfirmAfter = threshold
+
+	text := tr(chatID, "settings.confirm_after_on", value)
+	if threshold < 0 {
+		text = tr(chatID, "settings.confirm_after_off")
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func handleQuietDoneSetting(chatID int64, value string, bot BotClient) {
+	quiet, ok := parseOnOff(value)
+	if !ok {
+		sendUsage(chatID, "quietdone", bot)
+		return
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	todoData[chatID].Settings.QuietDone = quiet
+
+	text := tr(chatID, "settings.quiet_done_off")
+	if quiet {
+		text = tr(chatID, "settings.quiet_done_on")
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func handleAutoRemindSetting(chatID int64, value string, bot BotClient) {
+	var delay time.Duration
+	if value != "off" {
+		duration, err := parseDuration(value)
+		if err != nil || duration <= 0 {
+			sendUsage(chatID, "autoremind", bot)
+			return
+		}
+		delay = duration
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	todoData[chatID].Settings.AutoRemind = delay
+
+	text := tr(chatID, "settings.auto_remind_on", value)
+	if delay == 0 {
+		text = tr(chatID, "settings.auto_remind_off")
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+// reminderContent returns content, or the chat's default reminder text if
+// content is empty.
+func reminderContent(chatID int64, content string) string {
+	if content != "" {
+		return content
+	}
+	if userData, exists := todoData[chatID]; exists {
+		return userData.Settings.DefaultContent
+	}
+
+	return ""
+}
+
+func handleDefaultContentSetting(chatID int64, value string, bot BotClient) {
+	if value == "" {
+		sendUsage(chatID, "defaultcontent", bot)
+		return
+	}
+	if value == "off" {
+		value = ""
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	todoData[chatID].Settings.DefaultContent = value
+
+	text := tr(chatID, "settings.default_content_on", value)
+	if value == "" {
+		text = tr(chatID, "settings.default_content_off")
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
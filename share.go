@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Telegram only accepts up to 64 characters from [A-Za-z0-9_-] in a start
+// parameter, which is what base64url without padding produces.
+const maxStartParamLength = 64
+
+// encodeSharePayload packs a reminder's time and content into a deep-link
+// start parameter.
+func encodeSharePayload(reminder Reminder) (string, error) {
+	raw := strconv.FormatInt(reminder.Time.Unix(), 10) + "|" + reminder.Content
+	payload := base64.RawURLEncoding.EncodeToString([]byte(raw))
+	if len(payload) > maxStartParamLength {
+		return "", fmt.Errorf("reminder is too long to share")
+	}
+
+	return payload, nil
+}
+
+func decodeSharePayload(payload string) (Reminder, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return Reminder{}, err
+	}
+
+	timeStr, content, ok := strings.Cut(string(raw), "|")
+	if !ok || content == "" {
+		return Reminder{}, fmt.Errorf("malformed share payload")
+	}
+	unix, err := strconv.ParseInt(timeStr, 10, 64)
+	if err != nil {
+		return Reminder{}, err
+	}
+
+	return Reminder{Content: content, Time: time.Unix(unix, 0)}, nil
+}
+
+func shareLink(botUserName string, payload string) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s", botUserName, payload)
+}
+
+func handleShare(chatID int64, indexStr string, bot BotClient) {
+	reminder, ok := pendingReminderByIndex(chatID, indexStr)
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	payload, err := encodeSharePayload(reminder)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "share.too_long"))
+		send(bot, msg)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, shareLink(botUsername, payload))
+	msg.DisableWebPagePreview = true
+	send(bot, msg)
+}
+
+// handleSharedReminder recreates a reminder from a /start deep-link payload.
+func handleSharedReminder(chatID int64, payload string, bot BotClient) {
+	reminder, err := decodeSharePayload(payload)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "share.invalid"))
+		send(bot, msg)
+		return
+	}
+
+	if !reminder.Time.After(time.Now()) {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "share.expired"))
+		send(bot, msg)
+		return
+	}
+
+	if !checkQuota(chatID, len(reminder.Content), bot) {
+		return
+	}
+
+	createReminder(chatID, reminder.Time.Format(time.RFC3339), reminder, bot)
+}
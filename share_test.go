@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSharePayloadRoundTrip(t *testing.T) {
+	at := time.Date(2030, time.June, 1, 18, 30, 0, 0, time.UTC)
+	for _, content := range []string{"call mom", "pay rent | utilities", "полити квіти 🌱"} {
+		payload, err := encodeSharePayload(Reminder{Content: content, Time: at})
+		if err != nil {
+			t.Errorf("encodeSharePayload(%q): %v", content, err)
+			continue
+		}
+		if strings.Trim(payload, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789_-") != "" {
+			t.Errorf("payload %q has characters Telegram doesn't allow in a start parameter", payload)
+		}
+
+		reminder, err := decodeSharePayload(payload)
+		if err != nil {
+			t.Errorf("decodeSharePayload(%q): %v", payload, err)
+			continue
+		}
+		if reminder.Content != content || !reminder.Time.Equal(at) {
+			t.Errorf("%q came back as %q at %v", content, reminder.Content, reminder.Time)
+		}
+	}
+}
+
+func TestSharePayloadRejects(t *testing.T) {
+	if payload, err := encodeSharePayload(Reminder{Content: strings.Repeat("x", 50), Time: time.Now()}); err == nil {
+		t.Errorf("encoded an overlong reminder as %q (%d characters)", payload, len(payload))
+	}
+
+	for _, payload := range []string{"", "not base64!", "MTIzNA", "MTIzNHw", "YWJjfGNhbGwgbW9t"} {
+		if reminder, err := decodeSharePayload(payload); err == nil {
+			t.Errorf("decodeSharePayload(%q) = %+v, want an error", payload, reminder)
+		}
+	}
+}
+
+// A link made by /share in one chat sets the same reminder in another.
+func TestShareLink(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const from, to = 10501, 10502
+
+	oldUsername := botUsername
+	botUsername = "remindeer_test_bot"
+	t.Cleanup(func() { botUsername = oldUsername })
+
+	at := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+	todoData[from] = &UserData{Todos: []Todo{}, Reminders: []Reminder{{ID: 1, Content: "book tickets", Time: at}}, NextReminderID: 1}
+
+	dispatch(command(from, "/share 1"), bot)
+	sent := fake.sent(from)
+	link := sent[len(sent)-1]
+	payload, ok := strings.CutPrefix(link, "https://t.me/remindeer_test_bot?start=")
+	if !ok {
+		t.Fatalf("/share answered %q, want a deep link", link)
+	}
+
+	dispatch(command(to, "/start "+payload), bot)
+	reminders := pendingReminders(to)
+	if len(reminders) != 1 || reminders[0].Content != "book tickets" || !reminders[0].Time.Equal(at) {
+		t.Errorf("shared link set %+v, want book tickets at %v", reminders, at)
+	}
+}
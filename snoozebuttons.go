@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// "tomorrow" snoozes until this hour of the next day in the user's timezone.
+const snoozeTomorrowHour = 9
+
+var defaultSnoozeOptions = []string{"10m", "1h", "tomorrow"}
+
+// snoozeOptionTime resolves a snooze button option relative to now.
+func snoozeOptionTime(option string, now time.Time) (time.Time, error) {
+	if option == "tomorrow" {
+		return time.Date(now.Year(), now.Month(), now.Day()+1, snoozeTomorrowHour, 0, 0, 0, now.Location()), nil
+	}
+
+	duration, err := parseDuration(option)
+	if err != nil || duration <= 0 {
+		return time.Time{}, fmt.Errorf("invalid snooze option %q", option)
+	}
+
+	return now.Add(duration), nil
+}
+
+func chatSnoozeOptions(chatID int64) []string {
+	if userData, exists := todoData[chatID]; exists && userData.Settings.SnoozeOptions != nil {
+		return userData.Settings.SnoozeOptions
+	}
+
+	return defaultSnoozeOptions
+}
+
+// snoozeKeyboard builds the buttons attached to a fired reminder, or nil if
+// the user turned them off.
+func snoozeKeyboard(reminderID int, options []string) *tgbotapi.InlineKeyboardMarkup {
+	if len(options) == 0 {
+		return nil
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	for _, option := range options {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(
+			"⏰ "+option, fmt.Sprintf("snooze:%d:%s", reminderID, option)))
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(row)
+
+	return &keyboard
+}
+
+// firedReminder finds the latest delivery of a reminder in the chat's
+// history.
+func firedReminder(userData *UserData, id int) (Reminder, bool) {
+	for i := len(userData.History) - 1; i >= 0; i-- {
+		if userData.History[i].Reminder.ID == id {
+			return userData.History[i].Reminder, true
+		}
+	}
+
+	return Reminder{}, false
+}
+
+func handleSnoozeButton(chatID int64, arg string, messageID int, bot BotClient) {
+	idStr, option, _ := strings.Cut(arg, ":")
+	id, err := strconv.Atoi(idStr)
+	userData, exists := todoData[chatID]
+	if err != nil || !exists {
+		return
+	}
+
+	reminder, ok := firedReminder(userData, id)
+	if !ok {
+		send(bot, tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, tgbotapi.NewInlineKeyboardMarkup()))
+		return
+	}
+
+	newTime, err := snoozeOptionTime(option, time.Now().In(chatLocation(chatID)))
+	if err != nil {
+		return
+	}
+
+	if _, pending := findReminder(chatID, id); pending && !reminder.Recurring {
+		snoozeReminder(chatID, reminder, newTime, bot)
+	} else {
+		snoozed := reminder
+		snoozed.Time = newTime
+		snoozed.Recurring = false
+		snoozed.Schedule = ""
+		snoozed.CountdownMessageID = 0
+		snoozed.SnoozeCount++
+		snoozed, err = addReminder(chatID, snoozed)
+		if err != nil {
+			sendReminderLimit(chatID, bot)
+			return
+		}
+		scheduleReminder(chatID, snoozed, bot)
+
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "reminder.snoozed", snoozed.DisplayTitle(), formatTime(chatID, newTime))))
+
+		if err := saveUserData(); err != nil {
+			slog.Error("Failed to save user data", "err", err)
+		}
+	}
+
+	send(bot, tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, tgbotapi.NewInlineKeyboardMarkup()))
+}
+
+func handleSnoozeButtonsSetting(chatID int64, args string, bot BotClient) {
+	options := strings.Fields(args)
+	if len(options) == 0 {
+		sendUsage(chatID, "snoozebuttons", bot)
+		return
+	}
+
+	if len(options) == 1 && options[0] == "off" {
+		options = []string{}
+	}
+	for _, option := range options {
+		if _, err := snoozeOptionTime(option, time.Now()); err != nil {
+			msg := tgbotapi.NewMessage(chatID, tr(chatID, "snoozebuttons.invalid", option))
+			send(bot, msg)
+			return
+		}
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	todoData[chatID].Settings.SnoozeOptions = options
+
+	text := tr(chatID, "snoozebuttons.off")
+	if len(options) > 0 {
+		text = tr(chatID, "snoozebuttons.on", strings.Join(options, ", "))
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
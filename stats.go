@@ -0,0 +1,51 @@
+package main
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type DeliveryStats struct {
+	Delivered int `json:"delivered"`
+	Failed    int `json:"failed"`
+}
+
+// SuccessRate returns the share of delivered reminder sends, or 1 if nothing
+// was sent yet.
+func (s DeliveryStats) SuccessRate() float64 {
+	total := s.Delivered + s.Failed
+	if total == 0 {
+		return 1
+	}
+
+	return float64(s.Delivered) / float64(total)
+}
+
+func recordDelivery(chatID int64, err error) {
+	userData, exists := todoData[chatID]
+	if !exists {
+		return
+	}
+
+	if err != nil {
+		userData.DeliveryStats.Failed++
+	} else {
+		userData.DeliveryStats.Delivered++
+	}
+}
+
+func handleDeliveryStats(chatID int64, bot BotClient) {
+	var stats DeliveryStats
+	if userData, exists := todoData[chatID]; exists {
+		stats = userData.DeliveryStats
+	}
+
+	if stats.Delivered+stats.Failed == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "stats.empty"))
+		send(bot, msg)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "stats.delivery",
+		stats.Delivered, stats.Failed, stats.SuccessRate()*100))
+	send(bot, msg)
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const saveDelay = 2 * time.Second
+
+type Store interface {
+	Load() (map[int64]*UserData, error)
+	Save(data map[int64]*UserData) error
+}
+
+type JSONStore struct {
+	Path string
+}
+
+func (s *JSONStore) Load() (map[int64]*UserData, error) {
+	file, err := os.Open(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return decodeUserData(file)
+}
+
+// decodeUserData reads the chat-ID keyed user data, skipping entries whose
+// key isn't a chat ID or whose value doesn't decode instead of failing the
+// whole load.
+func decodeUserData(r io.Reader) (map[int64]*UserData, error) {
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	data := make(map[int64]*UserData)
+	for key, value := range raw {
+		chatID, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			slog.Warn("Skipping user data with invalid chat ID", "key", key)
+			continue
+		}
+
+		var userData UserData
+		if err := json.Unmarshal(value, &userData); err != nil {
+			slog.Warn("Skipping malformed user data", "chat_id", chatID, "err", err)
+			continue
+		}
+		data[chatID] = &userData
+	}
+
+	return data, nil
+}
+
+// Save writes to a temporary file next to Path and renames it over Path, so
+// a crash or encoding error midway leaves the previous data intact.
+func (s *JSONStore) Save(data map[int64]*UserData) error {
+	file, err := os.CreateTemp(filepath.Dir(s.Path), filepath.Base(s.Path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err := json.NewEncoder(file).Encode(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), s.Path)
+}
+
+// CachedStore keeps the latest saved snapshot in memory and writes it to the
+// backend asynchronously, batching saves that arrive within the delay into
+// a single write. Load returns the pending snapshot if there is one, so
+// reads always observe the last Save.
+type CachedStore struct {
+	backend Store
+	delay   time.Duration
+
+	mu      sync.Mutex
+	pending map[int64]*UserData
+	timer   *time.Timer
+}
+
+func NewCachedStore(backend Store, delay time.Duration) *CachedStore {
+	return &CachedStore{backend: backend, delay: delay}
+}
+
+func (s *CachedStore) Load() (map[int64]*UserData, error) {
+	s.mu.Lock()
+	pending := s.pending
+	s.mu.Unlock()
+
+	if pending != nil {
+		return cloneUserData(pending)
+	}
+
+	defer observeStorage("load", time.Now())
+	return s.backend.Load()
+}
+
+// Save takes a snapshot of data right away, so the caller may keep mutating
+// it, and schedules the write to the backend.
+func (s *CachedStore) Save(data map[int64]*UserData) error {
+	snapshot, err := cloneUserData(data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending = snapshot
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.delay, func() {
+			if err := s.Flush(); err != nil {
+				slog.Error("Failed to save user data", "err", err)
+			}
+		})
+	}
+
+	return nil
+}
+
+// Flush writes the pending snapshot, if any, to the backend.
+func (s *CachedStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if s.pending == nil {
+		return nil
+	}
+
+	start := time.Now()
+	err := s.backend.Save(s.pending)
+	observeStorage("save", start)
+	if err != nil {
+		return err
+	}
+	s.pending = nil
+
+	return nil
+}
+
+func (s *CachedStore) Close() error {
+	if err := s.Flush(); err != nil {
+		return err
+	}
+
+	if closer, ok := s.backend.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+func cloneUserData(data map[int64]*UserData) (map[int64]*UserData, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	clone := make(map[int64]*UserData)
+	if err := json.Unmarshal(raw, &clone); err != nil {
+		return nil, err
+	}
+
+	return clone, nil
+}
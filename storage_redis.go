@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisUserDataKey = "remindeer:userdata"
+
+// RedisStore keeps all chats in one hash, one JSON document per chat ID.
+// Like the SQLite store it remembers which fields it loaded or wrote, and
+// Save only removes those, so fields that failed to load are kept.
+type RedisStore struct {
+	client  *redis.Client
+	written map[int64]bool
+}
+
+func NewRedisStore(addr string) (*RedisStore, error) {
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return &RedisStore{client: client, written: make(map[int64]bool)}, nil
+}
+
+func (s *RedisStore) Load() (map[int64]*UserData, error) {
+	fields, err := s.client.HGetAll(context.Background(), redisUserDataKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[int64]*UserData)
+	written := make(map[int64]bool)
+	for field, raw := range fields {
+		chatID, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			slog.Warn("Skipping user data with invalid chat ID", "field", field)
+			continue
+		}
+
+		userData := &UserData{}
+		if err := json.Unmarshal([]byte(raw), userData); err != nil {
+			slog.Warn("Skipping malformed user data", "chat_id", chatID, "err", err)
+			continue
+		}
+		data[chatID] = userData
+		written[chatID] = true
+	}
+	s.written = written
+
+	return data, nil
+}
+
+func (s *RedisStore) Save(data map[int64]*UserData) error {
+	values := make(map[string]interface{}, len(data))
+	for chatID, userData := range data {
+		raw, err := json.Marshal(userData)
+		if err != nil {
+			return err
+		}
+		values[strconv.FormatInt(chatID, 10)] = raw
+	}
+
+	var removed []string
+	for chatID := range s.written {
+		if _, exists := data[chatID]; !exists {
+			removed = append(removed, strconv.FormatInt(chatID, 10))
+		}
+	}
+
+	ctx := context.Background()
+	pipe := s.client.TxPipeline()
+	if len(removed) > 0 {
+		pipe.HDel(ctx, redisUserDataKey, removed...)
+	}
+	if len(values) > 0 {
+		pipe.HSet(ctx, redisUserDataKey, values)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	s.written = make(map[int64]bool, len(data))
+	for chatID := range data {
+		s.written[chatID] = true
+	}
+
+	return nil
+}
+
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
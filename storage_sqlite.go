@@ -0,0 +1,147 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStore keeps each chat's data as a JSON document in its own row.
+// Save only rewrites the rows of chats that changed since the last save,
+// each in its own transaction.
+type SQLiteStore struct {
+	db      *sql.DB
+	written map[int64]string
+}
+
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS user_data (
+		chat_id INTEGER PRIMARY KEY,
+		data    TEXT NOT NULL
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db, written: make(map[int64]string)}, nil
+}
+
+// Load skips rows that don't decode, like the JSON store does. Skipped
+// rows are left out of written, so Save never deletes or overwrites them
+// unless the chat gets new data.
+func (s *SQLiteStore) Load() (map[int64]*UserData, error) {
+	rows, err := s.db.Query("SELECT chat_id, data FROM user_data")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	data := make(map[int64]*UserData)
+	written := make(map[int64]string)
+	for rows.Next() {
+		var chatID int64
+		var raw string
+		if err := rows.Scan(&chatID, &raw); err != nil {
+			return nil, err
+		}
+
+		userData := &UserData{}
+		if err := json.Unmarshal([]byte(raw), userData); err != nil {
+			slog.Warn("Skipping malformed user data", "chat_id", chatID, "err", err)
+			continue
+		}
+		data[chatID] = userData
+		written[chatID] = raw
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	s.written = written
+
+	return data, nil
+}
+
+func (s *SQLiteStore) Save(data map[int64]*UserData) error {
+	for chatID, userData := range data {
+		raw, err := json.Marshal(userData)
+		if err != nil {
+			return err
+		}
+		if s.written[chatID] == string(raw) {
+			continue
+		}
+		if err := s.saveChat(chatID, string(raw)); err != nil {
+			return err
+		}
+		s.written[chatID] = string(raw)
+	}
+
+	for chatID := range s.written {
+		if _, exists := data[chatID]; exists {
+			continue
+		}
+		if _, err := s.db.Exec("DELETE FROM user_data WHERE chat_id = ?", chatID); err != nil {
+			return err
+		}
+		delete(s.written, chatID)
+	}
+
+	return nil
+}
+
+func (s *SQLiteStore) saveChat(chatID int64, raw string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO user_data (chat_id, data) VALUES (?, ?)
+		ON CONFLICT (chat_id) DO UPDATE SET data = excluded.data`, chatID, raw)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// migrateFromJSON imports the JSON data file into an empty database once,
+// then renames the file so it isn't imported again.
+func (s *SQLiteStore) migrateFromJSON(path string) error {
+	var count int
+	if err := s.db.QueryRow("SELECT COUNT(*) FROM user_data").Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	data, err := (&JSONStore{Path: path}).Load()
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.Save(data); err != nil {
+		return err
+	}
+
+	slog.Info("Migrated user data to SQLite", "chats", len(data), "path", path)
+	return os.Rename(path, path+".migrated")
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
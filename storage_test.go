@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+// storeBackend sets up one Store implementation over fresh storage. open
+// returns a new Store over the same storage, as after a restart; corrupt
+// writes an entry for chatID that doesn't decode.
+type storeBackend struct {
+	name  string
+	setup func(t *testing.T) (open func() Store, corrupt func(chatID int64))
+}
+
+var storeBackends = []storeBackend{
+	{"json", func(t *testing.T) (func() Store, func(int64)) {
+		path := filepath.Join(t.TempDir(), "userdata.json")
+		open := func() Store { return &JSONStore{Path: path} }
+		corrupt := func(chatID int64) {
+			raw := map[string]json.RawMessage{}
+			if file, err := os.ReadFile(path); err == nil {
+				if err := json.Unmarshal(file, &raw); err != nil {
+					t.Fatal(err)
+				}
+			}
+			raw[strconv.FormatInt(chatID, 10)] = json.RawMessage(`[1]`)
+			file, err := json.Marshal(raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, file, 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return open, corrupt
+	}},
+	{"sqlite", func(t *testing.T) (func() Store, func(int64)) {
+		path := filepath.Join(t.TempDir(), "userdata.db")
+		open := func() Store {
+			s, err := NewSQLiteStore(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { s.Close() })
+			return s
+		}
+		corrupt := func(chatID int64) {
+			s := open().(*SQLiteStore)
+			if _, err := s.db.Exec("INSERT OR REPLACE INTO user_data (chat_id, data) VALUES (?, '[1]')", chatID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return open, corrupt
+	}},
+	{"redis", func(t *testing.T) (func() Store, func(int64)) {
+		server := miniredis.RunT(t)
+		open := func() Store {
+			s, err := NewRedisStore(server.Addr())
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { s.Close() })
+			return s
+		}
+		corrupt := func(chatID int64) {
+			server.HSet(redisUserDataKey, strconv.FormatInt(chatID, 10), "[1]")
+		}
+		return open, corrupt
+	}},
+}
+
+// loadStore loads from s, treating a JSON file that was never written as
+// empty the way startup does.
+func loadStore(t *testing.T, s Store) map[int64]*UserData {
+	t.Helper()
+	data, err := s.Load()
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[int64]*UserData{}
+	}
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	return data
+}
+
+func sampleUserData() map[int64]*UserData {
+	due := time.Date(2024, time.March, 15, 9, 0, 0, 0, time.UTC)
+	return map[int64]*UserData{
+		1: {
+			Todos:          []Todo{{ID: 1, Text: "buy milk", Due: &due}},
+			Reminders:      []Reminder{{ID: 1, Content: "call mom", Time: due}},
+			NextReminderID: 1,
+			NextTodoID:     1,
+			Settings:       Settings{Timezone: "Europe/Kyiv", Language: "en"},
+		},
+		-100200300: {
+			Todos:          []Todo{},
+			Reminders:      []Reminder{{ID: 4, Content: "standup", Recurring: true, Schedule: "0 10 * * 1-5"}},
+			NextReminderID: 4,
+		},
+	}
+}
+
+func assertSameData(t *testing.T, got, want map[int64]*UserData) {
+	t.Helper()
+	gotRaw, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantRaw, err := json.Marshal(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(gotRaw) != string(wantRaw) {
+		t.Errorf("loaded %s, want %s", gotRaw, wantRaw)
+	}
+}
+
+func TestStoreConformance(t *testing.T) {
+	for _, backend := range storeBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			t.Run("empty", func(t *testing.T) {
+				open, _ := backend.setup(t)
+				if data := loadStore(t, open()); len(data) != 0 {
+					t.Errorf("fresh store loaded %d chats", len(data))
+				}
+			})
+
+			t.Run("round trip", func(t *testing.T) {
+				open, _ := backend.setup(t)
+				if err := open().Save(sampleUserData()); err != nil {
+					t.Fatalf("Save: %v", err)
+				}
+				assertSameData(t, loadStore(t, open()), sampleUserData())
+			})
+
+			t.Run("removed chat", func(t *testing.T) {
+				open, _ := backend.setup(t)
+				s := open()
+				data := sampleUserData()
+				if err := s.Save(data); err != nil {
+					t.Fatalf("Save: %v", err)
+				}
+				delete(data, -100200300)
+				if err := s.Save(data); err != nil {
+					t.Fatalf("Save: %v", err)
+				}
+				assertSameData(t, loadStore(t, open()), data)
+			})
+
+			t.Run("updated chat", func(t *testing.T) {
+				open, _ := backend.setup(t)
+				s := open()
+				data := sampleUserData()
+				if err := s.Save(data); err != nil {
+					t.Fatalf("Save: %v", err)
+				}
+				data[1].Todos = append(data[1].Todos, Todo{ID: 2, Text: "call the bank"})
+				data[1].Reminders = nil
+				data[1].NextTodoID = 2
+				if err := s.Save(data); err != nil {
+					t.Fatalf("Save: %v", err)
+				}
+				assertSameData(t, loadStore(t, open()), data)
+			})
+
+			// The bot saves from handlers and timers at once, always
+			// through a CachedStore in front of the backend.
+			t.Run("concurrent saves", func(t *testing.T) {
+				open, _ := backend.setup(t)
+				s := NewCachedStore(open(), time.Millisecond)
+				var wg sync.WaitGroup
+				for range 8 {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						if err := s.Save(sampleUserData()); err != nil {
+							t.Errorf("Save: %v", err)
+						}
+					}()
+				}
+				wg.Wait()
+				if err := s.Flush(); err != nil {
+					t.Fatalf("Flush: %v", err)
+				}
+				assertSameData(t, loadStore(t, open()), sampleUserData())
+			})
+
+			t.Run("malformed entry", func(t *testing.T) {
+				open, corrupt := backend.setup(t)
+				if err := open().Save(sampleUserData()); err != nil {
+					t.Fatalf("Save: %v", err)
+				}
+				corrupt(42)
+
+				s := open()
+				data := loadStore(t, s)
+				assertSameData(t, data, sampleUserData())
+
+				// Saving what was loaded must not lose the chats that did load.
+				if err := s.Save(data); err != nil {
+					t.Fatalf("Save: %v", err)
+				}
+				assertSameData(t, loadStore(t, open()), sampleUserData())
+			})
+		})
+	}
+}
+
+// The JSON store reports a file that was never written as fs.ErrNotExist
+// instead of loading it as empty.
+func TestJSONStoreMissingFile(t *testing.T) {
+	s := &JSONStore{Path: filepath.Join(t.TempDir(), "missing.json")}
+	if _, err := s.Load(); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Load of missing file: got %v, want fs.ErrNotExist", err)
+	}
+}
+
+// A save that fails half way leaves the last good file in place and no
+// temporary file behind.
+func TestJSONStoreFailedSave(t *testing.T) {
+	dir := t.TempDir()
+	s := &JSONStore{Path: filepath.Join(dir, "userdata.json")}
+	if err := s.Save(sampleUserData()); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	before, err := os.ReadFile(s.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// JSON can't encode times past year 9999.
+	broken := sampleUserData()
+	broken[1].Reminders = append(broken[1].Reminders, Reminder{ID: 9, Content: "far off", Time: time.Date(10000, time.January, 1, 0, 0, 0, 0, time.UTC)})
+	if err := s.Save(broken); err == nil {
+		t.Fatal("Save of an unencodable time succeeded")
+	}
+
+	after, err := os.ReadFile(s.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("file changed by the failed save:\n%s", after)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != "userdata.json" {
+			t.Errorf("%s left behind", entry.Name())
+		}
+	}
+}
+
+func TestCachedStoreReadsPendingSave(t *testing.T) {
+	backend := &JSONStore{Path: filepath.Join(t.TempDir(), "userdata.json")}
+	s := NewCachedStore(backend, time.Hour)
+	if err := s.Save(sampleUserData()); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	assertSameData(t, loadStore(t, s), sampleUserData())
+
+	if err := s.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	assertSameData(t, loadStore(t, backend), sampleUserData())
+}
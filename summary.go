@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type WeekBucket struct {
+	Start     time.Time
+	Reminders []Reminder
+}
+
+// groupByWeek buckets reminders, which must already be sorted by time, into
+// the weeks they fall in.
+func groupByWeek(reminders []Reminder, weekStart time.Weekday, loc *time.Location) []WeekBucket {
+	var buckets []WeekBucket
+	for _, reminder := range reminders {
+		start := startOfWeek(reminder.Time.In(loc), weekStart)
+		if len(buckets) == 0 || !buckets[len(buckets)-1].Start.Equal(start) {
+			buckets = append(buckets, WeekBucket{Start: start})
+		}
+		last := &buckets[len(buckets)-1]
+		last.Reminders = append(last.Reminders, reminder)
+	}
+
+	return buckets
+}
+
+func handleSummary(chatID int64, bot BotClient) {
+	pending := pendingReminders(chatID)
+	if len(pending) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "reminders.empty"))
+		send(bot, msg)
+		return
+	}
+
+	var summary string
+	for _, bucket := range groupByWeek(pending, chatWeekStart(chatID), chatLocation(chatID)) {
+		summary += tr(chatID, "summary.week", bucket.Start.Format(tr(chatID, "format.date")), len(bucket.Reminders)) + "\n"
+		for _, reminder := range bucket.Reminders {
+			summary += fmt.Sprintf("• %s — %s\n", formatTime(chatID, reminder.Time), reminder.DisplayTitle())
+		}
+		summary += "\n"
+	}
+
+	msg := tgbotapi.NewMessage(chatID, strings.TrimSpace(summary))
+	send(bot, msg)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestGroupByWeek(t *testing.T) {
+	kyiv, err := time.LoadLocation("Europe/Kyiv")
+	if err != nil {
+		t.Skip(err)
+	}
+	at := func(day, hour, minute int) Reminder {
+		return Reminder{Content: fmt.Sprintf("%d/%02d:%02d", day, hour, minute), Time: time.Date(2024, time.March, day, hour, minute, 0, 0, time.UTC)}
+	}
+	// March 2024: the 9th is a Saturday, the 10th a Sunday, the 11th a Monday.
+	reminders := []Reminder{at(9, 12, 0), at(10, 12, 0), at(10, 22, 30), at(11, 9, 0), at(17, 9, 0)}
+
+	tests := []struct {
+		name      string
+		weekStart time.Weekday
+		loc       *time.Location
+		want      string
+	}{
+		{"monday", time.Monday, time.UTC, "03-04:[9/12:00 10/12:00 10/22:30] 03-11:[11/09:00 17/09:00]"},
+		{"sunday", time.Sunday, time.UTC, "03-03:[9/12:00] 03-10:[10/12:00 10/22:30 11/09:00] 03-17:[17/09:00]"},
+		{"monday in Kyiv", time.Monday, kyiv, "03-04:[9/12:00 10/12:00] 03-11:[10/22:30 11/09:00 17/09:00]"},
+	}
+	for _, test := range tests {
+		var got string
+		for i, bucket := range groupByWeek(reminders, test.weekStart, test.loc) {
+			if i > 0 {
+				got += " "
+			}
+			var contents []string
+			for _, reminder := range bucket.Reminders {
+				contents = append(contents, reminder.Content)
+			}
+			got += bucket.Start.Format("01-02") + ":" + fmt.Sprint(contents)
+		}
+		if got != test.want {
+			t.Errorf("%s: got %s, want %s", test.name, got, test.want)
+		}
+	}
+}
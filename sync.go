@@ -0,0 +1,157 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/robfig/cron/v3"
+)
+
+// systemEntries are the scheduler's own housekeeping jobs, which aren't
+// backed by any reminder.
+var systemEntries = make(map[cron.EntryID]bool)
+
+func addSystemJob(spec string, job func()) {
+	entryID, err := reminderScheduler.AddFunc(spec, locked(job))
+	if err != nil {
+		slog.Error("Failed to schedule job", "spec", spec, "err", err)
+		return
+	}
+	systemEntries[entryID] = true
+}
+
+type SyncReport struct {
+	Rearmed  int
+	Orphaned int
+}
+
+// reconcileReminders compares the stored reminders with the live timers and
+// cron entries: reminders without one are scheduled again (or caught up if
+// already due), and timers or entries without a reminder are stopped.
+func reconcileReminders(now time.Time, bot BotClient) SyncReport {
+	var report SyncReport
+
+	stored := make(map[reminderKey]Reminder)
+	for chatID, userData := range todoData {
+		for _, reminder := range userData.Reminders {
+			stored[reminderKey{ChatID: chatID, ReminderID: reminder.ID}] = reminder
+		}
+	}
+
+	for key := range reminderTimers {
+		if reminder, exists := stored[key]; !exists || reminder.Recurring {
+			chatLog(key.ChatID).Info("Stopping orphaned timer", "reminder_id", key.ReminderID)
+			reminderTimers[key].Stop()
+			delete(reminderTimers, key)
+			report.Orphaned++
+		}
+	}
+	for key := range headsUpTimers {
+		if reminder, exists := stored[key]; !exists || reminder.Recurring {
+			unscheduleHeadsUps(key)
+		}
+	}
+	for key, entryID := range reminderEntries {
+		if reminder, exists := stored[key]; !exists || !reminder.Recurring {
+			chatLog(key.ChatID).Info("Removing orphaned cron entry", "reminder_id", key.ReminderID)
+			reminderScheduler.Remove(entryID)
+			delete(reminderEntries, key)
+			report.Orphaned++
+		}
+	}
+	for key, timer := range deferredFires {
+		if reminder, exists := stored[key]; !exists || !reminder.Recurring {
+			chatLog(key.ChatID).Info("Stopping orphaned deferred fire", "reminder_id", key.ReminderID)
+			timer.Stop()
+			delete(deferredFires, key)
+			report.Orphaned++
+		}
+	}
+
+	missed := make(map[int64][]Reminder)
+	for key, reminder := range stored {
+		if isOnStart(reminder) {
+			continue
+		}
+		if reminder.Recurring {
+			if entryID, exists := reminderEntries[key]; exists && reminderScheduler.Entry(entryID).ID != 0 {
+				continue
+			}
+		} else if _, exists := reminderTimers[key]; exists {
+			continue
+		}
+
+		chatLog(key.ChatID).Info("Re-arming reminder", "reminder_id", key.ReminderID)
+		report.Rearmed++
+		if reminder.Recurring || reminder.Time.After(now) {
+			scheduleReminder(key.ChatID, reminder, bot)
+		} else {
+			missed[key.ChatID] = append(missed[key.ChatID], reminder)
+		}
+	}
+	for chatID, reminders := range missed {
+		catchUpReminders(chatID, reminders, bot)
+	}
+
+	return report
+}
+
+// cleanupCronEntries removes scheduler entries that belong to neither a
+// stored recurring reminder, a digest nor a housekeeping job, and returns
+// how many there were.
+func cleanupCronEntries() int {
+	known := make(map[cron.EntryID]bool)
+	for entryID := range systemEntries {
+		known[entryID] = true
+	}
+	for _, entryID := range digestEntries {
+		known[entryID] = true
+	}
+	for key, entryID := range reminderEntries {
+		if reminder, exists := findReminder(key.ChatID, key.ReminderID); exists && reminder.Recurring {
+			known[entryID] = true
+		}
+	}
+
+	removed := 0
+	for _, entry := range reminderScheduler.Entries() {
+		if known[entry.ID] {
+			continue
+		}
+		slog.Info("Removing cron entry with no backing reminder", "entry_id", entry.ID)
+		reminderScheduler.Remove(entry.ID)
+		removed++
+	}
+	for key, entryID := range reminderEntries {
+		if !known[entryID] {
+			delete(reminderEntries, key)
+		}
+	}
+
+	return removed
+}
+
+func handleRemindCleanup(chatID int64, userID int64, bot BotClient) {
+	if !checkAdmin(chatID, userID, bot) {
+		return
+	}
+
+	removed := cleanupCronEntries()
+	send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "sync.cleaned", removed)))
+}
+
+func handleRemindSync(chatID int64, userID int64, bot BotClient) {
+	if !checkAdmin(chatID, userID, bot) {
+		return
+	}
+
+	report := reconcileReminders(time.Now(), bot)
+
+	text := tr(chatID, "sync.in_sync")
+	if report.Rearmed > 0 || report.Orphaned > 0 {
+		text = tr(chatID, "sync.done",
+			report.Rearmed, report.Orphaned)
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+}
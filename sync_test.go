@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// /remindsync re-arms stored reminders that lost their timer or cron entry,
+// delivers the ones that came due meanwhile, and stops timers and entries
+// nothing is stored for.
+func TestReconcileReminders(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 10701
+
+	now := time.Now()
+	todoData[chatID] = &UserData{
+		Todos: []Todo{},
+		Reminders: []Reminder{
+			{ID: 1, Content: "armed", Time: now.Add(time.Hour)},
+			{ID: 2, Content: "lost timer", Time: now.Add(2 * time.Hour)},
+			{ID: 3, Content: "missed", Time: now.Add(-time.Minute)},
+			{ID: 4, Content: "lost entry", Recurring: true, Schedule: "0 9 * * *"},
+		},
+		NextReminderID: 4,
+	}
+	key := func(id int) reminderKey { return reminderKey{ChatID: chatID, ReminderID: id} }
+
+	dataMu.Lock()
+	defer dataMu.Unlock()
+	scheduleReminder(chatID, todoData[chatID].Reminders[0], bot)
+	reminderTimers[key(9)] = time.AfterFunc(time.Hour, func() {})
+	orphanEntry, err := reminderScheduler.AddFunc("0 10 * * *", func() {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reminderEntries[key(10)] = orphanEntry
+
+	report := reconcileReminders(now, bot)
+	if report.Rearmed != 3 || report.Orphaned != 2 {
+		t.Errorf("report %+v, want 3 re-armed and 2 orphaned", report)
+	}
+
+	for _, id := range []int{1, 2} {
+		if _, exists := reminderTimers[key(id)]; !exists {
+			t.Errorf("reminder %d has no timer", id)
+		}
+	}
+	if _, exists := reminderEntries[key(4)]; !exists {
+		t.Error("recurring reminder has no cron entry")
+	}
+	if _, exists := reminderTimers[key(9)]; exists {
+		t.Error("orphaned timer kept")
+	}
+	if _, exists := reminderEntries[key(10)]; exists || reminderScheduler.Entry(orphanEntry).ID != 0 {
+		t.Error("orphaned cron entry kept")
+	}
+	if _, pending := findReminder(chatID, 3); pending {
+		t.Error("missed reminder still pending after the sync")
+	}
+	if !sentContaining(fake.sent(chatID), "missed") {
+		t.Errorf("missed reminder not delivered; sent %q", fake.sent(chatID))
+	}
+
+	// A second sync finds nothing to do.
+	if report := reconcileReminders(now, bot); report != (SyncReport{}) {
+		t.Errorf("second sync reported %+v", report)
+	}
+	removeReminders(chatID, func(Reminder) bool { return true })
+}
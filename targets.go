@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// deliveryTargets lists every chat a reminder is delivered to: the chat it
+// was set in, followed by its extra targets.
+func deliveryTargets(chatID int64, reminder Reminder) []int64 {
+	targets := []int64{chatID}
+	for _, target := range reminder.Targets {
+		if target != chatID {
+			targets = append(targets, target)
+		}
+	}
+
+	return targets
+}
+
+func parseTargets(s string) ([]int64, error) {
+	var targets []int64
+	for _, field := range strings.Split(s, ",") {
+		target, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chat id %q", field)
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
+
+// isChatMember reports whether userID is in the target chat, so reminders
+// can't be sent into chats their author doesn't belong to.
+func isChatMember(target int64, userID int64, bot BotClient) bool {
+	config := tgbotapi.GetChatMemberConfig{
+		ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: target, UserID: userID},
+	}
+	var member tgbotapi.ChatMember
+	var err error
+	unlocked(func() {
+		outbox.Wait(config)
+		member, err = bot.GetChatMember(config)
+	})
+	if err != nil {
+		chatLog(target).Warn("Failed to check membership", "user_id", userID, "err", err)
+		return false
+	}
+
+	return !member.HasLeft() && !member.WasKicked()
+}
+
+func handleRemindTo(chatID int64, userID int64, targetsStr string, timeStr string, reminder Reminder, bot BotClient) {
+	targets, err := parseTargets(targetsStr)
+	if err != nil {
+		sendUsage(chatID, "remindto", bot)
+		return
+	}
+
+	for _, target := range targets {
+		if target != chatID && !isChatMember(target, userID, bot) {
+			msg := tgbotapi.NewMessage(chatID, tr(chatID, "targets.not_member", target))
+			send(bot, msg)
+			return
+		}
+	}
+	reminder.Targets = targets
+
+	handleReminder(chatID, userID, timeStr, reminder, bot)
+}
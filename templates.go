@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type TemplateItem struct {
+	TimeStr string `json:"time"`
+	Content string `json:"content"`
+}
+
+// parseTemplateItems parses one "<time> <message>" item per line.
+func parseTemplateItems(chatID int64, text string) ([]TemplateItem, error) {
+	var items []TemplateItem
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		timeStr, content, _ := strings.Cut(line, " ")
+		content = strings.TrimSpace(content)
+		if _, err := parseDuration(timeStr); err != nil || content == "" {
+			return nil, errors.New(tr(chatID, "templates.bad_line", i+1))
+		}
+		items = append(items, TemplateItem{TimeStr: timeStr, Content: content})
+	}
+
+	if len(items) == 0 {
+		return nil, errors.New(tr(chatID, "templates.empty_template"))
+	}
+
+	return items, nil
+}
+
+func handleSaveTemplate(chatID int64, args string, bot BotClient) {
+	name, body, _ := strings.Cut(args, "\n")
+	name = strings.TrimSpace(name)
+	if name == "" || strings.Contains(name, " ") {
+		sendUsage(chatID, "savetemplate", bot)
+		return
+	}
+
+	items, err := parseTemplateItems(chatID, body)
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "templates.invalid", err))
+		send(bot, msg)
+		return
+	}
+
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	userData := todoData[chatID]
+	if userData.Templates == nil {
+		userData.Templates = make(map[string][]TemplateItem)
+	}
+	userData.Templates[name] = items
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "templates.saved", name, len(items)))
+	send(bot, msg)
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func handleApplyTemplate(chatID int64, name string, bot BotClient) {
+	var items []TemplateItem
+	if userData, exists := todoData[chatID]; exists {
+		items = userData.Templates[name]
+	}
+	if len(items) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "templates.not_found", name))
+		send(bot, msg)
+		return
+	}
+
+	size := 0
+	for _, item := range items {
+		size += len(item.Content)
+	}
+	if !checkQuota(chatID, size, bot) {
+		return
+	}
+
+	now := time.Now().In(chatLocation(chatID))
+	created := 0
+	var failures string
+	for _, item := range items {
+		reminderTime, err := addDuration(now, item.TimeStr)
+		if err != nil {
+			failures += tr(chatID, "templates.item_bad_time", item.TimeStr, item.Content) + "\n"
+			continue
+		}
+
+		reminder, err := addReminder(chatID, Reminder{
+			Content: item.Content,
+			Time:    reminderTime,
+		})
+		if err != nil {
+			failures += tr(chatID, "templates.item_over_limit", item.TimeStr, item.Content, maxRemindersPerChat) + "\n"
+			continue
+		}
+		scheduleReminder(chatID, reminder, bot)
+		created++
+	}
+
+	text := tr(chatID, "templates.applied", name, created)
+	if failures != "" {
+		text = tr(chatID, "templates.applied_partial", name, created, len(items), failures)
+	}
+	send(bot, tgbotapi.NewMessage(chatID, text))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func handleTemplateList(chatID int64, bot BotClient) {
+	userData, exists := todoData[chatID]
+	if !exists || len(userData.Templates) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "templates.empty"))
+		send(bot, msg)
+		return
+	}
+
+	names := make([]string, 0, len(userData.Templates))
+	for name := range userData.Templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var list string
+	for _, name := range names {
+		list += fmt.Sprintf("%s:\n", name)
+		for _, item := range userData.Templates[name] {
+			list += fmt.Sprintf("  %s %s\n", item.TimeStr, item.Content)
+		}
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "templates.title", list))
+	send(bot, msg)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// The /applytemplate reply counts only the reminders it created and names
+// the items it skipped.
+func TestApplyTemplateReportsFailures(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 5001
+
+	userData := fullChat(chatID)
+	userData.Reminders = userData.Reminders[:maxRemindersPerChat-1]
+	userData.Settings.Language = "en"
+	userData.Templates = map[string][]TemplateItem{"morning": {
+		{TimeStr: "1h", Content: "water"},
+		{TimeStr: "soon", Content: "stretch"},
+		{TimeStr: "2h", Content: "read"},
+	}}
+
+	dispatch(command(chatID, "/applytemplate morning"), bot)
+	if got := len(todoData[chatID].Reminders); got != maxRemindersPerChat {
+		t.Errorf("%d reminders, want the limit of %d", got, maxRemindersPerChat)
+	}
+
+	sent := fake.sent(chatID)
+	if len(sent) == 0 {
+		t.Fatal("no reply")
+	}
+	reply := sent[len(sent)-1]
+	for _, want := range []string{"1 of 3", "soon stretch", "2h read"} {
+		if !strings.Contains(reply, want) {
+			t.Errorf("reply %q does not mention %q", reply, want)
+		}
+	}
+	if strings.Contains(reply, "water") {
+		t.Errorf("reply %q lists the item that was created", reply)
+	}
+}
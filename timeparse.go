@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A day phrase without a clock time, such as "tomorrow", fires at this hour.
+const defaultDayHour = 9
+
+var relativeDays = map[string]int{
+	"today":    0,
+	"tomorrow": 1,
+	"сьогодні": 0,
+	"завтра":   1,
+}
+
+// parseAnyClock accepts both "18:30" and "6:30pm".
+func parseAnyClock(s string) (int, error) {
+	if minutes, err := parseClock(s); err == nil {
+		return minutes, nil
+	}
+
+	return parseClock12(s)
+}
+
+// phraseDay resolves the day part of a phrase: "today", "tomorrow", a
+// weekday name or an ISO date. weekday reports whether the day may roll
+// over to next week once its time has passed.
+func phraseDay(word string, now time.Time) (day time.Time, weekday bool, ok bool) {
+	if offset, exists := relativeDays[word]; exists {
+		return now.AddDate(0, 0, offset), false, true
+	}
+
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		name := strings.ToLower(wd.String())
+		if word == name || word == name[:3] {
+			offset := (int(wd) - int(now.Weekday()) + 7) % 7
+			return now.AddDate(0, 0, offset), true, true
+		}
+	}
+
+	if date, err := time.ParseInLocation("2006-01-02", word, now.Location()); err == nil {
+		return date, false, true
+	}
+
+	return time.Time{}, false, false
+}
+
+// parseDayPhrase parses phrases like "tomorrow", "tomorrow 9am",
+// "friday 18:00" or "2024-12-24 18:00". isPhrase is false when s does not
+// start with a day at all.
+func parseDayPhrase(s string, now time.Time) (t time.Time, isPhrase bool, err error) {
+	dayStr, clockStr, hasClock := strings.Cut(strings.ToLower(strings.TrimSpace(s)), " ")
+	day, weekday, ok := phraseDay(dayStr, now)
+	if !ok {
+		return time.Time{}, false, nil
+	}
+
+	minutes := defaultDayHour * 60
+	if hasClock {
+		minutes, err = parseAnyClock(strings.TrimSpace(clockStr))
+		if err != nil {
+			return time.Time{}, true, fmt.Errorf("invalid time in %q", s)
+		}
+	} else if !weekday && dayStr[0] >= '0' && dayStr[0] <= '9' {
+		// A bare date keeps its old meaning of midnight.
+		minutes = 0
+	}
+
+	t = time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, now.Location())
+	if weekday && !t.After(now) {
+		t = t.AddDate(0, 0, 7)
+	}
+
+	return t, true, nil
+}
+
+// cutReminderTime splits the time off the front of a reminder command. The
+// time is usually one word, but a day phrase such as "tomorrow 9am" or
+// "2024-12-24 18:00" takes two.
+func cutReminderTime(args string) (string, string) {
+	fields, content := cutFields(args, 2)
+	if len(fields) == 2 {
+		phrase := fields[0] + " " + fields[1]
+		if _, isPhrase, err := parseDayPhrase(phrase, time.Now()); isPhrase && err == nil {
+			return phrase, content
+		}
+	}
+
+	fields, content = cutFields(args, 1)
+	if len(fields) == 0 {
+		return "", ""
+	}
+
+	return fields[0], content
+}
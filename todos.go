@@ -0,0 +1,508 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+type Todo struct {
+	ID          int            `json:"id,omitempty"`
+	Text        string         `json:"text"`
+	CreatedAt   time.Time      `json:"created_at"`
+	RepeatEvery *time.Duration `json:"repeat_every,omitempty"`
+	LastDoneAt  time.Time      `json:"last_done_at,omitempty"`
+	NudgeAfter  *time.Duration `json:"nudge_after,omitempty"`
+	NudgedAt    time.Time      `json:"nudged_at,omitempty"`
+	Due         *time.Time     `json:"due,omitempty"`
+	ReminderIDs []int          `json:"reminder_ids,omitempty"`
+	Progress    int            `json:"progress,omitempty"`
+	Owner       *Mention       `json:"owner,omitempty"`
+	Priority    int            `json:"priority,omitempty"`
+
+	SourceMessageID int `json:"source_message_id,omitempty"`
+}
+
+// addTodo stores a new todo under the chat's next ID. Like addReminder, it
+// is where the per-chat limit is enforced.
+func addTodo(chatID int64, todo Todo) (Todo, error) {
+	if _, exists := todoData[chatID]; !exists {
+		todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{}}
+	}
+	userData := todoData[chatID]
+	if todoCount(userData) >= maxTodosPerChat {
+		return Todo{}, errTodoLimit
+	}
+
+	userData.NextTodoID++
+	todo.ID = userData.NextTodoID
+	userData.Todos = append(userData.Todos, todo)
+
+	return todo, nil
+}
+
+// ScheduledTodo is a completed recurring todo waiting to be put back on the
+// list.
+type ScheduledTodo struct {
+	Todo    Todo      `json:"todo"`
+	ReaddAt time.Time `json:"readd_at"`
+}
+
+// UnmarshalJSON also accepts the plain strings todos used to be stored as;
+// their creation time is unknown and left zero.
+func (t *Todo) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*t = Todo{Text: text}
+		return nil
+	}
+
+	type plainTodo Todo
+	return json.Unmarshal(data, (*plainTodo)(t))
+}
+
+func formatAge(chatID int64, createdAt time.Time, now time.Time) string {
+	if createdAt.IsZero() {
+		return tr(chatID, "todo.age_unknown")
+	}
+
+	age := now.Sub(createdAt)
+	switch {
+	case age < time.Hour:
+		return tr(chatID, "todo.age_new")
+	case age < 24*time.Hour:
+		hours := int(age / time.Hour)
+		return trPlural(chatID, "todo.age_hours", hours, hours)
+	default:
+		days := int(age / (24 * time.Hour))
+		return trPlural(chatID, "todo.age_days", days, days)
+	}
+}
+
+func scheduleTodoReadd(chatID int64, scheduled ScheduledTodo, bot BotClient) {
+	time.AfterFunc(time.Until(scheduled.ReaddAt), locked(func() {
+		readdTodo(chatID, scheduled, bot)
+	}))
+}
+
+func readdTodo(chatID int64, scheduled ScheduledTodo, bot BotClient) {
+	userData, exists := todoData[chatID]
+	if !exists {
+		return
+	}
+
+	for i, pending := range userData.ScheduledTodos {
+		if pending.Todo.Text == scheduled.Todo.Text && pending.ReaddAt.Equal(scheduled.ReaddAt) {
+			userData.ScheduledTodos = append(userData.ScheduledTodos[:i], userData.ScheduledTodos[i+1:]...)
+			break
+		}
+	}
+
+	todo := scheduled.Todo
+	todo.CreatedAt = time.Now()
+	if _, err := addTodo(chatID, todo); err != nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "todo.readd_over_limit", todo.Text, maxTodosPerChat))
+		send(bot, msg)
+	} else {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "todo.readded", todo.Text))
+		send(bot, msg)
+	}
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func handleRepeatTodo(chatID int64, indexStr string, interval string, bot BotClient) {
+	userData, exists := todoData[chatID]
+	index, err := strconv.Atoi(indexStr)
+	if !exists || err != nil || index < 1 || index > len(userData.Todos) {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	todo := &userData.Todos[index-1]
+	if interval == "off" {
+		todo.RepeatEvery = nil
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "todo.repeat_off", todo.Text)))
+	} else {
+		duration, err := parseDuration(interval)
+		if err != nil || duration <= 0 {
+			msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.time_format"))
+			send(bot, msg)
+			return
+		}
+		todo.RepeatEvery = &duration
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "todo.repeat_on", todo.Text, interval)))
+	}
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+const inactivityCheckSpec = "@every 15m"
+
+// inactiveSince returns when a todo was last done, falling back to when it
+// was added, and the last time the user was nudged about it, whichever is
+// later.
+func inactiveSince(todo Todo) time.Time {
+	since := todo.CreatedAt
+	if todo.LastDoneAt.After(since) {
+		since = todo.LastDoneAt
+	}
+	if todo.NudgedAt.After(since) {
+		since = todo.NudgedAt
+	}
+
+	return since
+}
+
+// checkInactivity nudges users about todos they haven't done for longer
+// than the todo's nudge threshold, at most once per threshold period.
+func checkInactivity(now time.Time, bot BotClient) {
+	changed := false
+	for chatID, userData := range todoData {
+		for i := range userData.Todos {
+			todo := &userData.Todos[i]
+			if todo.NudgeAfter == nil || now.Sub(inactiveSince(*todo)) < *todo.NudgeAfter {
+				continue
+			}
+
+			reference := todo.LastDoneAt
+			if reference.IsZero() {
+				reference = todo.CreatedAt
+			}
+			days := int(now.Sub(reference) / (24 * time.Hour))
+			msg := tgbotapi.NewMessage(chatID, trPlural(chatID, "todo.nudge", days, todo.Text, days))
+			send(bot, msg)
+
+			todo.NudgedAt = now
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := saveUserData(); err != nil {
+			slog.Error("Failed to save user data", "err", err)
+		}
+	}
+}
+
+func handleNudgeTodo(chatID int64, indexStr string, threshold string, bot BotClient) {
+	userData, exists := todoData[chatID]
+	index, err := strconv.Atoi(indexStr)
+	if !exists || err != nil || index < 1 || index > len(userData.Todos) {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	todo := &userData.Todos[index-1]
+	if threshold == "off" {
+		todo.NudgeAfter = nil
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "todo.nudge_off", todo.Text)))
+	} else {
+		duration, err := parseDuration(threshold)
+		if err != nil || duration <= 0 {
+			msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.time_format"))
+			send(bot, msg)
+			return
+		}
+		todo.NudgeAfter = &duration
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "todo.nudge_on", todo.Text, threshold)))
+	}
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func handleDueTodo(chatID int64, indexStr string, dueStr string, bot BotClient) {
+	userData, exists := todoData[chatID]
+	index, err := strconv.Atoi(indexStr)
+	if !exists || err != nil || index < 1 || index > len(userData.Todos) {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	todo := &userData.Todos[index-1]
+	if dueStr == "off" {
+		todo.Due = nil
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "todo.due_cleared", todo.Text)))
+	} else {
+		due, err := parseDateTime(dueStr, chatLocation(chatID))
+		if err != nil {
+			msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.date_format"))
+			send(bot, msg)
+			return
+		}
+		todo.Due = &due
+		send(bot, tgbotapi.NewMessage(chatID, tr(chatID, "todo.due_set", todo.Text, formatTime(chatID, due))))
+	}
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+// remindTodoAt creates a reminder for the todo at the given time and links
+// the two.
+func remindTodoAt(chatID int64, todo Todo, at time.Time, bot BotClient) (Reminder, error) {
+	reminder, err := addReminder(chatID, Reminder{Content: todo.Text, Time: at, TodoID: todo.ID})
+	if err != nil {
+		return Reminder{}, err
+	}
+	scheduleReminder(chatID, reminder, bot)
+	relinkReminder(todoData[chatID], reminder)
+
+	return reminder, nil
+}
+
+// isArmed reports whether the todo already has a pending reminder.
+func isArmed(chatID int64, todo Todo) bool {
+	return len(linkedReminders(chatID, todo)) > 0
+}
+
+// armTodos creates reminders at the due time of every todo that has one in
+// the future and no reminder yet, until the chat reaches its reminder
+// limit. It returns how many were created.
+func armTodos(chatID int64, now time.Time, bot BotClient) int {
+	userData, exists := todoData[chatID]
+	if !exists {
+		return 0
+	}
+
+	armed := 0
+	for i := range userData.Todos {
+		todo := &userData.Todos[i]
+		if todo.Due == nil || !todo.Due.After(now) || isArmed(chatID, *todo) {
+			continue
+		}
+
+		if _, err := remindTodoAt(chatID, *todo, *todo.Due, bot); err != nil {
+			break
+		}
+		armed++
+	}
+
+	return armed
+}
+
+func handleArm(chatID int64, bot BotClient) {
+	armed := armTodos(chatID, time.Now(), bot)
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "todo.armed", armed))
+	send(bot, msg)
+
+	if armed > 0 {
+		if err := saveUserData(); err != nil {
+			slog.Error("Failed to save user data", "err", err)
+		}
+	}
+}
+
+// moveTodo moves the todo at from to position to, both zero-based, shifting
+// the todos in between.
+func moveTodo(todos []Todo, from int, to int) {
+	todo := todos[from]
+	if from < to {
+		copy(todos[from:to], todos[from+1:to+1])
+	} else {
+		copy(todos[to+1:from+1], todos[to:from])
+	}
+	todos[to] = todo
+}
+
+func handleMoveTodo(chatID int64, fromStr string, toStr string, bot BotClient) {
+	userData, exists := todoData[chatID]
+	if !exists || len(userData.Todos) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "todos.empty"))
+		send(bot, msg)
+		return
+	}
+
+	from, err := strconv.Atoi(fromStr)
+	if err != nil || from < 1 || from > len(userData.Todos) {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	var to int
+	switch toStr {
+	case "top":
+		to = 1
+	case "bottom":
+		to = len(userData.Todos)
+	default:
+		to, err = strconv.Atoi(toStr)
+		if err != nil || to < 1 || to > len(userData.Todos) {
+			msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+			send(bot, msg)
+			return
+		}
+	}
+
+	moveTodo(userData.Todos, from-1, to-1)
+
+	text, keyboard := todoPage(chatID, userData, 0)
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = keyboard
+	send(bot, msg)
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+func handleEditTodo(chatID int64, indexStr string, text string, bot BotClient) {
+	userData, exists := todoData[chatID]
+	index, err := strconv.Atoi(indexStr)
+	if !exists || err != nil || index < 1 || index > len(userData.Todos) {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	if !checkQuota(chatID, len(text)-len(userData.Todos[index-1].Text), bot) {
+		return
+	}
+
+	userData.Todos[index-1].Text = text
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "todo.edited", index, text))
+	send(bot, msg)
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+const progressBarWidth = 10
+
+func progressBar(percent int) string {
+	filled := percent * progressBarWidth / 100
+	return fmt.Sprintf("%s%s %d%%", strings.Repeat("▓", filled), strings.Repeat("░", progressBarWidth-filled), percent)
+}
+
+// handleProgress records how far along a todo is, clamped to 0–100. A todo
+// that reaches 100% is marked done.
+func handleProgress(chatID int64, indexStr string, percentStr string, bot BotClient) {
+	userData, exists := todoData[chatID]
+	index, err := strconv.Atoi(indexStr)
+	if !exists || err != nil || index < 1 || index > len(userData.Todos) {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "error.invalid_index"))
+		send(bot, msg)
+		return
+	}
+
+	percent, err := strconv.Atoi(strings.TrimSuffix(percentStr, "%"))
+	if err != nil {
+		sendUsage(chatID, "progress", bot)
+		return
+	}
+	percent = min(max(percent, 0), 100)
+	if percent == 100 {
+		handleMarkDone(chatID, indexStr, bot)
+		return
+	}
+
+	todo := &userData.Todos[index-1]
+	todo.Progress = percent
+	send(bot, tgbotapi.NewMessage(chatID, fmt.Sprintf("%s: %s", todo.Text, progressBar(percent))))
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
+
+var priorityMarks = map[int]string{
+	1:  "❗ ",
+	-1: "🔽 ",
+}
+
+// parseTodoAttributes takes a leading "!high" or "!low" and a trailing
+// "due <date>" off the todo's text. Text that doesn't parse is left alone.
+func parseTodoAttributes(todo Todo, now time.Time) Todo {
+	if fields, rest := cutFields(todo.Text, 1); len(fields) == 1 && strings.HasPrefix(fields[0], "!") && rest != "" {
+		if priority, ok := priorities[fields[0][1:]]; ok {
+			todo.Priority = priority
+			todo.Text = rest
+		}
+	}
+
+	if i := strings.LastIndex(todo.Text, " due "); i > 0 {
+		dueStr := strings.TrimSpace(todo.Text[i+len(" due "):])
+		due, isPhrase, err := parseDayPhrase(dueStr, now)
+		if !isPhrase {
+			due, err = parseDateTime(dueStr, now.Location())
+		}
+		if err == nil {
+			if isPhrase && !strings.Contains(dueStr, " ") && due.Hour() == 0 && due.Minute() == 0 {
+				// A bare date is due by the end of that day.
+				due = due.Add(24*time.Hour - time.Minute)
+			}
+			todo.Due = &due
+			todo.Text = strings.TrimSpace(todo.Text[:i])
+		}
+	}
+
+	return todo
+}
+
+// sortedTodoIndexes returns the positions of the todos to show, highest
+// priority first, then by due date, keeping the list's own order otherwise.
+func sortedTodoIndexes(todos []Todo, keep func(Todo) bool) []int {
+	var indexes []int
+	for i, todo := range todos {
+		if keep == nil || keep(todo) {
+			indexes = append(indexes, i)
+		}
+	}
+
+	sort.SliceStable(indexes, func(a, b int) bool {
+		x, y := todos[indexes[a]], todos[indexes[b]]
+		if x.Priority != y.Priority {
+			return x.Priority > y.Priority
+		}
+		if x.Due == nil || y.Due == nil {
+			return x.Due != nil && y.Due == nil
+		}
+		return x.Due.Before(*y.Due)
+	})
+
+	return indexes
+}
+
+func handleTodoFilter(chatID int64, filter string, bot BotClient) {
+	now := time.Now()
+	keep := func(todo Todo) bool { return todo.Due != nil && todo.Due.Before(now) }
+	title, empty := tr(chatID, "todos.overdue_title")+"\n", tr(chatID, "todos.overdue_empty")
+	if filter == "today" {
+		local := now.In(chatLocation(chatID))
+		end := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, local.Location())
+		keep = func(todo Todo) bool { return todo.Due != nil && todo.Due.Before(end) }
+		title, empty = tr(chatID, "todos.today_title")+"\n", tr(chatID, "todos.today_empty")
+	}
+
+	var indexes []int
+	userData, exists := todoData[chatID]
+	if exists {
+		indexes = sortedTodoIndexes(userData.Todos, keep)
+	}
+	if len(indexes) == 0 {
+		send(bot, tgbotapi.NewMessage(chatID, empty))
+		return
+	}
+
+	send(bot, tgbotapi.NewMessage(chatID, title+renderTodos(chatID, userData.Todos, indexes)))
+}
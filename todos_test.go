@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFormatAge(t *testing.T) {
+	useTestData(t)
+	const chatID = 10001
+	todoData[chatID] = &UserData{Todos: []Todo{}, Settings: Settings{Language: "en"}}
+	now := time.Date(2024, time.March, 14, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		createdAt time.Time
+		want      string
+	}{
+		{time.Time{}, "added at an unknown time"},
+		{now.Add(-59 * time.Minute), "added just now"},
+		{now.Add(-time.Hour), "added 1 hour ago"},
+		{now.Add(-23 * time.Hour), "added 23 hours ago"},
+		{now.Add(-24 * time.Hour), "added 1 day ago"},
+		{now.AddDate(0, 0, -40), "added 40 days ago"},
+	}
+	for _, test := range tests {
+		if got := formatAge(chatID, test.createdAt, now); got != test.want {
+			t.Errorf("formatAge(%v) = %q, want %q", now.Sub(test.createdAt), got, test.want)
+		}
+	}
+}
+
+// Todos saved before they had a creation time were plain strings; they
+// still load, with the time left unknown.
+func TestTodoMigratesPlainStrings(t *testing.T) {
+	raw := `{"todos": ["buy milk", {"id": 2, "text": "call the bank", "created_at": "2024-03-14T10:30:00Z"}]}`
+	var userData UserData
+	if err := json.Unmarshal([]byte(raw), &userData); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if len(userData.Todos) != 2 {
+		t.Fatalf("loaded %d todos, want 2", len(userData.Todos))
+	}
+	if old := userData.Todos[0]; old.Text != "buy milk" || !old.CreatedAt.IsZero() {
+		t.Errorf("plain string loaded as %+v", old)
+	}
+	if current := userData.Todos[1]; current.ID != 2 || !current.CreatedAt.Equal(time.Date(2024, time.March, 14, 10, 30, 0, 0, time.UTC)) {
+		t.Errorf("todo object loaded as %+v", current)
+	}
+}
+
+func TestRepeatTodoSetting(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+
+	week := 7 * 24 * time.Hour
+	tests := []struct {
+		args string
+		want *time.Duration
+	}{
+		{"1 1w", &week},
+		{"1 off", nil},
+		{"1 often", &week},
+		{"1 0h", &week},
+		{"2 1d", &week},
+	}
+	for i, test := range tests {
+		chatID := int64(10101 + i)
+		todoData[chatID] = &UserData{Todos: []Todo{{ID: 1, Text: "water the plants", RepeatEvery: &week}}, NextTodoID: 1}
+
+		dispatch(command(chatID, "/repeat "+test.args), bot)
+		got := todoData[chatID].Todos[0].RepeatEvery
+		if (got == nil) != (test.want == nil) || got != nil && *got != *test.want {
+			t.Errorf("/repeat %s: repeats every %v, want %v", test.args, got, test.want)
+		}
+	}
+}
+
+// A repeating todo leaves the list when done and comes back once its
+// interval has passed, still repeating.
+func TestRepeatingTodoComesBack(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 10111
+
+	every := 200 * time.Millisecond
+	todoData[chatID] = &UserData{Todos: []Todo{{ID: 1, Text: "water the plants", RepeatEvery: &every}}, NextTodoID: 1}
+
+	dispatch(command(chatID, "/done 1"), bot)
+	dataMu.Lock()
+	done := len(todoData[chatID].Todos) == 0 && len(todoData[chatID].ScheduledTodos) == 1
+	dataMu.Unlock()
+	if !done {
+		t.Fatalf("after /done: todos %+v, scheduled %+v", todoData[chatID].Todos, todoData[chatID].ScheduledTodos)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		dataMu.Lock()
+		todos, scheduled := append([]Todo(nil), todoData[chatID].Todos...), len(todoData[chatID].ScheduledTodos)
+		dataMu.Unlock()
+		if len(todos) == 1 {
+			if todos[0].Text != "water the plants" || todos[0].RepeatEvery == nil || *todos[0].RepeatEvery != every {
+				t.Errorf("came back as %+v", todos[0])
+			}
+			if scheduled != 0 {
+				t.Errorf("%d re-adds still scheduled", scheduled)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("todo never came back")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestProgressBar(t *testing.T) {
+	for percent, want := range map[int]string{
+		0:   "░░░░░░░░░░ 0%",
+		5:   "░░░░░░░░░░ 5%",
+		40:  "▓▓▓▓░░░░░░ 40%",
+		99:  "▓▓▓▓▓▓▓▓▓░ 99%",
+		100: "▓▓▓▓▓▓▓▓▓▓ 100%",
+	} {
+		if got := progressBar(percent); got != want {
+			t.Errorf("progressBar(%d) = %q, want %q", percent, got, want)
+		}
+	}
+}
+
+// /progress clamps to 0–100 and a todo that reaches 100% is done.
+func TestProgressCommand(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+
+	tests := []struct {
+		args     string
+		progress int
+		done     bool
+	}{
+		{"1 40", 40, false},
+		{"1 40%", 40, false},
+		{"1 -20", 0, false},
+		{"1 half", 10, false},
+		{"2 40", 10, false},
+		{"1 100", 0, true},
+		{"1 250%", 0, true},
+	}
+	for i, test := range tests {
+		chatID := int64(10841 + i)
+		todoData[chatID] = &UserData{Todos: []Todo{{ID: 1, Text: "write the report", Progress: 10}}, NextTodoID: 1}
+
+		dispatch(command(chatID, "/progress "+test.args), bot)
+		todos := todoData[chatID].Todos
+		if done := len(todos) == 0; done != test.done {
+			t.Errorf("/progress %s: done %v, want %v", test.args, done, test.done)
+			continue
+		}
+		if !test.done && todos[0].Progress != test.progress {
+			t.Errorf("/progress %s: progress %d, want %d", test.args, todos[0].Progress, test.progress)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"log/slog"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Cancelled reminders can be brought back with /undo for this long before
+// they are purged for good.
+const undoGracePeriod = 10 * time.Minute
+
+const purgeDeletedSpec = "@every 1m"
+
+type DeletedReminder struct {
+	Reminder  Reminder  `json:"reminder"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+// cancelReminders removes the matching reminders like removeReminders, but
+// keeps them aside so that /undo can restore them within the grace period.
+func cancelReminders(chatID int64, match func(Reminder) bool) int {
+	userData, exists := todoData[chatID]
+	if !exists {
+		return 0
+	}
+
+	now := time.Now()
+	return removeReminders(chatID, func(reminder Reminder) bool {
+		if !match(reminder) {
+			return false
+		}
+		userData.Deleted = append(userData.Deleted, DeletedReminder{Reminder: reminder, DeletedAt: now})
+		return true
+	})
+}
+
+func purgeExpiredDeletions(userData *UserData, now time.Time) bool {
+	kept := userData.Deleted[:0]
+	for _, deleted := range userData.Deleted {
+		if now.Sub(deleted.DeletedAt) < undoGracePeriod {
+			kept = append(kept, deleted)
+		}
+	}
+	purged := len(kept) != len(userData.Deleted)
+	userData.Deleted = kept
+
+	return purged
+}
+
+func purgeDeleted(now time.Time) {
+	purged := false
+	for _, userData := range todoData {
+		if purgeExpiredDeletions(userData, now) {
+			purged = true
+		}
+	}
+
+	if purged {
+		if err := saveUserData(); err != nil {
+			slog.Error("Failed to save user data", "err", err)
+		}
+	}
+}
+
+// undoCancellation restores the most recently cancelled batch of reminders
+// and returns them.
+func undoCancellation(chatID int64, now time.Time) []Reminder {
+	userData, exists := todoData[chatID]
+	if !exists {
+		return nil
+	}
+
+	purgeExpiredDeletions(userData, now)
+	if len(userData.Deleted) == 0 {
+		return nil
+	}
+
+	last := userData.Deleted[len(userData.Deleted)-1].DeletedAt
+	var restored []Reminder
+	kept := userData.Deleted[:0]
+	for _, deleted := range userData.Deleted {
+		if deleted.DeletedAt.Equal(last) {
+			restored = append(restored, deleted.Reminder)
+		} else {
+			kept = append(kept, deleted)
+		}
+	}
+	userData.Deleted = kept
+	userData.Reminders = append(userData.Reminders, restored...)
+	for _, reminder := range restored {
+		relinkReminder(userData, reminder)
+	}
+
+	return restored
+}
+
+func handleUndo(chatID int64, bot BotClient) {
+	restored := undoCancellation(chatID, time.Now())
+	if len(restored) == 0 {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "undo.empty"))
+		send(bot, msg)
+		return
+	}
+
+	for _, reminder := range restored {
+		scheduleReminder(chatID, reminder, bot)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, tr(chatID, "undo.restored", len(restored)))
+	send(bot, msg)
+
+	if err := saveUserData(); err != nil {
+		slog.Error("Failed to save user data", "err", err)
+	}
+}
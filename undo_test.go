@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// Cancelled reminders come back with /undo within the grace period, the
+// latest batch first, and are gone for good after it.
+func TestUndoCancellation(t *testing.T) {
+	useTestData(t)
+	cancelledAt := time.Date(2024, time.March, 14, 9, 0, 0, 0, time.UTC)
+	earlier := cancelledAt.Add(-time.Minute)
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    string
+		left    int
+	}{
+		{"right away", 0, "[pay rent call mom]", 1},
+		{"just in time", undoGracePeriod - time.Minute, "[pay rent call mom]", 0},
+		{"too late", undoGracePeriod, "[]", 0},
+	}
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chatID := int64(10601 + i)
+			todoData[chatID] = &UserData{
+				Todos: []Todo{},
+				Deleted: []DeletedReminder{
+					{Reminder: Reminder{ID: 1, Content: "stretch"}, DeletedAt: earlier},
+					{Reminder: Reminder{ID: 2, Content: "pay rent"}, DeletedAt: cancelledAt},
+					{Reminder: Reminder{ID: 3, Content: "call mom"}, DeletedAt: cancelledAt},
+				},
+			}
+
+			var restored []string
+			for _, reminder := range undoCancellation(chatID, cancelledAt.Add(test.elapsed)) {
+				restored = append(restored, reminder.Content)
+			}
+			if got := fmt.Sprint(restored); got != test.want {
+				t.Errorf("restored %s, want %s", got, test.want)
+			}
+			if got := len(todoData[chatID].Deleted); got != test.left {
+				t.Errorf("%d cancellations left to undo, want %d", got, test.left)
+			}
+			if got := len(todoData[chatID].Reminders); got != len(restored) {
+				t.Errorf("%d reminders pending after restoring %d", got, len(restored))
+			}
+		})
+	}
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	useTestData(t)
+	now := time.Date(2024, time.March, 14, 9, 0, 0, 0, time.UTC)
+	todoData[10611] = &UserData{Todos: []Todo{}, Deleted: []DeletedReminder{
+		{Reminder: Reminder{ID: 1, Content: "old"}, DeletedAt: now.Add(-undoGracePeriod)},
+		{Reminder: Reminder{ID: 2, Content: "fresh"}, DeletedAt: now.Add(-time.Minute)},
+	}}
+	todoData[10612] = &UserData{Todos: []Todo{}, Deleted: []DeletedReminder{
+		{Reminder: Reminder{ID: 1, Content: "older"}, DeletedAt: now.Add(-time.Hour)},
+	}}
+
+	purgeDeleted(now)
+	if deleted := todoData[10611].Deleted; len(deleted) != 1 || deleted[0].Reminder.Content != "fresh" {
+		t.Errorf("first chat kept %+v, want only the fresh cancellation", deleted)
+	}
+	if deleted := todoData[10612].Deleted; len(deleted) != 0 {
+		t.Errorf("second chat kept %+v", deleted)
+	}
+}
+
+func TestUndoCommand(t *testing.T) {
+	fake := newFakeBotAPI(t)
+	bot := fake.bot()
+	useTestData(t)
+	const chatID = 10621
+
+	todoData[chatID] = &UserData{Todos: []Todo{}, Reminders: []Reminder{{ID: 1, Content: "call mom", Time: time.Now().Add(time.Hour)}}, NextReminderID: 1}
+	dispatch(command(chatID, "/cancel 1"), bot)
+	if len(pendingReminders(chatID)) != 0 {
+		t.Fatal("/cancel kept the reminder")
+	}
+
+	dispatch(command(chatID, "/undo"), bot)
+	if reminders := pendingReminders(chatID); len(reminders) != 1 || reminders[0].Content != "call mom" {
+		t.Errorf("after /undo: %+v", reminders)
+	}
+	if _, scheduled := reminderTimers[reminderKey{ChatID: chatID, ReminderID: 1}]; !scheduled {
+		t.Error("restored reminder has no timer")
+	}
+}
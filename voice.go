@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	maxVoiceSize      = 5 << 20
+	transcribeTimeout = 30 * time.Second
+)
+
+// Transcriber turns recorded speech into text.
+type Transcriber interface {
+	Transcribe(audio []byte, mimeType string) (string, error)
+}
+
+// transcriber is nil unless a transcription service is configured, in which
+// case voice messages are not understood.
+var transcriber Transcriber
+
+// HTTPTranscriber posts the audio to a speech-to-text service that answers
+// with {"text": "..."}.
+type HTTPTranscriber struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewHTTPTranscriber(url string) *HTTPTranscriber {
+	return &HTTPTranscriber{URL: url, Client: &http.Client{Timeout: transcribeTimeout}}
+}
+
+func (t *HTTPTranscriber) Transcribe(audio []byte, mimeType string) (string, error) {
+	resp, err := t.Client.Post(t.URL, mimeType, bytes.NewReader(audio))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("transcription failed: %s", resp.Status)
+	}
+
+	var result struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	return result.Text, nil
+}
+
+// parseVoiceReminder reads a transcript like "remind 10m buy milk" into the
+// time and content of a reminder.
+func parseVoiceReminder(transcript string) (string, string, bool) {
+	fields, content := cutFields(strings.TrimSpace(transcript), 2)
+	if len(fields) < 2 || content == "" {
+		return "", "", false
+	}
+
+	command := strings.ToLower(strings.Trim(fields[0], "/.,!"))
+	if command != "remind" && command != "нагадай" {
+		return "", "", false
+	}
+
+	return strings.Trim(fields[1], ".,"), content, true
+}
+
+func handleVoice(chatID int64, message *tgbotapi.Message, bot BotClient) {
+	if transcriber == nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "voice.disabled"))
+		send(bot, msg)
+		return
+	}
+
+	var transcript string
+	var downloadErr, err error
+	unlocked(func() {
+		var audio []byte
+		audio, downloadErr = downloadFile(bot, message.Voice.FileID, maxVoiceSize)
+		if downloadErr == nil {
+			transcript, err = transcriber.Transcribe(audio, message.Voice.MimeType)
+		}
+	})
+	if downloadErr != nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "voice.download_failed", downloadErr))
+		send(bot, msg)
+		return
+	}
+	if err != nil {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "voice.recognize_failed", err))
+		send(bot, msg)
+		return
+	}
+
+	timeStr, content, ok := parseVoiceReminder(transcript)
+	if !ok {
+		msg := tgbotapi.NewMessage(chatID, tr(chatID, "voice.not_command", transcript))
+		send(bot, msg)
+		return
+	}
+
+	handleReminder(chatID, senderID(message), timeStr, Reminder{Content: content, SourceMessageID: message.MessageID}, bot)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const updateWatchdogThreshold = 30 * time.Second
+
+// watchUpdate starts a watchdog for processing a single update. If the
+// returned func isn't called within the threshold, a warning is logged
+// while the handler is still stuck, and again once it finally finishes.
+func watchUpdate(update tgbotapi.Update) func() {
+	start := time.Now()
+	timer := time.AfterFunc(updateWatchdogThreshold, func() {
+		chatLog(updateChatID(update)).Warn("Update still processing",
+			"update_id", update.UpdateID, "after", updateWatchdogThreshold)
+	})
+
+	return func() {
+		if !timer.Stop() {
+			chatLog(updateChatID(update)).Warn("Slow update finished",
+				"update_id", update.UpdateID, "after", time.Since(start).Round(time.Millisecond))
+		}
+	}
+}
+
+func updateChatID(update tgbotapi.Update) int64 {
+	if chat := update.FromChat(); chat != nil {
+		return chat.ID
+	}
+	// Inline updates come from no chat; the user's private chat with the
+	// bot has the same ID as the user.
+	if update.InlineQuery != nil {
+		return update.InlineQuery.From.ID
+	}
+	if update.ChosenInlineResult != nil {
+		return update.ChosenInlineResult.From.ID
+	}
+
+	return 0
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const webhookShutdownTimeout = 10 * time.Second
+
+// secretTokenHeader carries the secret_token given to setWebhook on every
+// update Telegram posts.
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// startPolling receives updates with long polling. A webhook left over from
+// an earlier run is removed first, since Telegram refuses getUpdates while
+// one is set.
+func startPolling(bot *tgbotapi.BotAPI, timeout int) (tgbotapi.UpdatesChannel, func()) {
+	if _, err := requestDirect(bot, tgbotapi.DeleteWebhookConfig{}); err != nil {
+		slog.Error("Failed to delete webhook", "err", err)
+	}
+
+	u := tgbotapi.NewUpdate(0)
+	u.Timeout = timeout
+
+	return bot.GetUpdatesChan(u), bot.StopReceivingUpdates
+}
+
+// newWebhookSecret returns a random secret token for runs where
+// WEBHOOK_SECRET isn't set. Telegram forgets it with the webhook, so a new
+// one each start is fine.
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// setWebhook registers the webhook with its secret token. The library's
+// WebhookConfig has no field for secret_token, so the request is built by
+// hand.
+func setWebhook(bot *tgbotapi.BotAPI, cfg Config, secret string) error {
+	params := tgbotapi.Params{"url": cfg.WebhookURL, "secret_token": secret}
+	var err error
+	if cfg.WebhookCert != "" {
+		files := []tgbotapi.RequestFile{{Name: "certificate", Data: tgbotapi.FilePath(cfg.WebhookCert)}}
+		_, err = bot.UploadFiles("setWebhook", params, files)
+	} else {
+		_, err = bot.MakeRequest("setWebhook", params)
+	}
+
+	return err
+}
+
+// startWebhook registers cfg.WebhookURL with Telegram and serves updates on
+// cfg.WebhookListen at the URL's path. Without a certificate it serves plain
+// HTTP for a reverse proxy to terminate TLS; with one it serves HTTPS itself
+// and uploads the certificate so self-signed ones work. Requests without
+// the secret token are rejected, so only Telegram can post updates. The
+// returned stop function waits for running requests and then closes the
+// channel.
+func startWebhook(bot *tgbotapi.BotAPI, cfg Config) (tgbotapi.UpdatesChannel, func(), error) {
+	webhookURL, err := url.Parse(cfg.WebhookURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	secret := cfg.WebhookSecret
+	if secret == "" {
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	listener, err := net.Listen("tcp", cfg.WebhookListen)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updates := make(chan tgbotapi.Update, bot.Buffer)
+	stopped := make(chan struct{})
+	var handling sync.WaitGroup
+	path := webhookURL.Path
+	if path == "" {
+		path = "/"
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		handling.Add(1)
+		defer handling.Done()
+
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get(secretTokenHeader)), []byte(secret)) != 1 {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		update, err := bot.HandleUpdate(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		select {
+		case updates <- *update:
+		case <-stopped:
+			// Telegram will deliver it again after the restart.
+			http.Error(w, "shutting down", http.StatusServiceUnavailable)
+		}
+	})
+	server := &http.Server{Handler: mux}
+
+	go func() {
+		var err error
+		if cfg.WebhookCert != "" {
+			err = server.ServeTLS(listener, cfg.WebhookCert, cfg.WebhookKey)
+		} else {
+			err = server.Serve(listener)
+		}
+		if !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Webhook server stopped", "err", err)
+		}
+	}()
+
+	if err := setWebhook(bot, cfg, secret); err != nil {
+		server.Close()
+		return nil, nil, err
+	}
+	slog.Info("Listening for webhook updates", "addr", cfg.WebhookListen, "path", path)
+
+	stop := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			slog.Error("Failed to stop webhook server", "err", err)
+		}
+		close(stopped)
+		handling.Wait()
+		close(updates)
+	}
+
+	return updates, stop, nil
+}
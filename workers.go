@@ -0,0 +1,94 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	chatQueueSize  = 64
+	chatWorkerIdle = time.Minute
+)
+
+// chatWorkers runs one goroutine per active chat, so updates of a chat are
+// handled in order while different chats don't wait in line behind each
+// other. A worker exits after being idle for chatWorkerIdle.
+type chatWorkers struct {
+	mu      sync.Mutex
+	queues  map[int64]chan tgbotapi.Update
+	running sync.WaitGroup
+	handle  func(tgbotapi.Update)
+}
+
+func newChatWorkers(handle func(tgbotapi.Update)) *chatWorkers {
+	return &chatWorkers{
+		queues: make(map[int64]chan tgbotapi.Update),
+		handle: handle,
+	}
+}
+
+// Dispatch queues the update for its chat, starting a worker if none is
+// running. It blocks only when that chat's queue is full. The queue is
+// written under mu so an idle worker can't exit with the update unread.
+func (w *chatWorkers) Dispatch(update tgbotapi.Update) {
+	chatID := updateChatID(update)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	queue, exists := w.queues[chatID]
+	if !exists {
+		queue = make(chan tgbotapi.Update, chatQueueSize)
+		w.queues[chatID] = queue
+		w.running.Add(1)
+		go w.run(chatID, queue)
+	}
+
+	queue <- update
+}
+
+func (w *chatWorkers) run(chatID int64, queue chan tgbotapi.Update) {
+	defer w.running.Done()
+
+	idle := time.NewTimer(chatWorkerIdle)
+	defer idle.Stop()
+	for {
+		select {
+		case update, ok := <-queue:
+			if !ok {
+				return
+			}
+			w.handle(update)
+			idle.Reset(chatWorkerIdle)
+		case <-idle.C:
+			// Dispatch may be holding mu while it waits for room in this
+			// very queue, so only exit if mu is free and nothing is queued.
+			if !w.mu.TryLock() {
+				idle.Reset(chatWorkerIdle)
+				continue
+			}
+			if len(queue) > 0 {
+				w.mu.Unlock()
+				idle.Reset(chatWorkerIdle)
+				continue
+			}
+			delete(w.queues, chatID)
+			w.mu.Unlock()
+			return
+		}
+	}
+}
+
+// Wait blocks until every queued update has been handled. No updates may be
+// dispatched once it has been called.
+func (w *chatWorkers) Wait() {
+	w.mu.Lock()
+	for chatID, queue := range w.queues {
+		close(queue)
+		delete(w.queues, chatID)
+	}
+	w.mu.Unlock()
+
+	w.running.Wait()
+}